@@ -2,11 +2,30 @@ package main
 
 import (
 	"AvitoInternship/internal/config"
+	"AvitoInternship/internal/grpcapi"
 	"AvitoInternship/internal/handlers"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"AvitoInternship/internal/integrations/chat"
+	"AvitoInternship/internal/integrations/email"
+	"AvitoInternship/internal/integrations/github"
+	"AvitoInternship/internal/jobs"
+	"AvitoInternship/internal/outbox"
 	"AvitoInternship/internal/repository/db"
+	"AvitoInternship/internal/subscriptions"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata"
+
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -25,9 +44,185 @@ func main() {
 		return
 	}
 	defer database.Close()
-	router := handlers.SetupRouter(database)
+
+	// назначения дублируются в GitHub, только если задан токен
+	var listener pullRequest.AssignmentListener
+	var reviewerSync *github.ReviewerSync
+	if cfg.GITHUB_TOKEN != "" {
+		client := github.NewClient(cfg.GITHUB_API_URL, cfg.GITHUB_TOKEN)
+		reviewerSync = github.NewReviewerSync(database, client, cfg.GITHUB_SYNC_ATTEMPTS, cfg.GITHUB_SYNC_BACKOFF, cfg.GITHUB_SYNC_WORKERS)
+		listener = reviewerSync
+	}
+
+	// SIGINT/SIGTERM останавливает фоновые задачи и запускает graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if cfg.VACATION_REASSIGN_ENABLED {
+		go jobs.NewVacationReassigner(database, cfg.VACATION_REASSIGN_INTERVAL, listener).Run(ctx)
+	}
+
+	if cfg.STALE_REVIEWS_ENABLED {
+		go jobs.NewStaleReviewEscalator(database, cfg.STALE_REVIEWS_INTERVAL, cfg.REVIEW_REMINDER_HOURS, cfg.REVIEW_ESCALATION_HOURS, listener).Run(ctx)
+	}
+
+	if cfg.DIGEST_ENABLED {
+		digest, err := digestMailer(cfg, database)
+		if err != nil {
+			log.Println("failed to configure digest:", err)
+			return
+		}
+		go digest.Run(ctx)
+	}
+
+	if cfg.OUTBOX_ENABLED {
+		sink, err := outboxSink(cfg, database)
+		if err != nil {
+			log.Println("failed to configure outbox:", err)
+			return
+		}
+		// подписки из /subscriptions/add получают события всегда, когда включен outbox
+		sinks := outbox.MultiSink{sink, subscriptions.NewSink(database)}
+		if cfg.CHAT_WEBHOOK_URL != "" {
+			notifier, deliverer, err := chatNotifier(cfg, database)
+			if err != nil {
+				log.Println("failed to configure chat notifications:", err)
+				return
+			}
+			sinks = append(sinks, notifier)
+			go deliverer.Run(ctx)
+		}
+		go outbox.NewDispatcher(database, sinks, cfg.OUTBOX_POLL_INTERVAL, cfg.OUTBOX_BATCH_SIZE, cfg.OUTBOX_MAX_ATTEMPTS).Run(ctx)
+		go subscriptions.NewDeliverer(database, cfg.OUTBOX_POLL_INTERVAL, cfg.SUBSCRIPTION_ATTEMPTS, cfg.SUBSCRIPTION_BACKOFF).Run(ctx)
+	} else if err := outbox.StopDispatch(ctx, database); err != nil {
+		// при включении outbox доставка начнется с текущей позиции, а не со всей истории
+		log.Println("failed to stop outbox dispatch:", err)
+		return
+	}
+	// события пишутся всегда, поэтому и очищаются независимо от OUTBOX_ENABLED
+	go outbox.NewCleaner(database, cfg.OUTBOX_RETENTION).Run(ctx)
+
+	// gRPC поднимается на отдельном порту, если он задан
+	var grpcServer *grpc.Server
+	if cfg.GRPC_PORT != "" {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPC_PORT)
+		if err != nil {
+			log.Println("failed to listen grpc port:", err)
+			return
+		}
+		grpcServer = grpcapi.Register(database, listener)
+		go func() {
+			log.Printf("gRPC server starting on port %s", cfg.GRPC_PORT)
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Println("grpc server stopped:", err)
+			}
+		}()
+	}
+
+	server := &http.Server{Addr: ":" + cfg.APP_PORT, Handler: handlers.SetupRouter(database, cfg, listener)}
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdown(server, grpcServer, reviewerSync, cfg.SHUTDOWN_TIMEOUT)
+	}()
+
 	log.Printf("Server starting on port %s", cfg.APP_PORT)
-	if err := http.ListenAndServe(":"+cfg.APP_PORT, router); err != nil {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal("failed to start server:", err)
 	}
+	<-shutdownDone
+}
+
+// shutdown дожидается текущих HTTP- и gRPC-запросов и очереди синхронизации
+// с GitHub, но не дольше timeout; после этого соединения закрываются.
+func shutdown(server *http.Server, grpcServer *grpc.Server, reviewerSync *github.ReviewerSync, timeout time.Duration) {
+	log.Println("shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Println("http server shutdown:", err)
+	}
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
+	}
+	if reviewerSync != nil {
+		synced := make(chan struct{})
+		go func() {
+			reviewerSync.Wait()
+			close(synced)
+		}()
+		select {
+		case <-synced:
+		case <-ctx.Done():
+			log.Println("github reviewer sync: pending actions dropped on shutdown")
+		}
+	}
+}
+
+func outboxSink(cfg *config.Config, database *sql.DB) (outbox.Sink, error) {
+	var sinks outbox.MultiSink
+	for _, kind := range cfg.OUTBOX_SINKS {
+		switch kind {
+		case "stdout":
+			sinks = append(sinks, outbox.NewStdoutSink())
+		case "file":
+			if cfg.OUTBOX_FILE_PATH == "" {
+				return nil, fmt.Errorf("OUTBOX_FILE_PATH is required for file sink")
+			}
+			sinks = append(sinks, outbox.NewFileSink(cfg.OUTBOX_FILE_PATH))
+		case "http":
+			if cfg.OUTBOX_HTTP_URL == "" {
+				return nil, fmt.Errorf("OUTBOX_HTTP_URL is required for http sink")
+			}
+			sinks = append(sinks, outbox.NewHTTPSink(cfg.OUTBOX_HTTP_URL))
+		default:
+			return nil, fmt.Errorf("unknown outbox sink %q", kind)
+		}
+	}
+	if len(sinks) == 0 {
+		sinks = append(sinks, outbox.NewStdoutSink())
+	}
+	return sinks, nil
+}
+
+// chatNotifier: Notifier кладет сообщения в очередь, Deliverer отправляет их в чат
+func chatNotifier(cfg *config.Config, database *sql.DB) (*chat.Notifier, *chat.Deliverer, error) {
+	client, err := chat.NewClient(cfg.CHAT_WEBHOOK_URL, cfg.CHAT_FORMAT)
+	if err != nil {
+		return nil, nil, err
+	}
+	notifier, err := chat.NewNotifier(database, client, cfg.CHAT_TEMPLATE_FILE)
+	if err != nil {
+		return nil, nil, err
+	}
+	deliverer := chat.NewDeliverer(database, client, cfg.OUTBOX_POLL_INTERVAL, cfg.CHAT_ATTEMPTS, cfg.CHAT_BACKOFF)
+	return notifier, deliverer, nil
+}
+
+// digestMailer при заданном DIGEST_DRY_RUN_DIR пишет письма в каталог вместо SMTP
+func digestMailer(cfg *config.Config, database *sql.DB) (*jobs.DigestMailer, error) {
+	loc, err := time.LoadLocation(cfg.DIGEST_TIME_ZONE)
+	if err != nil {
+		return nil, err
+	}
+	var sender email.Sender
+	switch {
+	case cfg.DIGEST_DRY_RUN_DIR != "":
+		sender = email.NewDirSender(cfg.DIGEST_DRY_RUN_DIR)
+	case cfg.SMTP_HOST != "":
+		sender = email.NewSMTPSender(cfg.SMTP_HOST, cfg.SMTP_PORT, cfg.SMTP_USERNAME, cfg.SMTP_PASSWORD)
+	default:
+		return nil, fmt.Errorf("SMTP_HOST or DIGEST_DRY_RUN_DIR is required")
+	}
+	return jobs.NewDigestMailer(database, sender, cfg.DIGEST_FROM, cfg.DIGEST_TIME, loc)
 }
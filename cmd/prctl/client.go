@@ -0,0 +1,152 @@
+package main
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// коды выхода; ошибки API отображаются в свой код по коду ошибки
+const (
+	exitOK       = 0
+	exitInternal = 1
+	exitUsage    = 2
+)
+
+var exitCodes = map[string]int{
+	dto.ErrorBadRequest:           10,
+	dto.ErrorCodeValidation:       11,
+	dto.ErrorMethodNotAllowed:     12,
+	dto.ErrorCodeInvalidSignature: 13,
+
+	dto.ErrorCodeNotFound:             20,
+	dto.ErrorCodeUserNotFound:         21,
+	dto.ErrorCodeTeamNotFound:         22,
+	dto.ErrorCodePullRequestNotFound:  23,
+	dto.ErrorCodeSubscriptionNotFound: 24,
+
+	dto.ErrorCodeTeamExists:        30,
+	dto.ErrorCodePRExists:          31,
+	dto.ErrorCodePRMerged:          32,
+	dto.ErrorCodeNotAssigned:       33,
+	dto.ErrorCodeNoCandidate:       34,
+	dto.ErrorCodeTeamHasOpenPRs:    35,
+	dto.ErrorCodeMemberOfOtherTeam: 36,
+	dto.ErrorCodeLoginTaken:        37,
+	dto.ErrorCodeImportFailed:      38,
+	dto.ErrorCodeUserHasOpenPRs:    39,
+	dto.ErrorCodeOutboxDisabled:    40,
+}
+
+// apiError - ответ API с ErrorResponse.
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+	Details json.RawMessage
+}
+
+func (e *apiError) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Code, e.Message)
+	if len(e.Details) > 0 && string(e.Details) != "null" {
+		msg += " " + string(e.Details)
+	}
+	return msg
+}
+
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func usageErrorf(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func exitCode(err error) int {
+	var apiErr *apiError
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &apiErr):
+		if code, ok := exitCodes[apiErr.Code]; ok {
+			return code
+		}
+		return exitInternal
+	case errors.As(err, &usageErr):
+		return exitUsage
+	default:
+		return exitInternal
+	}
+}
+
+type apiClient struct {
+	base string
+	http *http.Client
+}
+
+func newAPIClient(base string, client *http.Client) *apiClient {
+	return &apiClient{base: strings.TrimRight(base, "/"), http: client}
+}
+
+// do отправляет запрос с телом body (JSON, если не nil) и разбирает ответ 2xx в
+// out. Возвращает тело ответа как есть для вывода в -o json.
+func (e *env) do(method, path string, query url.Values, body, out any) ([]byte, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	u := e.api.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(e.ctx, method, u, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := e.api.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		var errResp struct {
+			Error struct {
+				Code    string          `json:"code"`
+				Message string          `json:"message"`
+				Details json.RawMessage `json:"details"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(raw, &errResp); err != nil || errResp.Error.Code == "" {
+			return nil, fmt.Errorf("%s %s: unexpected status %s", method, path, resp.Status)
+		}
+		return nil, &apiError{Status: resp.StatusCode, Code: errResp.Error.Code, Message: errResp.Error.Message, Details: errResp.Error.Details}
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return nil, fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+		}
+	}
+	return raw, nil
+}
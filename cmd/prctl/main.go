@@ -0,0 +1,157 @@
+// prctl - консольная утилита для операторов сервиса. Команды team, user и pr
+// работают через HTTP API, stats и migrate - напрямую с базой.
+package main
+
+import (
+	"AvitoInternship/internal/config"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const usage = `usage: prctl [flags] <command> [args]
+
+commands:
+  team add <team> [-member id:name[:is_active]]...
+  team get <team> [-active-only]
+  team sync <team> -f members.json [-missing deactivate|remove] [-dry-run]
+  user activate <user_id>
+  user deactivate <user_id>
+  pr create <pr_id> -name <name> -author <user_id> [-repository <repo>] [-mode working_hours]
+  pr merge <pr_id>
+  pr reassign <pr_id> -old <user_id> [-mode working_hours]
+  pr list [-status OPEN|MERGED] [-author <user_id>] [-team <team>] [-repository <repo>] [-sort s] [-limit n] [-cursor c]
+  stats [-team <team>]
+  migrate [up [n] | down [n] | force <version> | version] [-path migrations]
+
+flags:
+`
+
+// env - общие для команд настройки из глобальных флагов.
+type env struct {
+	ctx    context.Context
+	api    *apiClient
+	dsn    string
+	json   bool
+	stdout io.Writer
+}
+
+type command struct {
+	group string
+	name  string
+	run   func(e *env, args []string) error
+	// без ограничения -timeout: миграция может идти дольше любого запроса
+	noDeadline bool
+}
+
+var commands = []command{
+	{"team", "add", teamAdd, false},
+	{"team", "get", teamGet, false},
+	{"team", "sync", teamSync, false},
+	{"user", "activate", userSetActive(true), false},
+	{"user", "deactivate", userSetActive(false), false},
+	{"pr", "create", prCreate, false},
+	{"pr", "merge", prMerge, false},
+	{"pr", "reassign", prReassign, false},
+	{"pr", "list", prList, false},
+	{"stats", "", stats, false},
+	{"migrate", "", migrate, true},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	fs := flag.NewFlagSet("prctl", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", envOr("PRCTL_ADDR", "http://localhost:8080"), "service base URL (env PRCTL_ADDR)")
+	dsn := fs.String("dsn", os.Getenv("DB_DSN"), "database DSN for stats and migrate (env DB_DSN, default from deploy/.env)")
+	output := fs.String("o", "table", "output format: table or json")
+	timeout := fs.Duration("timeout", 30*time.Second, "request timeout (not applied to migrate)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "prctl: unknown output format %q\n", *output)
+		return exitUsage
+	}
+
+	cmd, rest, ok := findCommand(fs.Args())
+	if !ok {
+		fs.Usage()
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if !cmd.noDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	e := &env{
+		ctx:    ctx,
+		api:    newAPIClient(*addr, &http.Client{}),
+		dsn:    *dsn,
+		json:   *output == "json",
+		stdout: os.Stdout,
+	}
+	if err := cmd.run(e, rest); err != nil {
+		fmt.Fprintln(os.Stderr, "prctl:", err)
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+func findCommand(args []string) (command, []string, bool) {
+	if len(args) == 0 {
+		return command{}, nil, false
+	}
+	for _, c := range commands {
+		if c.group != args[0] {
+			continue
+		}
+		if c.name == "" {
+			return c, args[1:], true
+		}
+		if len(args) > 1 && c.name == args[1] {
+			return c, args[2:], true
+		}
+	}
+	return command{}, nil, false
+}
+
+// dbDSN возвращает DSN из -dsn/DB_DSN или, как cmd/main.go, собирает его из
+// deploy/.env.
+func (e *env) dbDSN() (string, error) {
+	if e.dsn != "" {
+		return e.dsn, nil
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", usageErrorf("database DSN is not set: use -dsn or DB_DSN")
+	}
+	return fmt.Sprintf("postgres://%s:%s@localhost:%s/%s?sslmode=disable",
+		cfg.POSTGRES_USER, cfg.POSTGRES_PASSWORD, cfg.DB_HOST_PORT, cfg.POSTGRES_DB), nil
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"AvitoInternship/internal/handlers/dto"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		pos     []string
+		active  bool
+		limit   int
+		wantErr bool
+	}{
+		{name: "flags after positional", args: []string{"backend", "-active-only", "-limit", "5"}, pos: []string{"backend"}, active: true, limit: 5},
+		{name: "flags between positional", args: []string{"up", "-limit=2", "3"}, pos: []string{"up", "3"}, limit: 2},
+		{name: "double dash", args: []string{"--", "-active-only"}, pos: []string{"-active-only"}},
+		{name: "no args"},
+		{name: "unknown flag", args: []string{"backend", "-verbose"}, wantErr: true},
+		{name: "bad value", args: []string{"-limit", "many"}, wantErr: true},
+		{name: "help", args: []string{"-h"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("team get", flag.ContinueOnError)
+			active := fs.Bool("active-only", false, "")
+			limit := fs.Int("limit", 0, "")
+			pos, err := parseFlags(fs, tt.args)
+			if tt.wantErr {
+				var usageErr *usageError
+				if !errors.As(err, &usageErr) {
+					t.Fatalf("err = %v, want usage error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pos, tt.pos) || *active != tt.active || *limit != tt.limit {
+				t.Errorf("pos = %q active = %v limit = %d", pos, *active, *limit)
+			}
+		})
+	}
+}
+
+func TestMemberFlagsSet(t *testing.T) {
+	var m memberFlags
+	for _, s := range []string{"u1:Ivan", "u2:Petr:false", "u3:Olga:true"} {
+		if err := m.Set(s); err != nil {
+			t.Fatalf("Set(%q): %v", s, err)
+		}
+	}
+	want := memberFlags{
+		{UserID: "u1", Username: "Ivan", IsActive: true},
+		{UserID: "u2", Username: "Petr", IsActive: false},
+		{UserID: "u3", Username: "Olga", IsActive: true},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("members = %+v", m)
+	}
+
+	for _, s := range []string{"u1", "u1:Ivan:yes", "u1:Ivan:true:x", ""} {
+		if err := m.Set(s); err == nil {
+			t.Errorf("Set(%q): expected error", s)
+		}
+	}
+	if len(m) != len(want) {
+		t.Errorf("invalid values were appended: %+v", m)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{usageErrorf("usage"), exitUsage},
+		{fmt.Errorf("team add: %w", usageErrorf("bad flag")), exitUsage},
+		{&apiError{Code: dto.ErrorCodeTeamNotFound}, 22},
+		{fmt.Errorf("request: %w", &apiError{Code: dto.ErrorCodeNoCandidate}), 34},
+		{&apiError{Code: "SOMETHING_NEW"}, exitInternal},
+		{errors.New("connection refused"), exitInternal},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestOnlyMigrateHasNoDeadline(t *testing.T) {
+	for _, c := range commands {
+		if c.noDeadline != (c.group == "migrate") {
+			t.Errorf("%s %s: noDeadline = %v", c.group, c.name, c.noDeadline)
+		}
+	}
+}
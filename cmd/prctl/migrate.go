@@ -0,0 +1,228 @@
+package main
+
+import (
+	"AvitoInternship/internal/repository/db"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+
+	gomigrate "github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type migration struct {
+	version int64
+	name    string
+}
+
+type migrateResult struct {
+	Applied []string `json:"applied"`
+	Version *int64   `json:"version"`
+	Dirty   bool     `json:"dirty"`
+}
+
+// migrate up [n] | down [n] | force <version> | version
+//
+// Миграции применяет golang-migrate, как сервис migrate в docker-compose: та
+// же таблица schema_migrations и та же advisory-блокировка. up применяет все
+// (или n) новых миграций, down откатывает одну (или n) последних. Миграция,
+// упавшая посередине, оставляет базу dirty; после ручного исправления версию
+// выставляет force. Ограничение -timeout к migrate не применяется, а по Ctrl+C
+// текущая миграция дорабатывает до конца.
+func migrate(e *env, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dir := fs.String("path", "migrations", "directory with NNN_name.up.sql and NNN_name.down.sql files")
+	pos, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) > 2 {
+		return usageErrorf("usage: prctl migrate [up [n] | down [n] | force <version> | version] [-path dir]")
+	}
+	action := "up"
+	if len(pos) > 0 {
+		action = pos[0]
+	}
+	var n int64
+	if len(pos) > 1 {
+		if n, err = strconv.ParseInt(pos[1], 10, 64); err != nil || n < 0 {
+			return usageErrorf("migrate %s: expected a non-negative number, got %q", action, pos[1])
+		}
+	}
+	switch {
+	case action == "force" && len(pos) != 2:
+		return usageErrorf("usage: prctl migrate force <version>")
+	case action == "version" && len(pos) != 1:
+		return usageErrorf("usage: prctl migrate version")
+	case action != "up" && action != "down" && action != "force" && action != "version":
+		return usageErrorf("migrate: unknown action %q", action)
+	}
+
+	migrations, err := loadMigrations(*dir)
+	if err != nil {
+		return err
+	}
+	absDir, err := filepath.Abs(*dir)
+	if err != nil {
+		return err
+	}
+	dsn, err := e.dbDSN()
+	if err != nil {
+		return err
+	}
+	database, err := db.InitDB(dsn)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	driver, err := postgres.WithInstance(database, &postgres.Config{})
+	if err != nil {
+		_ = database.Close()
+		return err
+	}
+	m, err := gomigrate.NewWithDatabaseInstance("file://"+filepath.ToSlash(absDir), "postgres", driver)
+	if err != nil {
+		_ = driver.Close()
+		return err
+	}
+	// закрывает и базу
+	defer func() { _, _ = m.Close() }()
+	go func() {
+		<-e.ctx.Done()
+		m.GracefulStop <- true
+	}()
+
+	before, _, err := migrateVersion(m)
+	if err != nil {
+		return err
+	}
+	switch {
+	case action == "up" && n == 0:
+		err = m.Up()
+	case action == "up":
+		err = m.Steps(int(n))
+	case action == "down":
+		err = m.Steps(-int(max(n, 1)))
+	case action == "force":
+		err = m.Force(int(n))
+	}
+	var dirtyErr gomigrate.ErrDirty
+	var shortErr gomigrate.ErrShortLimit
+	switch {
+	case errors.As(err, &dirtyErr):
+		return errDirty(int64(dirtyErr.Version))
+	case errors.Is(err, gomigrate.ErrNoChange), errors.As(err, &shortErr):
+		// применено все, что было
+	case err != nil:
+		return err
+	}
+
+	version, dirty, err := migrateVersion(m)
+	if err != nil {
+		return err
+	}
+	res := migrateResult{Applied: make([]string, 0), Version: version, Dirty: dirty}
+	if action != "force" {
+		res.Applied = appliedBetween(migrations, before, version)
+	}
+	if e.json {
+		return e.encodeJSON(res)
+	}
+	for _, name := range res.Applied {
+		fmt.Fprintln(e.stdout, name)
+	}
+	switch {
+	case version == nil:
+		fmt.Fprintln(e.stdout, "no migrations applied")
+	case dirty:
+		fmt.Fprintf(e.stdout, "version %d (dirty)\n", *version)
+	default:
+		fmt.Fprintf(e.stdout, "version %d\n", *version)
+	}
+	return nil
+}
+
+// migrateVersion возвращает текущую версию; nil - ни одной миграции не применено.
+func migrateVersion(m *gomigrate.Migrate) (*int64, bool, error) {
+	version, dirty, err := m.Version()
+	if errors.Is(err, gomigrate.ErrNilVersion) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	v := int64(version)
+	return &v, dirty, nil
+}
+
+// appliedBetween возвращает миграции, примененные при переходе с версии from на
+// to (nil - ни одной миграции): при росте версии - up по возрастанию, при
+// откате - down по убыванию.
+func appliedBetween(migrations []migration, from, to *int64) []string {
+	applied := make([]string, 0)
+	below := func(v int64, bound *int64) bool { return bound != nil && v <= *bound }
+	for _, m := range migrations {
+		if below(m.version, to) && !below(m.version, from) {
+			applied = append(applied, fmt.Sprintf("%d_%s up", m.version, m.name))
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if below(m.version, from) && !below(m.version, to) {
+			applied = append(applied, fmt.Sprintf("%d_%s down", m.version, m.name))
+		}
+	}
+	return applied
+}
+
+func errDirty(version int64) error {
+	return fmt.Errorf("database is dirty at version %d: fix it manually and run prctl migrate force <version>", version)
+}
+
+// loadMigrations читает каталог миграций и сортирует их по версии.
+func loadMigrations(dir string) ([]migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]migration)
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid version", entry.Name())
+		}
+		if m, ok := byVersion[version]; ok && m.name != match[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, m.name, match[2])
+		}
+		byVersion[version] = migration{version: version, name: match[2]}
+	}
+	if len(byVersion) == 0 {
+		return nil, fmt.Errorf("no migrations found in %s", dir)
+	}
+
+	out := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		out = append(out, m)
+	}
+	slices.SortFunc(out, func(a, b migration) int {
+		switch {
+		case a.version < b.version:
+			return -1
+		case a.version > b.version:
+			return 1
+		}
+		return 0
+	})
+	return out, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeMigrations(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadMigrations(t *testing.T) {
+	dir := writeMigrations(t,
+		"010_ten.up.sql", "010_ten.down.sql",
+		"002_two.up.sql", "002_two.down.sql",
+		"001_init.up.sql",
+		"README.md", "003_bad.sql",
+	)
+	got, err := loadMigrations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []migration{{1, "init"}, {2, "two"}, {10, "ten"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("migrations = %+v", got)
+	}
+}
+
+func TestLoadRepositoryMigrations(t *testing.T) {
+	migrations, err := loadMigrations(filepath.Join("..", "..", "migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.version != int64(i+1) {
+			t.Fatalf("migration %d_%s: versions must go 1, 2, 3... without gaps", m.version, m.name)
+		}
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	tests := map[string][]string{
+		"duplicate version": {"001_init.up.sql", "001_other.down.sql"},
+		"no migrations":     {"README.md"},
+	}
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadMigrations(writeMigrations(t, files...)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+	if _, err := loadMigrations(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing dir: expected error")
+	}
+}
+
+func TestAppliedBetween(t *testing.T) {
+	migrations := []migration{{1, "init"}, {2, "two"}, {5, "five"}}
+	v := func(n int64) *int64 { return &n }
+	tests := []struct {
+		name     string
+		from, to *int64
+		want     string
+	}{
+		{name: "up from empty", to: v(5), want: "1_init up,2_two up,5_five up"},
+		{name: "up n", from: v(1), to: v(2), want: "2_two up"},
+		{name: "no change", from: v(2), to: v(2)},
+		{name: "down one", from: v(5), to: v(2), want: "5_five down"},
+		{name: "down to empty", from: v(2), want: "2_two down,1_init down"},
+		{name: "nothing applied", from: nil, to: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(appliedBetween(migrations, tt.from, tt.to), ",")
+			if got != tt.want {
+				t.Errorf("applied = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// parseArgs разбирает флаги команды вперемешку с позиционными аргументами
+// (prctl team get backend -active-only) и проверяет число позиционных.
+func parseArgs(fs *flag.FlagSet, args []string, positional ...string) ([]string, error) {
+	pos, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(pos) != len(positional) {
+		return nil, usageErrorf("usage: prctl %s %s", fs.Name(), strings.Join(placeholders(positional), " "))
+	}
+	return pos, nil
+}
+
+// parseFlags возвращает позиционные аргументы без проверки их числа.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, usageErrorf("see prctl -h for usage of %s", fs.Name())
+			}
+			return nil, usageErrorf("%s: %v", fs.Name(), err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return pos, nil
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
+
+func placeholders(names []string) []string {
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = "<" + n + ">"
+	}
+	return out
+}
+
+// writeJSON выводит ответ API с отступами.
+func (e *env) writeJSON(raw []byte) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := e.stdout.Write(buf.Bytes())
+	return err
+}
+
+// encodeJSON выводит значение, которое не пришло из API (stats, migrate).
+func (e *env) encodeJSON(v any) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeTable выводит строки колонками; пустые значения заменяются на "-".
+func (e *env) writeTable(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			if c == "" {
+				c = "-"
+			}
+			cells[i] = c
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func formatBool(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
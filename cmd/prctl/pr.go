@@ -0,0 +1,147 @@
+package main
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type prResponse struct {
+	PR dto.PullRequestDTO `json:"pr"`
+}
+
+// pr create - POST /pullRequest/create
+func prCreate(e *env, args []string) error {
+	fs := flag.NewFlagSet("pr create", flag.ContinueOnError)
+	name := fs.String("name", "", "pull request title")
+	author := fs.String("author", "", "author user id")
+	repository := fs.String("repository", "", "repository owner/name")
+	mode := fs.String("mode", dto.AssignmentModeDefault, "reviewer selection mode: empty or working_hours")
+	pos, err := parseArgs(fs, args, "pr_id")
+	if err != nil {
+		return err
+	}
+
+	req := dto.PullRequestDTO{
+		PullRequestID:   pos[0],
+		PullRequestName: *name,
+		AuthorID:        *author,
+		Repository:      *repository,
+		AssignmentMode:  *mode,
+	}
+	var resp prResponse
+	raw, err := e.do(http.MethodPost, "/pullRequest/create", nil, req, &resp)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		return e.writeJSON(raw)
+	}
+	return e.writePullRequest(resp.PR)
+}
+
+// pr merge - POST /pullRequest/merge
+func prMerge(e *env, args []string) error {
+	fs := flag.NewFlagSet("pr merge", flag.ContinueOnError)
+	pos, err := parseArgs(fs, args, "pr_id")
+	if err != nil {
+		return err
+	}
+
+	var resp prResponse
+	raw, err := e.do(http.MethodPost, "/pullRequest/merge", nil, dto.MergePRRequest{PullRequestID: pos[0]}, &resp)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		return e.writeJSON(raw)
+	}
+	return e.writePullRequest(resp.PR)
+}
+
+// pr reassign - POST /pullRequest/reassign
+func prReassign(e *env, args []string) error {
+	fs := flag.NewFlagSet("pr reassign", flag.ContinueOnError)
+	old := fs.String("old", "", "reviewer to replace")
+	mode := fs.String("mode", dto.AssignmentModeDefault, "reviewer selection mode: empty or working_hours")
+	pos, err := parseArgs(fs, args, "pr_id")
+	if err != nil {
+		return err
+	}
+
+	req := dto.ReassignReviewerRequest{PullRequestID: pos[0], OldReviewerID: *old, AssignmentMode: *mode}
+	var resp dto.ReassignReviewerResponse
+	raw, err := e.do(http.MethodPost, "/pullRequest/reassign", nil, req, &resp)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		return e.writeJSON(raw)
+	}
+	fmt.Fprintf(e.stdout, "%s replaced by %s\n", *old, resp.ReplacedBy)
+	return e.writePullRequest(resp.PR)
+}
+
+// pr list - GET /pullRequest/list. Выводит одну страницу; курсор следующей
+// печатается в stderr, чтобы не смешиваться с таблицей.
+func prList(e *env, args []string) error {
+	fs := flag.NewFlagSet("pr list", flag.ContinueOnError)
+	status := fs.String("status", "", "OPEN or MERGED")
+	author := fs.String("author", "", "author user id")
+	team := fs.String("team", "", "owner team")
+	repository := fs.String("repository", "", "repository owner/name")
+	sort := fs.String("sort", "", "created_at, -created_at, id or -id")
+	limit := fs.Int("limit", 0, "page size (1-500)")
+	cursor := fs.String("cursor", "", "next_cursor of the previous page")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	for key, v := range map[string]string{
+		"status": *status, "author_id": *author, "team_name": *team,
+		"repository": *repository, "sort": *sort, "cursor": *cursor,
+	} {
+		if v != "" {
+			q.Set(key, v)
+		}
+	}
+	if *limit != 0 {
+		q.Set("limit", strconv.Itoa(*limit))
+	}
+
+	var resp struct {
+		PullRequests []dto.PullRequestShortDTO `json:"pull_requests"`
+		NextCursor   string                    `json:"next_cursor"`
+	}
+	raw, err := e.do(http.MethodGet, "/pullRequest/list", q, nil, &resp)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		return e.writeJSON(raw)
+	}
+
+	rows := make([][]string, 0, len(resp.PullRequests))
+	for _, pr := range resp.PullRequests {
+		rows = append(rows, []string{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, pr.Repository, formatTime(pr.CreatedAt)})
+	}
+	if err := e.writeTable([]string{"PR_ID", "NAME", "AUTHOR", "STATUS", "REPOSITORY", "CREATED"}, rows); err != nil {
+		return err
+	}
+	if resp.NextCursor != "" {
+		fmt.Fprintf(os.Stderr, "next page: -cursor %s\n", resp.NextCursor)
+	}
+	return nil
+}
+
+func (e *env) writePullRequest(pr dto.PullRequestDTO) error {
+	return e.writeTable([]string{"PR_ID", "NAME", "AUTHOR", "STATUS", "TEAM", "REVIEWERS", "MERGED"},
+		[][]string{{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, pr.TeamName,
+			strings.Join(pr.AssignedReviewers, ","), formatTime(pr.MergedAt)}})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"AvitoInternship/internal/repository/db"
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+const statsSummarySQL = `
+SELECT (SELECT COUNT(*) FROM team),
+       (SELECT COUNT(*) FROM "user" WHERE deleted_at IS NULL),
+       (SELECT COUNT(*) FROM "user" WHERE is_active),
+       (SELECT COUNT(*) FROM pull_request WHERE status = 'OPEN'),
+       (SELECT COUNT(*) FROM pull_request WHERE status = 'MERGED');
+`
+
+// пустое имя команды - все пользователи
+const statsReviewersSQL = `
+SELECT u.id, u.name, COALESCE(t.name, ''), u.is_active,
+       COUNT(pr.id) FILTER (WHERE pr.status = 'OPEN'),
+       COUNT(pr.id)
+FROM "user" u
+LEFT JOIN team t ON t.id = u.team_id
+LEFT JOIN pull_request_reviewer r ON r.user_id = u.id
+LEFT JOIN pull_request pr ON pr.id = r.pr_id
+WHERE u.deleted_at IS NULL AND ($1 = '' OR t.name = $1)
+GROUP BY u.id, u.name, t.name, u.is_active
+ORDER BY 5 DESC, 6 DESC, u.id;
+`
+
+type statsSummary struct {
+	Teams              int                `json:"teams"`
+	Users              int                `json:"users"`
+	ActiveUsers        int                `json:"active_users"`
+	OpenPullRequests   int                `json:"open_pull_requests"`
+	MergedPullRequests int                `json:"merged_pull_requests"`
+	Reviewers          []reviewerStatsRow `json:"reviewers"`
+}
+
+type reviewerStatsRow struct {
+	UserID      string `json:"user_id"`
+	Username    string `json:"username"`
+	TeamName    string `json:"team_name"`
+	IsActive    bool   `json:"is_active"`
+	OpenReviews int    `json:"open_reviews"`
+	Assignments int    `json:"assignments"`
+}
+
+// stats - сводка по базе и нагрузка ревьюеров: число OPEN-ревью и всех
+// назначений.
+func stats(e *env, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	team := fs.String("team", "", "only members of this team")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	dsn, err := e.dbDSN()
+	if err != nil {
+		return err
+	}
+	database, err := db.InitDB(dsn)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer database.Close()
+
+	var s statsSummary
+	if err := database.QueryRowContext(e.ctx, statsSummarySQL).Scan(
+		&s.Teams, &s.Users, &s.ActiveUsers, &s.OpenPullRequests, &s.MergedPullRequests); err != nil {
+		return err
+	}
+	rows, err := database.QueryContext(e.ctx, statsReviewersSQL, *team)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	s.Reviewers = make([]reviewerStatsRow, 0)
+	for rows.Next() {
+		var r reviewerStatsRow
+		if err := rows.Scan(&r.UserID, &r.Username, &r.TeamName, &r.IsActive, &r.OpenReviews, &r.Assignments); err != nil {
+			return err
+		}
+		s.Reviewers = append(s.Reviewers, r)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if e.json {
+		return e.encodeJSON(s)
+	}
+	fmt.Fprintf(e.stdout, "teams: %d, users: %d (%d active), pull requests: %d open, %d merged\n",
+		s.Teams, s.Users, s.ActiveUsers, s.OpenPullRequests, s.MergedPullRequests)
+	table := make([][]string, 0, len(s.Reviewers))
+	for _, r := range s.Reviewers {
+		table = append(table, []string{r.UserID, r.Username, r.TeamName, formatBool(r.IsActive),
+			strconv.Itoa(r.OpenReviews), strconv.Itoa(r.Assignments)})
+	}
+	return e.writeTable([]string{"USER_ID", "USERNAME", "TEAM", "ACTIVE", "OPEN_REVIEWS", "ASSIGNMENTS"}, table)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// memberFlags - повторяемый флаг -member id:name[:is_active].
+type memberFlags []dto.TeamMemberDTO
+
+func (m *memberFlags) String() string { return "" }
+
+func (m *memberFlags) Set(s string) error {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("expected id:name[:is_active], got %q", s)
+	}
+	member := dto.TeamMemberDTO{UserID: parts[0], Username: parts[1], IsActive: true}
+	if len(parts) == 3 {
+		active, err := strconv.ParseBool(parts[2])
+		if err != nil {
+			return fmt.Errorf("is_active must be true or false, got %q", parts[2])
+		}
+		member.IsActive = active
+	}
+	*m = append(*m, member)
+	return nil
+}
+
+// team add - POST /team/add
+func teamAdd(e *env, args []string) error {
+	fs := flag.NewFlagSet("team add", flag.ContinueOnError)
+	var members memberFlags
+	fs.Var(&members, "member", "team member as id:name[:is_active], repeatable")
+	pos, err := parseArgs(fs, args, "team")
+	if err != nil {
+		return err
+	}
+
+	req := dto.TeamDTO{TeamName: pos[0], Members: members}
+	if req.Members == nil {
+		req.Members = make([]dto.TeamMemberDTO, 0)
+	}
+	var resp struct {
+		Team dto.TeamDTO `json:"team"`
+	}
+	raw, err := e.do(http.MethodPost, "/team/add", nil, req, &resp)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		return e.writeJSON(raw)
+	}
+	return e.writeTeam(resp.Team)
+}
+
+// team get - GET /team/get
+func teamGet(e *env, args []string) error {
+	fs := flag.NewFlagSet("team get", flag.ContinueOnError)
+	activeOnly := fs.Bool("active-only", false, "only active members")
+	pos, err := parseArgs(fs, args, "team")
+	if err != nil {
+		return err
+	}
+
+	q := url.Values{"team_name": {pos[0]}}
+	if *activeOnly {
+		q.Set("active_only", "true")
+	}
+	var team dto.TeamDTO
+	raw, err := e.do(http.MethodGet, "/team/get", q, nil, &team)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		return e.writeJSON(raw)
+	}
+	return e.writeTeam(team)
+}
+
+// team sync - PUT /team/sync. Файл содержит JSON-массив участников в формате
+// members из /team/get; "-" - читать из stdin.
+func teamSync(e *env, args []string) error {
+	fs := flag.NewFlagSet("team sync", flag.ContinueOnError)
+	file := fs.String("f", "", "JSON file with the desired members, - for stdin")
+	missing := fs.String("missing", dto.SyncMissingDeactivate, "what to do with members missing from the file: deactivate or remove")
+	dryRun := fs.Bool("dry-run", false, "only show the changes")
+	pos, err := parseArgs(fs, args, "team")
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		return usageErrorf("team sync: -f is required")
+	}
+
+	data, err := readInput(*file)
+	if err != nil {
+		return err
+	}
+	req := dto.TeamSyncRequest{TeamName: pos[0], Missing: *missing, DryRun: *dryRun}
+	if err := json.Unmarshal(data, &req.Members); err != nil {
+		return usageErrorf("team sync: %s: expected a JSON array of members: %v", *file, err)
+	}
+
+	var resp dto.TeamSyncResponse
+	raw, err := e.do(http.MethodPut, "/team/sync", nil, req, &resp)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		return e.writeJSON(raw)
+	}
+
+	var rows [][]string
+	groups := []struct {
+		name    string
+		changes []dto.TeamMemberChangeDTO
+	}{{"added", resp.Added}, {"updated", resp.Updated}, {"deactivated", resp.Deactivated}, {"removed", resp.Removed}}
+	for _, g := range groups {
+		for _, c := range g.changes {
+			rows = append(rows, []string{g.name, c.UserID, c.Username, formatBool(c.IsActive), c.PreviousTeam, strings.Join(c.Fields, ",")})
+		}
+	}
+	if resp.DryRun {
+		fmt.Fprintln(e.stdout, "dry run, nothing was changed")
+	}
+	if resp.TeamCreated {
+		fmt.Fprintf(e.stdout, "team %s created\n", resp.TeamName)
+	}
+	if err := e.writeTable([]string{"CHANGE", "USER_ID", "USERNAME", "ACTIVE", "PREVIOUS_TEAM", "FIELDS"}, rows); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "%d unchanged\n", resp.Unchanged)
+	return e.writeReassignments(resp.Reassigned)
+}
+
+func (e *env) writeTeam(team dto.TeamDTO) error {
+	fmt.Fprintf(e.stdout, "team %s\n", team.TeamName)
+	rows := make([][]string, 0, len(team.Members))
+	for _, m := range team.Members {
+		rows = append(rows, []string{m.UserID, m.Username, formatBool(m.IsActive)})
+	}
+	return e.writeTable([]string{"USER_ID", "USERNAME", "ACTIVE"}, rows)
+}
+
+func (e *env) writeReassignments(list []dto.ReassignmentDTO) error {
+	if len(list) == 0 {
+		return nil
+	}
+	fmt.Fprintln(e.stdout, "reassigned reviews:")
+	rows := make([][]string, 0, len(list))
+	for _, r := range list {
+		rows = append(rows, []string{r.PullRequestID, r.UserID, r.ReplacedBy, r.Error})
+	}
+	return e.writeTable([]string{"PR_ID", "REVIEWER", "REPLACED_BY", "ERROR"}, rows)
+}
+
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
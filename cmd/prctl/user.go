@@ -0,0 +1,34 @@
+package main
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"flag"
+	"net/http"
+)
+
+// user activate|deactivate - POST /users/setIsActive
+func userSetActive(active bool) func(e *env, args []string) error {
+	name := "user deactivate"
+	if active {
+		name = "user activate"
+	}
+	return func(e *env, args []string) error {
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		pos, err := parseArgs(fs, args, "user_id")
+		if err != nil {
+			return err
+		}
+
+		var resp dto.UserResponse
+		raw, err := e.do(http.MethodPost, "/users/setIsActive", nil, dto.SetIsActiveRequest{UserID: pos[0], IsActive: active}, &resp)
+		if err != nil {
+			return err
+		}
+		if e.json {
+			return e.writeJSON(raw)
+		}
+		u := resp.User
+		return e.writeTable([]string{"USER_ID", "USERNAME", "TEAM", "ACTIVE"},
+			[][]string{{u.UserID, u.Username, u.TeamName, formatBool(u.IsActive)}})
+	}
+}
@@ -0,0 +1,127 @@
+// Package codeowners разбирает файлы CODEOWNERS в синтаксисе GitHub.
+//
+// Правила сопоставления повторяют GitHub: шаблоны ведут себя как в .gitignore
+// (без отрицаний "!" и диапазонов "[...]"), а при нескольких совпадениях
+// побеждает последняя подходящая строка файла.
+package codeowners
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type Rule struct {
+	Pattern string
+	Owners  []string
+	Line    int
+	re      *regexp.Regexp
+}
+
+type File struct {
+	Rules []Rule
+}
+
+// Parse разбирает содержимое CODEOWNERS. Строки без владельцев допустимы:
+// они снимают владение с путей, которые совпали с более ранними правилами.
+func Parse(content string) (*File, error) {
+	f := &File{}
+	for i, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(stripComment(raw))
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		pattern := strings.ReplaceAll(fields[0], `\#`, "#")
+		re, err := compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		owners := fields[1:]
+		for _, o := range owners {
+			if !strings.HasPrefix(o, "@") && !strings.Contains(o, "@") {
+				return nil, fmt.Errorf("line %d: invalid owner %q", i+1, o)
+			}
+		}
+		f.Rules = append(f.Rules, Rule{Pattern: pattern, Owners: owners, Line: i + 1, re: re})
+	}
+	return f, nil
+}
+
+// Match возвращает последнее правило, подходящее под путь, или nil.
+func (f *File) Match(path string) *Rule {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(f.Rules) - 1; i >= 0; i-- {
+		if f.Rules[i].re.MatchString(path) {
+			return &f.Rules[i]
+		}
+	}
+	return nil
+}
+
+// Owners возвращает владельцев пути согласно правилу с наивысшим приоритетом.
+func (f *File) Owners(path string) []string {
+	if rule := f.Match(path); rule != nil {
+		return rule.Owners
+	}
+	return nil
+}
+
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' && (i == 0 || line[i-1] != '\\') {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "!") {
+		return nil, fmt.Errorf("negation is not supported: %q", pattern)
+	}
+	if strings.ContainsAny(pattern, "[]") {
+		return nil, fmt.Errorf("character ranges are not supported: %q", pattern)
+	}
+
+	p := strings.TrimSuffix(pattern, "/")
+	anchored := strings.HasPrefix(p, "/") || strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				switch {
+				case i+2 < len(p) && p[i+2] == '/':
+					b.WriteString("(?:.*/)?")
+					i += 2
+				default:
+					b.WriteString(".*")
+					i++
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// "docs/*" владеет только файлами непосредственно в docs, остальные
+	// шаблоны, совпавшие с каталогом, распространяются на все его содержимое
+	if !strings.HasSuffix(p, "/*") && p != "*" {
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,95 @@
+package codeowners
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		{"*", []string{"a.go", "dir/a.go", "a/b/c"}, nil},
+		{"*.js", []string{"a.js", "src/a.js", "src/deep/a.js"}, []string{"a.jsx", "a.go"}},
+		{"/build/logs/", []string{"build/logs/a.log", "build/logs/deep/a.log"}, []string{"src/build/logs/a.log"}},
+		{"docs/*", []string{"docs/a.md"}, []string{"docs/deep/a.md", "src/docs/a.md"}},
+		{"apps/", []string{"apps/a", "src/apps/a", "apps/deep/a"}, []string{"apps.go", "myapps/a"}},
+		{"/docs/", []string{"docs/a.md", "docs/deep/a.md"}, []string{"src/docs/a.md"}},
+		{"**/logs", []string{"logs/a", "build/logs/a", "a/b/logs/c"}, []string{"mylogs/a"}},
+		{"docs/**/a.md", []string{"docs/a.md", "docs/x/a.md", "docs/x/y/a.md"}, []string{"docs/b.md"}},
+		{"src/**", []string{"src/a", "src/a/b"}, []string{"a/src/a"}},
+		{"a?.go", []string{"ab.go", "x/ab.go"}, []string{"a.go", "a/b.go", "abc.go"}},
+		{"file.go", []string{"file.go", "pkg/file.go"}, []string{"file.gox", "afile.go"}},
+		{"dir/file.go", []string{"dir/file.go"}, []string{"x/dir/file.go"}},
+		{"a+b(c).go", []string{"a+b(c).go"}, []string{"aab(c).go"}},
+	}
+	for _, tt := range tests {
+		re, err := compile(tt.pattern)
+		if err != nil {
+			t.Fatalf("compile(%q): %v", tt.pattern, err)
+		}
+		for _, p := range tt.match {
+			if !re.MatchString(p) {
+				t.Errorf("%q should match %q", tt.pattern, p)
+			}
+		}
+		for _, p := range tt.noMatch {
+			if re.MatchString(p) {
+				t.Errorf("%q should not match %q", tt.pattern, p)
+			}
+		}
+	}
+}
+
+func TestCompileRejects(t *testing.T) {
+	for _, pattern := range []string{"!a.go", "[ab].go", "/", ""} {
+		if _, err := compile(pattern); err == nil {
+			t.Errorf("compile(%q): expected error", pattern)
+		}
+	}
+}
+
+func TestOwnersLastMatchWins(t *testing.T) {
+	f, err := Parse(`# comment
+*                 @org/core
+*.go              @alice bob@example.com
+/internal/        @org/backend # trailing comment
+/internal/legacy/
+docs/*            @carol
+\#notes.md        @dave
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path   string
+		owners []string
+	}{
+		{"README.md", []string{"@org/core"}},
+		{"main.go", []string{"@alice", "bob@example.com"}},
+		{"/cmd/main.go", []string{"@alice", "bob@example.com"}},
+		{"internal/a.go", []string{"@org/backend"}},
+		{"internal/legacy/a.go", nil},
+		{"docs/a.md", []string{"@carol"}},
+		{"docs/deep/a.md", []string{"@org/core"}},
+		{"#notes.md", []string{"@dave"}},
+	}
+	for _, tt := range tests {
+		if got := f.Owners(tt.path); !slices.Equal(got, tt.owners) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.owners)
+		}
+	}
+	if rule := f.Match("internal/a.go"); rule == nil || rule.Line != 4 {
+		t.Errorf("Match(internal/a.go) = %+v, want line 4", rule)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, content := range []string{"*.go alice", "[a].go @alice", "!x @alice"} {
+		if _, err := Parse(content); err == nil {
+			t.Errorf("Parse(%q): expected error", content)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +16,53 @@ type Config struct {
 	DB_HOST_PORT      string
 	DB_DSN            string
 	APP_PORT          string
+	GRPC_PORT         string
+	SHUTDOWN_TIMEOUT  time.Duration
+
+	VACATION_REASSIGN_ENABLED  bool
+	VACATION_REASSIGN_INTERVAL time.Duration
+
+	GITHUB_WEBHOOK_SECRET string
+	GITLAB_WEBHOOK_TOKEN  string
+
+	GITHUB_API_URL       string
+	GITHUB_TOKEN         string
+	GITHUB_SYNC_ATTEMPTS int
+	GITHUB_SYNC_BACKOFF  time.Duration
+	GITHUB_SYNC_WORKERS  int
+
+	OUTBOX_ENABLED       bool
+	OUTBOX_SINKS         []string
+	OUTBOX_FILE_PATH     string
+	OUTBOX_HTTP_URL      string
+	OUTBOX_POLL_INTERVAL time.Duration
+	OUTBOX_BATCH_SIZE    int
+	OUTBOX_MAX_ATTEMPTS  int
+	OUTBOX_RETENTION     time.Duration
+
+	SUBSCRIPTION_ATTEMPTS int
+	SUBSCRIPTION_BACKOFF  time.Duration
+
+	CHAT_WEBHOOK_URL   string
+	CHAT_FORMAT        string
+	CHAT_TEMPLATE_FILE string
+	CHAT_ATTEMPTS      int
+	CHAT_BACKOFF       time.Duration
+
+	DIGEST_ENABLED     bool
+	DIGEST_TIME        string
+	DIGEST_TIME_ZONE   string
+	DIGEST_FROM        string
+	DIGEST_DRY_RUN_DIR string
+	SMTP_HOST          string
+	SMTP_PORT          int
+	SMTP_USERNAME      string
+	SMTP_PASSWORD      string
+
+	STALE_REVIEWS_ENABLED   bool
+	STALE_REVIEWS_INTERVAL  time.Duration
+	REVIEW_REMINDER_HOURS   int
+	REVIEW_ESCALATION_HOURS int
 }
 
 func LoadConfig() (*Config, error) {
@@ -25,5 +75,93 @@ func LoadConfig() (*Config, error) {
 		POSTGRES_PASSWORD: os.Getenv("POSTGRES_PASSWORD"),
 		DB_HOST_PORT:      os.Getenv("DB_HOST_PORT"),
 		APP_PORT:          os.Getenv("APP_PORT"),
+		GRPC_PORT:         os.Getenv("GRPC_PORT"),
+		SHUTDOWN_TIMEOUT:  getEnvDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+
+		VACATION_REASSIGN_ENABLED:  getEnvBool("VACATION_REASSIGN_ENABLED", false),
+		VACATION_REASSIGN_INTERVAL: getEnvDuration("VACATION_REASSIGN_INTERVAL", 5*time.Minute),
+
+		GITHUB_WEBHOOK_SECRET: os.Getenv("GITHUB_WEBHOOK_SECRET"),
+		GITLAB_WEBHOOK_TOKEN:  os.Getenv("GITLAB_WEBHOOK_TOKEN"),
+
+		GITHUB_API_URL:       os.Getenv("GITHUB_API_URL"),
+		GITHUB_TOKEN:         os.Getenv("GITHUB_TOKEN"),
+		GITHUB_SYNC_ATTEMPTS: getEnvInt("GITHUB_SYNC_ATTEMPTS", 5),
+		GITHUB_SYNC_BACKOFF:  getEnvDuration("GITHUB_SYNC_BACKOFF", time.Second),
+		GITHUB_SYNC_WORKERS:  getEnvInt("GITHUB_SYNC_WORKERS", 4),
+
+		OUTBOX_ENABLED:       getEnvBool("OUTBOX_ENABLED", false),
+		OUTBOX_SINKS:         getEnvList("OUTBOX_SINKS"),
+		OUTBOX_FILE_PATH:     os.Getenv("OUTBOX_FILE_PATH"),
+		OUTBOX_HTTP_URL:      os.Getenv("OUTBOX_HTTP_URL"),
+		OUTBOX_POLL_INTERVAL: getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OUTBOX_BATCH_SIZE:    getEnvInt("OUTBOX_BATCH_SIZE", 100),
+		OUTBOX_MAX_ATTEMPTS:  getEnvInt("OUTBOX_MAX_ATTEMPTS", 20),
+		OUTBOX_RETENTION:     getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+
+		SUBSCRIPTION_ATTEMPTS: getEnvInt("SUBSCRIPTION_ATTEMPTS", 8),
+		SUBSCRIPTION_BACKOFF:  getEnvDuration("SUBSCRIPTION_BACKOFF", 5*time.Second),
+
+		CHAT_WEBHOOK_URL:   os.Getenv("CHAT_WEBHOOK_URL"),
+		CHAT_FORMAT:        os.Getenv("CHAT_FORMAT"),
+		CHAT_TEMPLATE_FILE: os.Getenv("CHAT_TEMPLATE_FILE"),
+		CHAT_ATTEMPTS:      getEnvInt("CHAT_ATTEMPTS", 5),
+		CHAT_BACKOFF:       getEnvDuration("CHAT_BACKOFF", 5*time.Second),
+
+		DIGEST_ENABLED:     getEnvBool("DIGEST_ENABLED", false),
+		DIGEST_TIME:        getEnv("DIGEST_TIME", "09:00"),
+		DIGEST_TIME_ZONE:   getEnv("DIGEST_TIME_ZONE", "UTC"),
+		DIGEST_FROM:        os.Getenv("DIGEST_FROM"),
+		DIGEST_DRY_RUN_DIR: os.Getenv("DIGEST_DRY_RUN_DIR"),
+		SMTP_HOST:          os.Getenv("SMTP_HOST"),
+		SMTP_PORT:          getEnvInt("SMTP_PORT", 587),
+		SMTP_USERNAME:      os.Getenv("SMTP_USERNAME"),
+		SMTP_PASSWORD:      os.Getenv("SMTP_PASSWORD"),
+
+		STALE_REVIEWS_ENABLED:   getEnvBool("STALE_REVIEWS_ENABLED", false),
+		STALE_REVIEWS_INTERVAL:  getEnvDuration("STALE_REVIEWS_INTERVAL", 10*time.Minute),
+		REVIEW_REMINDER_HOURS:   getEnvInt("REVIEW_REMINDER_HOURS", 24),
+		REVIEW_ESCALATION_HOURS: getEnvInt("REVIEW_ESCALATION_HOURS", 72),
 	}, nil
 }
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func getEnvBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func getEnvInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
+
+func getEnvList(key string) []string {
+	var res []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
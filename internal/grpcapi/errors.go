@@ -0,0 +1,77 @@
+package grpcapi
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"errors"
+	"log"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const errorDomain = "reviewer.avito"
+
+// коды домена и соответствующие им статусы gRPC; остальные ошибки - Internal
+var errorCodes = map[string]codes.Code{
+	dto.ErrorCodeTeamExists:          codes.AlreadyExists,
+	dto.ErrorCodePRExists:            codes.AlreadyExists,
+	dto.ErrorCodePRMerged:            codes.FailedPrecondition,
+	dto.ErrorCodeNotAssigned:         codes.FailedPrecondition,
+	dto.ErrorCodeNoCandidate:         codes.FailedPrecondition,
+	dto.ErrorCodeNotFound:            codes.NotFound,
+	dto.ErrorCodeUserNotFound:        codes.NotFound,
+	dto.ErrorCodeTeamNotFound:        codes.NotFound,
+	dto.ErrorCodePullRequestNotFound: codes.NotFound,
+	dto.ErrorBadRequest:              codes.InvalidArgument,
+	dto.ErrorCodeValidation:          codes.InvalidArgument,
+}
+
+// statusError переводит ошибку репозитория или сервиса в статус gRPC.
+// Код домена передается в ErrorInfo.reason, для NO_CANDIDATE причины отказа
+// по каждому участнику - в PreconditionFailure, для VALIDATION_ERROR ошибки
+// полей - в BadRequest.
+func statusError(err error, message string) error {
+	code, ok := errorCodes[err.Error()]
+	if !ok {
+		log.Printf("grpc: %s: %v", message, err)
+		return status.Error(codes.Internal, message)
+	}
+
+	st, detErr := status.New(code, message).WithDetails(&errdetails.ErrorInfo{Reason: err.Error(), Domain: errorDomain})
+	if detErr != nil {
+		return status.Error(code, message)
+	}
+	var noCandidate *dto.NoCandidateError
+	if errors.As(err, &noCandidate) && len(noCandidate.Skipped) > 0 {
+		failure := &errdetails.PreconditionFailure{}
+		for _, s := range noCandidate.Skipped {
+			failure.Violations = append(failure.Violations, &errdetails.PreconditionFailure_Violation{
+				Type:        s.Reason,
+				Subject:     s.UserID,
+				Description: "candidate skipped: " + s.Reason,
+			})
+		}
+		if withFailure, err := st.WithDetails(failure); err == nil {
+			st = withFailure
+		}
+	}
+	var validation *dto.ValidationError
+	if errors.As(err, &validation) {
+		badRequest := &errdetails.BadRequest{}
+		for _, f := range validation.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+			})
+		}
+		if withBadRequest, err := st.WithDetails(badRequest); err == nil {
+			st = withBadRequest
+		}
+	}
+	return st.Err()
+}
+
+func invalidArgument(message string) error {
+	return statusError(errors.New(dto.ErrorBadRequest), message)
+}
@@ -0,0 +1,140 @@
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"regexp"
+	"testing"
+
+	"AvitoInternship/internal/grpcapi/reviewerv1"
+	"AvitoInternship/internal/handlers/dto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{"exists", errors.New(dto.ErrorCodeTeamExists), codes.AlreadyExists, dto.ErrorCodeTeamExists},
+		{"merged", errors.New(dto.ErrorCodePRMerged), codes.FailedPrecondition, dto.ErrorCodePRMerged},
+		{"not found", errors.New(dto.ErrorCodeUserNotFound), codes.NotFound, dto.ErrorCodeUserNotFound},
+		{"bad request", errors.New(dto.ErrorBadRequest), codes.InvalidArgument, dto.ErrorBadRequest},
+		{"internal", errors.New("connection refused"), codes.Internal, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(statusError(tt.err, "failed"))
+			if st.Code() != tt.code || st.Message() != "failed" {
+				t.Errorf("status = %v %q, want %v", st.Code(), st.Message(), tt.code)
+			}
+			if got := errorInfoReason(st); got != tt.reason {
+				t.Errorf("ErrorInfo.reason = %q, want %q", got, tt.reason)
+			}
+		})
+	}
+}
+
+func TestStatusErrorDetails(t *testing.T) {
+	noCandidate := &dto.NoCandidateError{Skipped: []dto.SkippedCandidateDTO{{UserID: "u3", Reason: "inactive"}}}
+	st := status.Convert(statusError(noCandidate, "failed to reassign"))
+	if st.Code() != codes.FailedPrecondition {
+		t.Fatalf("code = %v", st.Code())
+	}
+	var failure *errdetails.PreconditionFailure
+	for _, d := range st.Details() {
+		if f, ok := d.(*errdetails.PreconditionFailure); ok {
+			failure = f
+		}
+	}
+	if failure == nil || len(failure.Violations) != 1 || failure.Violations[0].Subject != "u3" || failure.Violations[0].Type != "inactive" {
+		t.Errorf("PreconditionFailure = %v", failure)
+	}
+
+	validation := &dto.ValidationError{Fields: []dto.FieldErrorDTO{{Field: "members[0].user_id", Message: "is required"}}}
+	st = status.Convert(statusError(validation, "invalid team"))
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code = %v", st.Code())
+	}
+	var badRequest *errdetails.BadRequest
+	for _, d := range st.Details() {
+		if b, ok := d.(*errdetails.BadRequest); ok {
+			badRequest = b
+		}
+	}
+	if badRequest == nil || len(badRequest.FieldViolations) != 1 || badRequest.FieldViolations[0].Field != "members[0].user_id" {
+		t.Errorf("BadRequest = %v", badRequest)
+	}
+}
+
+func errorInfoReason(st *status.Status) string {
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			if info.Domain != errorDomain {
+				return "wrong domain " + info.Domain
+			}
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+// dialBufconn поднимает ReviewerService в памяти и возвращает клиента.
+func dialBufconn(t *testing.T, db *sql.DB) reviewerv1.ReviewerServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := Register(db, nil)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.GracefulStop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return reviewerv1.NewReviewerServiceClient(conn)
+}
+
+func TestServerErrorsOverGRPC(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	client := dialBufconn(t, db)
+	ctx := context.Background()
+
+	_, err = client.GetTeam(ctx, &reviewerv1.GetTeamRequest{})
+	if st := status.Convert(err); st.Code() != codes.InvalidArgument || errorInfoReason(st) != dto.ErrorBadRequest {
+		t.Errorf("empty team_name: %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, COALESCE(default_max_open_reviews, 0)")).
+		WithArgs("ghost").WillReturnError(sql.ErrNoRows)
+	_, err = client.GetTeam(ctx, &reviewerv1.GetTeamRequest{TeamName: "ghost"})
+	if st := status.Convert(err); st.Code() != codes.NotFound || errorInfoReason(st) != dto.ErrorCodeTeamNotFound {
+		t.Errorf("missing team: %v", err)
+	}
+
+	_, err = client.AddTeam(ctx, &reviewerv1.AddTeamRequest{Team: &reviewerv1.Team{TeamName: "backend", Members: []*reviewerv1.TeamMember{{UserId: "", Username: "x"}}}})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument || errorInfoReason(st) != dto.ErrorCodeValidation {
+		t.Errorf("invalid team: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
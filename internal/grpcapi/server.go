@@ -0,0 +1,194 @@
+// Package grpcapi отдает операции HTTP API по gRPC (сервис
+// reviewer.v1.ReviewerService из api/proto). Обработчики используют те же
+// репозитории и PullRequestService, что и HTTP.
+package grpcapi
+
+import (
+	"AvitoInternship/internal/grpcapi/reviewerv1"
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"AvitoInternship/internal/handlers/team"
+	"AvitoInternship/internal/handlers/user"
+	"context"
+	"database/sql"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Server struct {
+	reviewerv1.UnimplementedReviewerServiceServer
+
+	teams *team.TeamRepository
+	users *user.UserRepository
+	prs   *pullRequest.PullRequestRepository
+	svc   *pullRequest.PullRequestService
+}
+
+func NewServer(db *sql.DB, listener pullRequest.AssignmentListener) *Server {
+	prs := pullRequest.NewPullRequestRepository(db)
+	prs.SetListener(listener)
+	return &Server{
+		teams: team.NewTeamRepository(db),
+		users: user.NewUserRepository(db),
+		prs:   prs,
+		svc:   pullRequest.NewService(db, listener),
+	}
+}
+
+// Register создает grpc.Server с зарегистрированным ReviewerService.
+func Register(db *sql.DB, listener pullRequest.AssignmentListener, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	reviewerv1.RegisterReviewerServiceServer(s, NewServer(db, listener))
+	return s
+}
+
+func (s *Server) AddTeam(ctx context.Context, req *reviewerv1.AddTeamRequest) (*reviewerv1.TeamResponse, error) {
+	t := dto.TeamDTO{TeamName: req.GetTeam().GetTeamName(), Members: make([]dto.TeamMemberDTO, 0, len(req.GetTeam().GetMembers()))}
+	for _, m := range req.GetTeam().GetMembers() {
+		t.Members = append(t.Members, dto.TeamMemberDTO{UserID: m.UserId, Username: m.Username, IsActive: m.IsActive})
+	}
+	if err := t.Validate(); err != nil {
+		return nil, statusError(err, "invalid team")
+	}
+	res, err := s.teams.AddTeam(ctx, t)
+	if err != nil {
+		return nil, statusError(err, "failed to add team")
+	}
+	return &reviewerv1.TeamResponse{Team: teamToProto(res)}, nil
+}
+
+func (s *Server) GetTeam(ctx context.Context, req *reviewerv1.GetTeamRequest) (*reviewerv1.TeamResponse, error) {
+	if req.GetTeamName() == "" {
+		return nil, invalidArgument("team_name is required")
+	}
+	res, err := s.teams.GetTeam(ctx, req.TeamName)
+	if err != nil {
+		return nil, statusError(err, "failed to get team")
+	}
+	return &reviewerv1.TeamResponse{Team: teamToProto(res)}, nil
+}
+
+func (s *Server) SetIsActive(ctx context.Context, req *reviewerv1.SetIsActiveRequest) (*reviewerv1.UserResponse, error) {
+	if err := (dto.SetIsActiveRequest{UserID: req.GetUserId(), IsActive: req.GetIsActive()}).Validate(); err != nil {
+		return nil, statusError(err, "invalid request")
+	}
+	u, err := s.users.SetIsActive(ctx, req.UserId, req.IsActive)
+	if err != nil {
+		return nil, statusError(err, "failed to update user")
+	}
+	return &reviewerv1.UserResponse{User: &reviewerv1.User{
+		UserId:   u.UserID,
+		Username: u.Username,
+		TeamName: u.TeamName,
+		IsActive: u.IsActive,
+	}}, nil
+}
+
+func (s *Server) GetReview(ctx context.Context, req *reviewerv1.GetReviewRequest) (*reviewerv1.GetReviewResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, invalidArgument("user_id is required")
+	}
+	prs, err := s.users.GetReviewPullRequests(ctx, req.UserId, req.Repository)
+	if err != nil {
+		return nil, statusError(err, "failed to get pull requests")
+	}
+	res := &reviewerv1.GetReviewResponse{UserId: req.UserId}
+	for _, pr := range prs {
+		res.PullRequests = append(res.PullRequests, &reviewerv1.PullRequestShort{
+			PullRequestId:   pr.PullRequestID,
+			PullRequestName: pr.PullRequestName,
+			AuthorId:        pr.AuthorID,
+			Status:          pr.Status,
+			Repository:      pr.Repository,
+		})
+	}
+	return res, nil
+}
+
+func (s *Server) CreatePullRequest(ctx context.Context, req *reviewerv1.CreatePullRequestRequest) (*reviewerv1.PullRequestResponse, error) {
+	in := dto.PullRequestDTO{
+		PullRequestID:   req.GetPullRequestId(),
+		PullRequestName: req.GetPullRequestName(),
+		AuthorID:        req.GetAuthorId(),
+		TeamName:        req.GetTeamName(),
+		Repository:      req.GetRepository(),
+		ChangedFiles:    req.GetChangedFiles(),
+		RequiredTags:    req.GetRequiredTags(),
+		AssignmentMode:  req.GetAssignmentMode(),
+	}
+	if err := in.Validate(); err != nil {
+		return nil, statusError(err, "invalid pull request")
+	}
+	pr, err := s.prs.CreatePullRequest(ctx, in)
+	if err != nil {
+		return nil, statusError(err, "failed to create pull request")
+	}
+	return &reviewerv1.PullRequestResponse{Pr: pullRequestToProto(pr)}, nil
+}
+
+func (s *Server) MergePullRequest(ctx context.Context, req *reviewerv1.MergePullRequestRequest) (*reviewerv1.PullRequestResponse, error) {
+	if err := (dto.MergePRRequest{PullRequestID: req.GetPullRequestId()}).Validate(); err != nil {
+		return nil, statusError(err, "invalid request")
+	}
+	pr, err := s.prs.MergePullRequest(ctx, req.PullRequestId)
+	if err != nil {
+		return nil, statusError(err, "failed to merge pull request")
+	}
+	return &reviewerv1.PullRequestResponse{Pr: pullRequestToProto(pr)}, nil
+}
+
+func (s *Server) ReassignReviewer(ctx context.Context, req *reviewerv1.ReassignReviewerRequest) (*reviewerv1.ReassignReviewerResponse, error) {
+	in := dto.ReassignReviewerRequest{
+		PullRequestID:  req.GetPullRequestId(),
+		OldReviewerID:  req.GetOldReviewerId(),
+		AssignmentMode: req.GetAssignmentMode(),
+	}
+	if err := in.Validate(); err != nil {
+		return nil, statusError(err, "invalid request")
+	}
+	res, err := s.svc.ReassignInMode(ctx, req.PullRequestId, req.OldReviewerId, req.AssignmentMode)
+	if err != nil {
+		return nil, statusError(err, "failed to reassign reviewer")
+	}
+	return &reviewerv1.ReassignReviewerResponse{
+		Pr:         pullRequestToProto(&res.PR),
+		ReplacedBy: res.ReplacedBy,
+		Reason:     res.Reason,
+	}, nil
+}
+
+func teamToProto(t *dto.TeamDTO) *reviewerv1.Team {
+	res := &reviewerv1.Team{TeamName: t.TeamName}
+	for _, m := range t.Members {
+		res.Members = append(res.Members, &reviewerv1.TeamMember{UserId: m.UserID, Username: m.Username, IsActive: m.IsActive})
+	}
+	return res
+}
+
+func pullRequestToProto(pr *dto.PullRequestDTO) *reviewerv1.PullRequest {
+	res := &reviewerv1.PullRequest{
+		PullRequestId:     pr.PullRequestID,
+		PullRequestName:   pr.PullRequestName,
+		AuthorId:          pr.AuthorID,
+		Status:            pr.Status,
+		TeamName:          pr.TeamName,
+		Repository:        pr.Repository,
+		RequiredTags:      pr.RequiredTags,
+		AssignedReviewers: pr.AssignedReviewers,
+		CreatedAt:         timestamp(pr.CreatedAt),
+		MergedAt:          timestamp(pr.MergedAt),
+	}
+	for _, m := range pr.ReviewerMatches {
+		res.ReviewerMatches = append(res.ReviewerMatches, &reviewerv1.ReviewerMatch{UserId: m.UserID, Reason: m.Reason})
+	}
+	return res
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"database/sql"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Export - GET /admin/export?format=jsonl|csv&types=team,user,pull_request,reviewer
+//
+// Выгрузка пишется в ответ по мере чтения из базы. Ошибка посреди выгрузки
+// только обрывает ответ: статус к этому моменту уже отправлен.
+func Export(db *sql.DB) http.HandlerFunc {
+	repo := NewAdminRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		q := r.URL.Query()
+		var v dto.Validator
+		format := q.Get("format")
+		if format == "" {
+			format = dto.FormatJSONL
+		}
+		v.OneOf("format", format, dto.FormatJSONL, dto.FormatCSV)
+		types := dto.RecordTypes
+		if s := q.Get("types"); s != "" {
+			types = strings.Split(s, ",")
+			for i, typ := range types {
+				v.OneOf("types["+strconv.Itoa(i)+"]", typ, dto.RecordTypes...)
+			}
+		}
+		if err := v.Err(); err != nil {
+			common.WriteValidationError(w, err)
+			return
+		}
+
+		contentType := "application/x-ndjson"
+		if format == dto.FormatCSV {
+			contentType = "text/csv; charset=utf-8"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="export.`+format+`"`)
+		w.WriteHeader(http.StatusOK)
+
+		if err := repo.Export(r.Context(), types, newRecordWriter(format, w)); err != nil {
+			log.Printf("admin: export failed: %v", err)
+		}
+	}
+}
+
+// MaxImportSize - предельный размер тела загрузки.
+const MaxImportSize = 256 << 20
+
+// Import - POST /admin/import?format=jsonl|csv&strict=true
+//
+// Формат по умолчанию берется из Content-Type (text/csv - CSV, иначе JSON
+// Lines). Тело читается потоком, не больше MaxImportSize.
+func Import(db *sql.DB) http.HandlerFunc {
+	repo := NewAdminRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		q := r.URL.Query()
+		var v dto.Validator
+		format := q.Get("format")
+		if format == "" {
+			format = dto.FormatJSONL
+			if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "text/csv" {
+				format = dto.FormatCSV
+			}
+		}
+		v.OneOf("format", format, dto.FormatJSONL, dto.FormatCSV)
+		var strict bool
+		if s := q.Get("strict"); s != "" {
+			var err error
+			if strict, err = strconv.ParseBool(s); err != nil {
+				v.Add("strict", "must be true or false")
+			}
+		}
+		if err := v.Err(); err != nil {
+			common.WriteValidationError(w, err)
+			return
+		}
+
+		res, err := repo.Import(r.Context(), newRecordReader(format, http.MaxBytesReader(w, r.Body, MaxImportSize)), strict)
+		if err != nil {
+			var failed *dto.ImportFailedError
+			if errors.As(err, &failed) {
+				common.WriteErrorDetails(w, http.StatusUnprocessableEntity, dto.ErrorCodeImportFailed, "import aborted, nothing was imported", failed.Errors)
+				return
+			}
+			log.Printf("admin: import failed: %v", err)
+			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to import records")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, res)
+	}
+}
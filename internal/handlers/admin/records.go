@@ -0,0 +1,271 @@
+package admin
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// колонки CSV; в выгрузке пишутся все, при загрузке обязательна только type
+var csvColumns = []string{
+	"type", "team_name", "user_id", "username", "is_active", "email",
+	"pull_request_id", "pull_request_name", "author_id", "status", "repository",
+	"created_at", "merged_at",
+}
+
+// максимальная длина строки JSON Lines
+const maxLineSize = 1 << 20
+
+type recordWriter interface {
+	Write(rec dto.RecordDTO) error
+	Flush() error
+}
+
+// recordReader возвращает записи по одной. Ошибка разбора строки возвращается
+// как *rowError, и без fatal чтение можно продолжать; прочие ошибки и io.EOF
+// завершают чтение.
+type recordReader interface {
+	Read() (dto.RecordDTO, int, error)
+}
+
+type rowError struct {
+	line    int
+	field   string
+	message string
+	// дальше читать нельзя: неверный заголовок CSV или слишком длинная строка
+	fatal bool
+}
+
+func (e *rowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.message)
+}
+
+func newRecordWriter(format string, w io.Writer) recordWriter {
+	if format == dto.FormatCSV {
+		return &csvWriter{w: csv.NewWriter(w)}
+	}
+	bw := bufio.NewWriter(w)
+	return &jsonlWriter{bw: bw, enc: json.NewEncoder(bw)}
+}
+
+func newRecordReader(format string, r io.Reader) recordReader {
+	if format == dto.FormatCSV {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.ReuseRecord = true
+		return &csvReader{r: cr}
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), maxLineSize)
+	return &jsonlReader{sc: sc}
+}
+
+type jsonlWriter struct {
+	bw  *bufio.Writer
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(rec dto.RecordDTO) error { return w.enc.Encode(rec) }
+func (w *jsonlWriter) Flush() error                  { return w.bw.Flush() }
+
+type jsonlReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func (r *jsonlReader) Read() (dto.RecordDTO, int, error) {
+	for r.sc.Scan() {
+		r.line++
+		if len(r.sc.Bytes()) == 0 {
+			continue
+		}
+		var rec dto.RecordDTO
+		if err := json.Unmarshal(r.sc.Bytes(), &rec); err != nil {
+			return rec, r.line, &rowError{line: r.line, message: "invalid JSON: " + err.Error()}
+		}
+		return rec, r.line, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return dto.RecordDTO{}, r.line + 1, &rowError{line: r.line + 1, message: fmt.Sprintf("line is longer than %d bytes", maxLineSize), fatal: true}
+		}
+		return dto.RecordDTO{}, r.line, err
+	}
+	return dto.RecordDTO{}, r.line, io.EOF
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (w *csvWriter) Write(rec dto.RecordDTO) error {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if err := w.w.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+	row := make([]string, len(csvColumns))
+	for i, col := range csvColumns {
+		row[i] = csvValue(rec, col)
+	}
+	return w.w.Write(row)
+}
+
+func (w *csvWriter) Flush() error {
+	if !w.wroteHeader {
+		// пустая выгрузка - только заголовок
+		w.wroteHeader = true
+		if err := w.w.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func csvValue(rec dto.RecordDTO, col string) string {
+	switch col {
+	case "type":
+		return rec.Type
+	case "team_name":
+		return rec.TeamName
+	case "user_id":
+		return rec.UserID
+	case "username":
+		return rec.Username
+	case "is_active":
+		if rec.IsActive == nil {
+			return ""
+		}
+		return strconv.FormatBool(*rec.IsActive)
+	case "email":
+		return rec.Email
+	case "pull_request_id":
+		return rec.PullRequestID
+	case "pull_request_name":
+		return rec.PullRequestName
+	case "author_id":
+		return rec.AuthorID
+	case "status":
+		return rec.Status
+	case "repository":
+		return rec.Repository
+	case "created_at":
+		return formatTime(rec.CreatedAt)
+	case "merged_at":
+		return formatTime(rec.MergedAt)
+	}
+	return ""
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+func (r *csvReader) Read() (dto.RecordDTO, int, error) {
+	if r.header == nil {
+		header, err := r.r.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return dto.RecordDTO{}, 1, &rowError{line: 1, message: "invalid CSV header: " + parseErr.Err.Error(), fatal: true}
+			}
+			return dto.RecordDTO{}, 1, err
+		}
+		r.header = slices.Clone(header)
+		for _, col := range r.header {
+			if !slices.Contains(csvColumns, col) {
+				return dto.RecordDTO{}, 1, &rowError{line: 1, message: fmt.Sprintf("unknown CSV column %q", col), fatal: true}
+			}
+		}
+		if !slices.Contains(r.header, "type") {
+			return dto.RecordDTO{}, 1, &rowError{line: 1, message: `CSV header must contain the "type" column`, fatal: true}
+		}
+	}
+
+	row, err := r.r.Read()
+	line, _ := r.r.FieldPos(0)
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return dto.RecordDTO{}, parseErr.Line, &rowError{line: parseErr.Line, message: "invalid CSV: " + parseErr.Err.Error()}
+		}
+		return dto.RecordDTO{}, line, err
+	}
+	if len(row) != len(r.header) {
+		return dto.RecordDTO{}, line, &rowError{line: line, message: fmt.Sprintf("expected %d fields, got %d", len(r.header), len(row))}
+	}
+
+	var rec dto.RecordDTO
+	for i, col := range r.header {
+		if err := setCSVValue(&rec, col, row[i]); err != nil {
+			return rec, line, &rowError{line: line, field: col, message: err.Error()}
+		}
+	}
+	return rec, line, nil
+}
+
+func setCSVValue(rec *dto.RecordDTO, col, value string) error {
+	switch col {
+	case "type":
+		rec.Type = value
+	case "team_name":
+		rec.TeamName = value
+	case "user_id":
+		rec.UserID = value
+	case "username":
+		rec.Username = value
+	case "is_active":
+		if value == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		rec.IsActive = &b
+	case "email":
+		rec.Email = value
+	case "pull_request_id":
+		rec.PullRequestID = value
+	case "pull_request_name":
+		rec.PullRequestName = value
+	case "author_id":
+		rec.AuthorID = value
+	case "status":
+		rec.Status = value
+	case "repository":
+		rec.Repository = value
+	case "created_at", "merged_at":
+		if value == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.New("must be an RFC 3339 timestamp")
+		}
+		if col == "created_at" {
+			rec.CreatedAt = &t
+		} else {
+			rec.MergedAt = &t
+		}
+	}
+	return nil
+}
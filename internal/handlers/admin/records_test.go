@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"AvitoInternship/internal/handlers/dto"
+)
+
+func sampleRecords() []dto.RecordDTO {
+	active, inactive := true, false
+	created := time.Date(2025, 3, 1, 10, 30, 0, 123456000, time.UTC)
+	merged := created.Add(90 * time.Minute)
+	return []dto.RecordDTO{
+		{Type: dto.RecordTeam, TeamName: "backend"},
+		{Type: dto.RecordUser, UserID: "u1", Username: "Ivan, \"the\" dev", IsActive: &active, TeamName: "backend", Email: "ivan@example.com"},
+		{Type: dto.RecordUser, UserID: "u2", Username: "Petr", IsActive: &inactive},
+		{Type: dto.RecordPullRequest, PullRequestID: "pr-1", PullRequestName: "Fix\nbug", AuthorID: "u1", Status: "MERGED",
+			TeamName: "backend", Repository: "org/repo", CreatedAt: &created, MergedAt: &merged},
+		{Type: dto.RecordReviewer, PullRequestID: "pr-1", UserID: "u2"},
+	}
+}
+
+func TestRecordsRoundTrip(t *testing.T) {
+	for _, format := range []string{dto.FormatJSONL, dto.FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := newRecordWriter(format, &buf)
+			want := sampleRecords()
+			for _, rec := range want {
+				if err := w.Write(rec); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			rd := newRecordReader(format, &buf)
+			var got []dto.RecordDTO
+			for {
+				rec, _, err := rd.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, rec)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip:\n got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestRecordReaderRowErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		// строки, на которых ожидается ошибка записи; остальные читаются
+		errLines []int
+		fatal    bool
+	}{
+		{
+			name:     "jsonl invalid line",
+			format:   dto.FormatJSONL,
+			input:    "{\"type\":\"team\",\"team_name\":\"a\"}\n\n{oops\n{\"type\":\"team\",\"team_name\":\"b\"}\n",
+			errLines: []int{3},
+		},
+		{
+			name:     "csv field count and bad values",
+			format:   dto.FormatCSV,
+			input:    "type,user_id,is_active,created_at\nuser,u1,true,\nuser,u2\nuser,u3,yes,\nuser,u4,,yesterday\nuser,u5,false,2025-01-01T00:00:00Z\n",
+			errLines: []int{3, 4, 5},
+		},
+		{
+			name:     "csv unknown column",
+			format:   dto.FormatCSV,
+			input:    "type,color\nteam,red\n",
+			errLines: []int{1},
+			fatal:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd := newRecordReader(tt.format, strings.NewReader(tt.input))
+			var errLines []int
+			for {
+				_, line, err := rd.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				var rowErr *rowError
+				if err != nil && !errors.As(err, &rowErr) {
+					t.Fatal(err)
+				}
+				if rowErr == nil {
+					continue
+				}
+				if rowErr.line != line {
+					t.Errorf("row error line %d, reader line %d", rowErr.line, line)
+				}
+				errLines = append(errLines, rowErr.line)
+				if rowErr.fatal != tt.fatal {
+					t.Errorf("line %d: fatal = %v", rowErr.line, rowErr.fatal)
+				}
+				if rowErr.fatal {
+					break
+				}
+			}
+			if !reflect.DeepEqual(errLines, tt.errLines) {
+				t.Errorf("error lines = %v, want %v", errLines, tt.errLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"context"
+	"database/sql"
+	"slices"
+)
+
+const exportTeamsSQL = `
+SELECT name FROM team ORDER BY name;
+`
+
+// удаленные авторы и ревьюеры MERGED PR выгружаются неактивными без команды,
+// чтобы их PR загрузились обратно
+const exportUsersSQL = `
+SELECT u.id, u.name, u.is_active, COALESCE(t.name, ''), COALESCE(u.email, '')
+FROM "user" u
+LEFT JOIN team t ON t.id = u.team_id
+ORDER BY u.id;
+`
+
+// время смержа - updated_at, как в /pullRequest/merge
+const exportPullRequestsSQL = `
+SELECT pr.id, pr.title, pr.author_id, pr.status, COALESCE(t.name, ''), COALESCE(pr.repository, ''), pr.created_at, pr.updated_at
+FROM pull_request pr
+LEFT JOIN team t ON t.id = pr.team_id
+ORDER BY pr.id;
+`
+
+const exportReviewersSQL = `
+SELECT pr_id, user_id FROM pull_request_reviewer ORDER BY pr_id, user_id;
+`
+
+type AdminRepository struct {
+	db        *sql.DB
+	batchSize int
+}
+
+func NewAdminRepository(db *sql.DB) *AdminRepository {
+	return &AdminRepository{db: db, batchSize: importBatchSize}
+}
+
+// Export пишет записи типов types в порядке dto.RecordTypes. Все выборки идут
+// в одной транзакции REPEATABLE READ, поэтому выгрузка согласована, а строки
+// читаются из базы по мере записи и не накапливаются в памяти.
+func (r *AdminRepository) Export(ctx context.Context, types []string, w recordWriter) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	exports := map[string]struct {
+		query string
+		scan  func(*sql.Rows, *dto.RecordDTO) error
+	}{
+		dto.RecordTeam: {exportTeamsSQL, func(rows *sql.Rows, rec *dto.RecordDTO) error {
+			return rows.Scan(&rec.TeamName)
+		}},
+		dto.RecordUser: {exportUsersSQL, func(rows *sql.Rows, rec *dto.RecordDTO) error {
+			var active bool
+			if err := rows.Scan(&rec.UserID, &rec.Username, &active, &rec.TeamName, &rec.Email); err != nil {
+				return err
+			}
+			rec.IsActive = &active
+			return nil
+		}},
+		dto.RecordPullRequest: {exportPullRequestsSQL, func(rows *sql.Rows, rec *dto.RecordDTO) error {
+			var createdAt sql.NullTime
+			var updatedAt sql.NullTime
+			if err := rows.Scan(&rec.PullRequestID, &rec.PullRequestName, &rec.AuthorID, &rec.Status,
+				&rec.TeamName, &rec.Repository, &createdAt, &updatedAt); err != nil {
+				return err
+			}
+			if createdAt.Valid {
+				rec.CreatedAt = &createdAt.Time
+			}
+			if rec.Status == "MERGED" && updatedAt.Valid {
+				rec.MergedAt = &updatedAt.Time
+			}
+			return nil
+		}},
+		dto.RecordReviewer: {exportReviewersSQL, func(rows *sql.Rows, rec *dto.RecordDTO) error {
+			return rows.Scan(&rec.PullRequestID, &rec.UserID)
+		}},
+	}
+
+	for _, typ := range dto.RecordTypes {
+		if !slices.Contains(types, typ) {
+			continue
+		}
+		e := exports[typ]
+		if err := exportRows(ctx, tx, typ, e.query, e.scan, w); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func exportRows(ctx context.Context, tx *sql.Tx, typ, query string, scan func(*sql.Rows, *dto.RecordDTO) error, w recordWriter) error {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rec := dto.RecordDTO{Type: typ}
+		if err := scan(rows, &rec); err != nil {
+			return err
+		}
+		if err := w.Write(rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,273 @@
+package admin
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const importTeamSQL = `
+INSERT INTO team(name) VALUES ($1) ON CONFLICT (name) DO NOTHING;
+`
+
+const importUserSQL = `
+INSERT INTO "user"(id, name, is_active, team_id, email)
+VALUES ($1, $2, COALESCE($3, TRUE), $4, NULLIF($5, ''))
+ON CONFLICT (id) DO UPDATE
+SET name = EXCLUDED.name,
+    is_active = COALESCE($3, "user".is_active OR "user".deleted_at IS NOT NULL),
+    team_id = EXCLUDED.team_id,
+    email = COALESCE(EXCLUDED.email, "user".email),
+    deleted_at = NULL;
+`
+
+// без team_name PR принадлежит команде автора, как при /pullRequest/create
+const importPullRequestSQL = `
+INSERT INTO pull_request(id, title, author_id, status, team_id, repository, created_at, updated_at)
+VALUES ($1, $2, $3, $4, COALESCE($5::int, (SELECT team_id FROM "user" WHERE id = $3)), NULLIF($6, ''),
+        COALESCE($7::timestamp, NOW()), $8::timestamp)
+ON CONFLICT (id) DO UPDATE
+SET title = EXCLUDED.title,
+    author_id = EXCLUDED.author_id,
+    status = EXCLUDED.status,
+    team_id = EXCLUDED.team_id,
+    repository = EXCLUDED.repository,
+    created_at = COALESCE($7::timestamp, pull_request.created_at),
+    updated_at = EXCLUDED.updated_at;
+`
+
+const importReviewerSQL = `
+INSERT INTO pull_request_reviewer(pr_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+`
+
+// поля записи, на которые ссылаются внешние ключи
+var foreignKeyFields = map[string]string{
+	"pull_request_author_id_fkey":        "author_id",
+	"pull_request_team_id_fkey":          "team_name",
+	"pull_request_reviewer_pr_id_fkey":   "pull_request_id",
+	"pull_request_reviewer_user_id_fkey": "user_id",
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// importBatchSize - число записей в одной транзакции загрузки без strict
+const importBatchSize = 500
+
+// Import загружает записи из rd. Записи применяются по одной в порядке
+// входных данных, поэтому команды должны идти раньше пользователей, а
+// пользователи - раньше PR. Без strict записи применяются пачками по
+// r.batchSize в отдельных транзакциях, ошибочная запись откатывается до точки
+// сохранения, а ошибки записей собираются в результат; со strict все записи
+// применяются одной транзакцией, и первая ошибка отменяет загрузку
+// (*dto.ImportFailedError). Тело больше лимита (*http.MaxBytesError) - ошибка
+// последней строки, после которой чтение прекращается. События в outbox при
+// загрузке не пишутся.
+func (r *AdminRepository) Import(ctx context.Context, rd recordReader, strict bool) (*dto.ImportResultDTO, error) {
+	res := &dto.ImportResultDTO{Imported: make(map[string]int), Errors: make([]dto.ImportErrorDTO, 0)}
+	for _, typ := range dto.RecordTypes {
+		res.Imported[typ] = 0
+	}
+
+	tx, err := r.beginImport(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// tx заменяется после каждой пачки; откатывается последняя
+	defer func() { _ = tx.Rollback() }()
+
+	for {
+		rec, line, err := rd.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *rowError
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = &rowError{line: line, message: fmt.Sprintf("request body must be at most %d bytes", tooLarge.Limit), fatal: true}
+		}
+		if err != nil && !errors.As(err, &rowErr) {
+			return nil, err
+		}
+		res.Processed++
+
+		var errs []dto.ImportErrorDTO
+		switch {
+		case rowErr != nil:
+			errs = []dto.ImportErrorDTO{{Line: rowErr.line, Type: rec.Type, Field: rowErr.field, Message: rowErr.message}}
+		case strict:
+			if errs, err = importRecord(ctx, tx, line, rec); err != nil {
+				return nil, err
+			}
+		default:
+			if errs, err = importInSavepoint(ctx, tx, line, rec); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(errs) == 0 {
+			res.Imported[rec.Type]++
+		} else {
+			res.Failed++
+			if strict {
+				return nil, &dto.ImportFailedError{Errors: errs}
+			}
+			for _, e := range errs {
+				if len(res.Errors) == dto.MaxImportErrors {
+					res.ErrorsTruncated = true
+					break
+				}
+				res.Errors = append(res.Errors, e)
+			}
+			if rowErr != nil && rowErr.fatal {
+				break
+			}
+		}
+
+		if !strict && res.Processed%r.batchSize == 0 {
+			if err := tx.Commit(); err != nil {
+				return nil, err
+			}
+			next, err := r.beginImport(ctx)
+			if err != nil {
+				return nil, err
+			}
+			tx = next
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *AdminRepository) beginImport(ctx context.Context) (*sql.Tx, error) {
+	return r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+}
+
+// importInSavepoint применяет запись в транзакции пачки: ошибка записи
+// откатывает только ее, и транзакцию можно продолжать.
+func importInSavepoint(ctx context.Context, tx *sql.Tx, line int, rec dto.RecordDTO) ([]dto.ImportErrorDTO, error) {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT import_record`); err != nil {
+		return nil, err
+	}
+	errs, err := importRecord(ctx, tx, line, rec)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_record`); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT import_record`); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// importRecord проверяет и применяет запись. Ошибки записи (валидация,
+// ссылки на несуществующие записи, недопустимые данные) возвращаются списком,
+// прочие ошибки базы - через error.
+func importRecord(ctx context.Context, ex execer, line int, rec dto.RecordDTO) ([]dto.ImportErrorDTO, error) {
+	if err := rec.Validate(); err != nil {
+		var verr *dto.ValidationError
+		if !errors.As(err, &verr) {
+			return nil, err
+		}
+		errs := make([]dto.ImportErrorDTO, 0, len(verr.Fields))
+		for _, f := range verr.Fields {
+			errs = append(errs, dto.ImportErrorDTO{Line: line, Type: rec.Type, Field: f.Field, Message: f.Message})
+		}
+		return errs, nil
+	}
+
+	err := applyRecord(ctx, ex, rec)
+	var rowErr *rowError
+	var pgErr *pq.Error
+	switch {
+	case err == nil:
+		return nil, nil
+	case errors.As(err, &rowErr):
+		return []dto.ImportErrorDTO{{Line: line, Type: rec.Type, Field: rowErr.field, Message: rowErr.message}}, nil
+	case errors.As(err, &pgErr) && pgErr.Code == "23503":
+		return []dto.ImportErrorDTO{{Line: line, Type: rec.Type, Field: foreignKeyFields[pgErr.Constraint], Message: "references a missing record"}}, nil
+	case errors.As(err, &pgErr) && (strings.HasPrefix(string(pgErr.Code), "22") || strings.HasPrefix(string(pgErr.Code), "23")):
+		// недопустимые данные (22) и прочие нарушения ограничений (23)
+		return []dto.ImportErrorDTO{{Line: line, Type: rec.Type, Message: pgErr.Message}}, nil
+	default:
+		return nil, err
+	}
+}
+
+func applyRecord(ctx context.Context, ex execer, rec dto.RecordDTO) error {
+	switch rec.Type {
+	case dto.RecordTeam:
+		_, err := ex.ExecContext(ctx, importTeamSQL, rec.TeamName)
+		return err
+
+	case dto.RecordUser:
+		teamID, err := lookupTeam(ctx, ex, rec.TeamName)
+		if err != nil {
+			return err
+		}
+		var isActive sql.NullBool
+		if rec.IsActive != nil {
+			isActive = sql.NullBool{Bool: *rec.IsActive, Valid: true}
+		}
+		_, err = ex.ExecContext(ctx, importUserSQL, rec.UserID, rec.Username, isActive, teamID, rec.Email)
+		return err
+
+	case dto.RecordPullRequest:
+		teamID, err := lookupTeam(ctx, ex, rec.TeamName)
+		if err != nil {
+			return err
+		}
+		status := rec.Status
+		if status == "" {
+			status = "OPEN"
+		}
+		_, err = ex.ExecContext(ctx, importPullRequestSQL, rec.PullRequestID, rec.PullRequestName, rec.AuthorID, status,
+			teamID, rec.Repository, nullTime(rec.CreatedAt), nullTime(rec.MergedAt))
+		return err
+
+	case dto.RecordReviewer:
+		_, err := ex.ExecContext(ctx, importReviewerSQL, rec.PullRequestID, rec.UserID)
+		return err
+	}
+	return nil
+}
+
+// lookupTeam возвращает id команды или NULL для пустого имени.
+func lookupTeam(ctx context.Context, ex execer, name string) (sql.NullInt64, error) {
+	if name == "" {
+		return sql.NullInt64{}, nil
+	}
+	var id int64
+	if err := ex.QueryRowContext(ctx, `SELECT id FROM team WHERE name = $1`, name).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sql.NullInt64{}, &rowError{field: "team_name", message: "team not found"}
+		}
+		return sql.NullInt64{}, err
+	}
+	return sql.NullInt64{Int64: id, Valid: true}, nil
+}
+
+// время передается в UTC: в этом поясе его читает выгрузка
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
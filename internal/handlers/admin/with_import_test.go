@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"AvitoInternship/internal/handlers/dto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func expectSavepoint(mock sqlmock.Sqlmock, failed bool) {
+	if failed {
+		mock.ExpectExec(regexp.QuoteMeta(`ROLLBACK TO SAVEPOINT import_record`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec(regexp.QuoteMeta(`RELEASE SAVEPOINT import_record`)).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestImportCollectsRowErrorsInBatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := NewAdminRepository(db)
+	repo.batchSize = 2
+
+	input := strings.Join([]string{
+		`{"type":"team","team_name":"backend"}`,
+		`{"type":"user","user_id":"u1","username":"Ivan","team_name":"missing"}`,
+		`{"type":"user","user_id":"","username":"Petr"}`,
+		`{"type":"reviewer","pull_request_id":"pr-x","user_id":"u1"}`,
+		`{oops`,
+	}, "\n")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT import_record`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(importTeamSQL)).WithArgs("backend").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSavepoint(mock, false)
+
+	mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT import_record`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM team WHERE name = $1`)).WithArgs("missing").WillReturnError(sql.ErrNoRows)
+	expectSavepoint(mock, true)
+	// пачка из двух записей фиксируется
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT import_record`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectSavepoint(mock, true)
+
+	// нарушение внешнего ключа прерывает только свою запись
+	mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT import_record`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(importReviewerSQL)).WithArgs("pr-x", "u1").
+		WillReturnError(&pq.Error{Code: "23503", Constraint: "pull_request_reviewer_pr_id_fkey"})
+	expectSavepoint(mock, true)
+	mock.ExpectCommit()
+
+	// ошибка разбора строки не доходит до базы
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	res, err := repo.Import(context.Background(), newRecordReader(dto.FormatJSONL, strings.NewReader(input)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Processed != 5 || res.Failed != 4 || res.Imported[dto.RecordTeam] != 1 {
+		t.Errorf("res = %+v", res)
+	}
+	var got [][2]any
+	for _, e := range res.Errors {
+		got = append(got, [2]any{e.Line, e.Field})
+	}
+	want := [][2]any{{2, "team_name"}, {3, "user_id"}, {4, "pull_request_id"}, {5, ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("errors = %+v", res.Errors)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportStrictRollsBackOnFirstError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := NewAdminRepository(db)
+
+	input := `{"type":"team","team_name":"backend"}` + "\n" + `{"type":"team","team_name":""}` + "\n"
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(importTeamSQL)).WithArgs("backend").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	_, err = repo.Import(context.Background(), newRecordReader(dto.FormatJSONL, strings.NewReader(input)), true)
+	var failed *dto.ImportFailedError
+	if !errors.As(err, &failed) || len(failed.Errors) != 1 || failed.Errors[0].Line != 2 {
+		t.Fatalf("err = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportStopsAtBodyLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := NewAdminRepository(db)
+
+	first := `{"type":"team","team_name":"backend"}` + "\n"
+	input := first + `{"type":"team","team_name":"frontend"}` + "\n"
+	body := http.MaxBytesReader(httptest.NewRecorder(), readCloser{strings.NewReader(input)}, int64(len(first)))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SAVEPOINT import_record`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(importTeamSQL)).WithArgs("backend").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSavepoint(mock, false)
+	// загруженное до превышения лимита фиксируется
+	mock.ExpectCommit()
+
+	res, err := repo.Import(context.Background(), newRecordReader(dto.FormatJSONL, body), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported[dto.RecordTeam] != 1 || res.Failed != 1 || len(res.Errors) != 1 ||
+		!strings.Contains(res.Errors[0].Message, "must be at most") {
+		t.Errorf("res = %+v", res)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type readCloser struct{ *strings.Reader }
+
+func (readCloser) Close() error { return nil }
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ParseClock разбирает время суток "HH:MM" и возвращает число минут от полуночи.
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// WithinHours сообщает, попадает ли момент now в рабочие часы [start, end) в
+// часовом поясе loc. Интервал может переходить через полночь (например, 22:00-06:00).
+// Совпадающие start и end означают, что ограничения нет.
+func WithinHours(now time.Time, loc *time.Location, start, end int) bool {
+	if start == end {
+		return true
+	}
+	local := now.In(loc)
+	m := local.Hour()*60 + local.Minute()
+	if start <= end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+var locations sync.Map
+
+// LoadLocation - time.LoadLocation с кэшем: часовой пояс проверяется для
+// каждого кандидата при каждом выборе ревьюеров.
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithinHours(t *testing.T) {
+	loc, err := LoadLocation("Asia/Novosibirsk") // UTC+7
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(h, m int) time.Time { return time.Date(2025, 3, 10, h, m, 0, 0, loc).UTC() }
+	clock := func(s string) int {
+		m, err := ParseClock(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	tests := []struct {
+		now        time.Time
+		start, end string
+		want       bool
+	}{
+		{at(9, 0), "09:00", "18:00", true},
+		{at(17, 59), "09:00", "18:00", true},
+		{at(18, 0), "09:00", "18:00", false},
+		{at(8, 59), "09:00", "18:00", false},
+		{at(23, 0), "22:00", "06:00", true},
+		{at(5, 59), "22:00", "06:00", true},
+		{at(12, 0), "22:00", "06:00", false},
+		{at(3, 0), "09:00", "09:00", true},
+	}
+	for _, tt := range tests {
+		if got := WithinHours(tt.now, loc, clock(tt.start), clock(tt.end)); got != tt.want {
+			t.Errorf("WithinHours(%s, %s-%s) = %v, want %v", tt.now.In(loc).Format("15:04"), tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestLoadLocationCached(t *testing.T) {
+	a, err := LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := LoadLocation("Europe/Moscow")
+	if a != b {
+		t.Error("expected the cached location to be reused")
+	}
+	if _, err := LoadLocation("Mars/Olympus"); err == nil {
+		t.Error("expected an error for an unknown zone")
+	}
+}
@@ -0,0 +1,98 @@
+package common
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxBodySize - предельный размер тела JSON-запроса.
+const MaxBodySize = 1 << 20
+
+// DecodeJSON читает тело запроса в dst и проверяет его: см. Decode и Validate.
+// При ошибке ответ уже записан и возвращается false.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	return Decode(w, r, dst) && Validate(w, dst)
+}
+
+// Decode читает тело запроса в dst: не больше MaxBodySize, без неизвестных
+// полей и лишних данных после объекта. Нужен, когда перед проверкой в DTO
+// подставляются параметры пути.
+func Decode(w http.ResponseWriter, r *http.Request, dst any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(dst)
+	if err == nil {
+		if _, tokErr := dec.Token(); tokErr != io.EOF {
+			err = errTrailingData
+		}
+	}
+	if err != nil {
+		writeDecodeError(w, err)
+		return false
+	}
+	return true
+}
+
+// Validate проверяет dst, если он реализует Validate() error.
+func Validate(w http.ResponseWriter, dst any) bool {
+	if v, ok := dst.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			WriteValidationError(w, err)
+			return false
+		}
+	}
+	return true
+}
+
+var errTrailingData = errors.New("trailing data")
+
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &tooLarge):
+		WriteErrorDetails(w, http.StatusRequestEntityTooLarge, dto.ErrorCodeValidation, "request body is too large",
+			[]dto.FieldErrorDTO{{Message: fmt.Sprintf("request body must be at most %d bytes", tooLarge.Limit)}})
+	case errors.As(err, &typeErr):
+		WriteValidationError(w, &dto.ValidationError{Fields: []dto.FieldErrorDTO{
+			{Field: typeErr.Field, Message: "must be " + jsonTypeName(typeErr.Type.Kind().String())},
+		}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		WriteValidationError(w, &dto.ValidationError{Fields: []dto.FieldErrorDTO{{Field: field, Message: "unknown field"}}})
+	case errors.Is(err, errTrailingData):
+		WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "request body must contain a single JSON object")
+	default:
+		WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "invalid request body")
+	}
+}
+
+func jsonTypeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "bool":
+		return "a boolean"
+	case kind == "string":
+		return "a string"
+	case kind == "slice", kind == "array":
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// WriteValidationError пишет 400 VALIDATION_ERROR со списком полей в details.
+func WriteValidationError(w http.ResponseWriter, err error) {
+	var verr *dto.ValidationError
+	if !errors.As(err, &verr) {
+		WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, err.Error())
+		return
+	}
+	WriteErrorDetails(w, http.StatusBadRequest, dto.ErrorCodeValidation, "request validation failed", verr.Fields)
+}
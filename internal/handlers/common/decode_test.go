@@ -0,0 +1,84 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"AvitoInternship/internal/handlers/dto"
+)
+
+type decodeTarget struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (d decodeTarget) Validate() error {
+	var v dto.Validator
+	v.Name("name", d.Name)
+	return v.Err()
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+		field  string
+	}{
+		{"ok", `{"name":"a","count":1}`, http.StatusOK, "", ""},
+		{"ok with trailing whitespace", "{\"name\":\"a\"}\n  ", http.StatusOK, "", ""},
+		{"unknown field", `{"name":"a","extra":1}`, http.StatusBadRequest, dto.ErrorCodeValidation, "extra"},
+		{"wrong type", `{"name":"a","count":"1"}`, http.StatusBadRequest, dto.ErrorCodeValidation, "count"},
+		{"trailing object", `{"name":"a"}{"name":"b"}`, http.StatusBadRequest, dto.ErrorBadRequest, ""},
+		{"trailing garbage", `{"name":"a"} x`, http.StatusBadRequest, dto.ErrorBadRequest, ""},
+		{"malformed", `{"name":`, http.StatusBadRequest, dto.ErrorBadRequest, ""},
+		{"empty", ``, http.StatusBadRequest, dto.ErrorBadRequest, ""},
+		{"validation", `{"name":""}`, http.StatusBadRequest, dto.ErrorCodeValidation, "name"},
+		{"too large", `{"name":"` + strings.Repeat("a", MaxBodySize) + `"}`, http.StatusRequestEntityTooLarge, dto.ErrorCodeValidation, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			var dst decodeTarget
+			ok := DecodeJSON(rec, req, &dst)
+
+			if ok != (tt.status == http.StatusOK) {
+				t.Fatalf("DecodeJSON = %v, response %d %s", ok, rec.Code, rec.Body)
+			}
+			if ok {
+				return
+			}
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			var resp ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error.Code != tt.code {
+				t.Errorf("code = %s, want %s", resp.Error.Code, tt.code)
+			}
+			if tt.field != "" {
+				details, _ := json.Marshal(resp.Error.Details)
+				var fields []dto.FieldErrorDTO
+				if err := json.Unmarshal(details, &fields); err != nil || len(fields) != 1 || fields[0].Field != tt.field {
+					t.Errorf("details = %s, want field %s", details, tt.field)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteValidationErrorFallback(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteValidationError(rec, errors.New("boom"))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), dto.ErrorBadRequest) {
+		t.Errorf("response %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,151 @@
+package common
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeCursor возвращает непрозрачную строку next_cursor.
+func EncodeCursor(c dto.Cursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.Sort + "\x00" + c.Key + "\x00" + c.ID))
+}
+
+func DecodeCursor(s string) (*dto.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	parts := strings.Split(string(raw), "\x00")
+	if len(parts) != 3 || parts[2] == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	return &dto.Cursor{Sort: parts[0], Key: parts[1], ID: parts[2]}, nil
+}
+
+// ParsePage разбирает limit, cursor и sort. defaultLimit 0 - без ограничения,
+// пока не задан limit или cursor. Ошибки записываются в v.
+func ParsePage(q url.Values, defaultLimit int, defaultSort string, sorts []string, v *dto.Validator) dto.PageRequest {
+	page := dto.PageRequest{Limit: defaultLimit, Sort: defaultSort}
+	s := q.Get("sort")
+	if c, err := DecodeCursor(q.Get("cursor")); s == "" && err == nil && slices.Contains(sorts, c.Sort) {
+		// без sort продолжаем в порядке, для которого выдан курсор
+		page.Sort = c.Sort
+	}
+	if s != "" {
+		if slices.Contains(sorts, s) {
+			page.Sort = s
+		} else {
+			v.OneOf("sort", s, sorts...)
+		}
+	}
+	if c := q.Get("cursor"); c != "" {
+		cursor, err := DecodeCursor(c)
+		switch {
+		case err != nil:
+			v.Add("cursor", "is invalid")
+		case cursor.Sort != page.Sort:
+			v.Add("cursor", "was issued for sort %q", cursor.Sort)
+		default:
+			page.After = cursor
+		}
+		if page.Limit == 0 {
+			page.Limit = dto.DefaultPageLimit
+		}
+	}
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > dto.MaxPageLimit {
+			v.Add("limit", "must be between 1 and %d", dto.MaxPageLimit)
+		} else {
+			page.Limit = n
+		}
+	}
+	return page
+}
+
+// ParsePullRequestFilter разбирает фильтры списков PR: status, author_id,
+// repository, team_name, created_from, created_to (RFC 3339) и параметры страницы.
+func ParsePullRequestFilter(q url.Values, defaultLimit int, defaultSort string, v *dto.Validator) dto.PullRequestFilter {
+	f := dto.PullRequestFilter{
+		AuthorID:   q.Get("author_id"),
+		Status:     q.Get("status"),
+		Repository: q.Get("repository"),
+		TeamName:   q.Get("team_name"),
+		Page:       ParsePage(q, defaultLimit, defaultSort, dto.PullRequestSorts, v),
+	}
+	if f.Status != "" {
+		v.OneOf("status", f.Status, "OPEN", "MERGED")
+	}
+	f.CreatedFrom = parseTimeParam(q, "created_from", v)
+	f.CreatedTo = parseTimeParam(q, "created_to", v)
+	if f.CreatedFrom != nil && f.CreatedTo != nil && !f.CreatedTo.After(*f.CreatedFrom) {
+		v.Add("created_to", "must be after created_from")
+	}
+	return f
+}
+
+// ParseUserFilter разбирает фильтры списков пользователей: team_name,
+// active_only и параметры страницы.
+func ParseUserFilter(q url.Values, defaultLimit int, v *dto.Validator) dto.UserFilter {
+	f := dto.UserFilter{
+		TeamName: q.Get("team_name"),
+		Page:     ParsePage(q, defaultLimit, dto.SortID, dto.UserSorts, v),
+	}
+	if s := q.Get("active_only"); s != "" {
+		active, err := strconv.ParseBool(s)
+		if err != nil {
+			v.Add("active_only", "must be true or false")
+		}
+		f.ActiveOnly = active
+	}
+	return f
+}
+
+func parseTimeParam(q url.Values, name string, v *dto.Validator) *time.Time {
+	s := q.Get(name)
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		v.Add(name, "must be an RFC 3339 timestamp")
+		return nil
+	}
+	return &t
+}
+
+// Keyset дописывает к запросу условие "после курсора" и ORDER BY для
+// постраничной выдачи по (key, id). key - выражение ключа сортировки (для
+// сортировки по id совпадает с id), cast - тип, к которому приводится значение
+// ключа из курсора. args пополняется параметрами, where - уже собранные условия.
+//
+// Порядок (key, id) не совпадает с порядком фиксации: запись, зафиксированная
+// во время обхода, может оказаться до курсора и в этот обход не попасть.
+// Существующие записи не пропускаются и не повторяются.
+func Keyset(where []string, args []any, key, cast, id string, desc bool, after *dto.Cursor) ([]string, []any, string) {
+	op, dir := ">", "ASC"
+	if desc {
+		op, dir = "<", "DESC"
+	}
+	if after != nil {
+		if key == id {
+			args = append(args, after.ID)
+			where = append(where, fmt.Sprintf("%s %s $%d", id, op, len(args)))
+		} else {
+			args = append(args, after.Key, after.ID)
+			where = append(where, fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d)", key, id, op, len(args)-1, cast, len(args)))
+		}
+	}
+	order := fmt.Sprintf("%s %s", id, dir)
+	if key != id {
+		order = fmt.Sprintf("%s %s, %s %s", key, dir, id, dir)
+	}
+	return where, args, order
+}
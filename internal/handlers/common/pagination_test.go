@@ -0,0 +1,198 @@
+package common
+
+import (
+	"encoding/base64"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"AvitoInternship/internal/handlers/dto"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []dto.Cursor{
+		{Sort: dto.SortID, ID: "pr-1"},
+		{Sort: dto.SortCreatedAtDesc, Key: "2025-01-02T03:04:05.123456Z", ID: "pr-2"},
+		{Sort: dto.SortName, Key: "Алиса / ?&=", ID: "u/1"},
+	}
+	for _, want := range tests {
+		s := EncodeCursor(want)
+		if url.QueryEscape(s) != s {
+			t.Errorf("cursor %q is not URL-safe", s)
+		}
+		got, err := DecodeCursor(s)
+		if err != nil {
+			t.Fatalf("DecodeCursor(%q): %v", s, err)
+		}
+		if *got != want {
+			t.Errorf("round trip = %+v, want %+v", *got, want)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for _, s := range []string{
+		"",
+		"not base64!",
+		EncodeCursor(dto.Cursor{Sort: dto.SortID}),
+		raw("id\x00pr-1"),
+		raw("id\x00\x00pr-1\x00extra"),
+	} {
+		if c, err := DecodeCursor(s); err == nil {
+			t.Errorf("DecodeCursor(%q) = %+v, want error", s, c)
+		}
+	}
+}
+
+func TestParsePage(t *testing.T) {
+	byID := dto.Cursor{Sort: dto.SortID, ID: "pr-9"}
+	byCreatedDesc := dto.Cursor{Sort: dto.SortCreatedAtDesc, Key: "2025-01-01T00:00:00Z", ID: "pr-3"}
+	tests := []struct {
+		name         string
+		query        string
+		defaultLimit int
+		want         dto.PageRequest
+		fields       []string
+	}{
+		{
+			name: "defaults",
+			want: dto.PageRequest{Sort: dto.SortCreatedAtDesc},
+		},
+		{
+			name:         "default limit",
+			defaultLimit: 20,
+			want:         dto.PageRequest{Limit: 20, Sort: dto.SortCreatedAtDesc},
+		},
+		{
+			name:  "explicit sort and limit",
+			query: "sort=id&limit=10",
+			want:  dto.PageRequest{Limit: 10, Sort: dto.SortID},
+		},
+		{
+			name:  "cursor implies its sort and default limit",
+			query: "cursor=" + EncodeCursor(byID),
+			want:  dto.PageRequest{Limit: dto.DefaultPageLimit, Sort: dto.SortID, After: &byID},
+		},
+		{
+			name:  "cursor with matching sort",
+			query: "sort=-created_at&limit=5&cursor=" + EncodeCursor(byCreatedDesc),
+			want:  dto.PageRequest{Limit: 5, Sort: dto.SortCreatedAtDesc, After: &byCreatedDesc},
+		},
+		{
+			name:   "cursor for another sort",
+			query:  "sort=-id&cursor=" + EncodeCursor(byID),
+			want:   dto.PageRequest{Limit: dto.DefaultPageLimit, Sort: dto.SortIDDesc},
+			fields: []string{"cursor"},
+		},
+		{
+			name:   "cursor with unknown sort",
+			query:  "cursor=" + EncodeCursor(dto.Cursor{Sort: dto.SortName, ID: "u1"}),
+			want:   dto.PageRequest{Limit: dto.DefaultPageLimit, Sort: dto.SortCreatedAtDesc},
+			fields: []string{"cursor"},
+		},
+		{
+			name:   "garbage cursor",
+			query:  "cursor=%21%21",
+			want:   dto.PageRequest{Limit: dto.DefaultPageLimit, Sort: dto.SortCreatedAtDesc},
+			fields: []string{"cursor"},
+		},
+		{
+			name:   "unknown sort",
+			query:  "sort=name",
+			want:   dto.PageRequest{Sort: dto.SortCreatedAtDesc},
+			fields: []string{"sort"},
+		},
+		{
+			name:   "limit below range",
+			query:  "limit=0",
+			want:   dto.PageRequest{Sort: dto.SortCreatedAtDesc},
+			fields: []string{"limit"},
+		},
+		{
+			name:   "limit above range",
+			query:  "limit=501",
+			want:   dto.PageRequest{Sort: dto.SortCreatedAtDesc},
+			fields: []string{"limit"},
+		},
+		{
+			name:   "limit not a number",
+			query:  "limit=ten",
+			want:   dto.PageRequest{Sort: dto.SortCreatedAtDesc},
+			fields: []string{"limit"},
+		},
+		{
+			name:  "max limit",
+			query: "limit=500",
+			want:  dto.PageRequest{Limit: dto.MaxPageLimit, Sort: dto.SortCreatedAtDesc},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var v dto.Validator
+			got := ParsePage(q, tt.defaultLimit, dto.SortCreatedAtDesc, dto.PullRequestSorts, &v)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("page = %+v, want %+v", got, tt.want)
+			}
+			var fields []string
+			if verr, ok := v.Err().(*dto.ValidationError); ok {
+				for _, f := range verr.Fields {
+					fields = append(fields, f.Field)
+				}
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("invalid fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	after := &dto.Cursor{Sort: dto.SortCreatedAt, Key: "2025-01-01T00:00:00Z", ID: "pr-1"}
+	tests := []struct {
+		name  string
+		key   string
+		desc  bool
+		after *dto.Cursor
+		where []string
+		args  []any
+		order string
+	}{
+		{
+			name:  "by id first page",
+			key:   "pr.id",
+			where: []string{"pr.status = $1"},
+			args:  []any{"OPEN"},
+			order: "pr.id ASC",
+		},
+		{
+			name:  "by id after cursor desc",
+			key:   "pr.id",
+			desc:  true,
+			after: after,
+			where: []string{"pr.status = $1", "pr.id < $2"},
+			args:  []any{"OPEN", "pr-1"},
+			order: "pr.id DESC",
+		},
+		{
+			name:  "by key after cursor",
+			key:   "pr.created_at",
+			after: after,
+			where: []string{"pr.status = $1", "(pr.created_at, pr.id) > ($2::timestamp, $3)"},
+			args:  []any{"OPEN", "2025-01-01T00:00:00Z", "pr-1"},
+			order: "pr.created_at ASC, pr.id ASC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args, order := Keyset([]string{"pr.status = $1"}, []any{"OPEN"}, tt.key, "timestamp", "pr.id", tt.desc, tt.after)
+			if !reflect.DeepEqual(where, tt.where) || !reflect.DeepEqual(args, tt.args) || order != tt.order {
+				t.Errorf("Keyset = %q %v %q, want %q %v %q", where, args, order, tt.where, tt.args, tt.order)
+			}
+		})
+	}
+}
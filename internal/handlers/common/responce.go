@@ -19,3 +19,13 @@ func WriteError(w http.ResponseWriter, status int, code, message string) {
 		},
 	})
 }
+
+func WriteErrorDetails(w http.ResponseWriter, status int, code, message string, details any) {
+	WriteJSON(w, status, ErrorResponse{
+		Error: ErrorBody{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	})
+}
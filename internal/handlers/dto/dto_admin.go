@@ -0,0 +1,72 @@
+package dto
+
+import "time"
+
+// типы записей выгрузки в порядке, в котором их нужно загружать: каждая
+// запись ссылается только на записи предыдущих типов
+const (
+	RecordTeam        = "team"
+	RecordUser        = "user"
+	RecordPullRequest = "pull_request"
+	RecordReviewer    = "reviewer"
+)
+
+var RecordTypes = []string{RecordTeam, RecordUser, RecordPullRequest, RecordReviewer}
+
+// форматы выгрузки и загрузки
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// RecordDTO - одна строка выгрузки. Заполняются поля своего типа:
+//   - team: team_name;
+//   - user: user_id, username, is_active, team_name (пусто - без команды), email;
+//   - pull_request: pull_request_id, pull_request_name, author_id, status,
+//     team_name (команда-владелец), repository, created_at, merged_at;
+//   - reviewer: pull_request_id, user_id.
+type RecordDTO struct {
+	Type            string     `json:"type"`
+	TeamName        string     `json:"team_name,omitempty"`
+	UserID          string     `json:"user_id,omitempty"`
+	Username        string     `json:"username,omitempty"`
+	IsActive        *bool      `json:"is_active,omitempty"`
+	Email           string     `json:"email,omitempty"`
+	PullRequestID   string     `json:"pull_request_id,omitempty"`
+	PullRequestName string     `json:"pull_request_name,omitempty"`
+	AuthorID        string     `json:"author_id,omitempty"`
+	Status          string     `json:"status,omitempty"`
+	Repository      string     `json:"repository,omitempty"`
+	CreatedAt       *time.Time `json:"created_at,omitempty"`
+	MergedAt        *time.Time `json:"merged_at,omitempty"`
+}
+
+// ImportErrorDTO - ошибка строки загрузки; Line - номер строки во входных данных.
+type ImportErrorDTO struct {
+	Line    int    `json:"line"`
+	Type    string `json:"type,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResultDTO - итог загрузки: Imported - число загруженных записей по
+// типам, Errors - первые MaxImportErrors ошибок (ErrorsTruncated - были еще).
+type ImportResultDTO struct {
+	Processed       int              `json:"processed"`
+	Imported        map[string]int   `json:"imported"`
+	Failed          int              `json:"failed"`
+	Errors          []ImportErrorDTO `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+}
+
+const MaxImportErrors = 1000
+
+// ImportFailedError возвращается при загрузке со strict=true: первая ошибочная
+// запись отменяет всю загрузку. Error() совпадает с кодом IMPORT_FAILED.
+type ImportFailedError struct {
+	Errors []ImportErrorDTO
+}
+
+func (e *ImportFailedError) Error() string {
+	return ErrorCodeImportFailed
+}
@@ -0,0 +1,57 @@
+package dto
+
+import "time"
+
+// постраничная выдача списков
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// сортировки списков PR; "-" в начале - по убыванию
+const (
+	SortCreatedAt     = "created_at"
+	SortCreatedAtDesc = "-created_at"
+	SortID            = "id"
+	SortIDDesc        = "-id"
+	SortName          = "name"
+	SortNameDesc      = "-name"
+)
+
+var (
+	PullRequestSorts = []string{SortCreatedAt, SortCreatedAtDesc, SortID, SortIDDesc}
+	UserSorts        = []string{SortID, SortIDDesc, SortName, SortNameDesc}
+)
+
+// Cursor - позиция в списке: значение ключа сортировки и id последней
+// выданной записи. Следующая страница начинается строго после (Key, ID),
+// поэтому новые записи не сдвигают выдачу и не дублируются.
+type Cursor struct {
+	Sort string
+	Key  string
+	ID   string
+}
+
+// PageRequest - Limit 0 означает "без ограничения" (поведение v1 без limit/cursor).
+type PageRequest struct {
+	Limit int
+	Sort  string
+	After *Cursor
+}
+
+type PullRequestFilter struct {
+	ReviewerID  string
+	AuthorID    string
+	Status      string
+	Repository  string
+	TeamName    string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Page        PageRequest
+}
+
+type UserFilter struct {
+	TeamName   string
+	ActiveOnly bool
+	Page       PageRequest
+}
@@ -5,32 +5,93 @@ import (
 )
 
 type PullRequestDTO struct {
-	PullRequestID     string     `json:"pull_request_id"`
-	PullRequestName   string     `json:"pull_request_name"`
-	AuthorID          string     `json:"author_id"`
-	Status            string     `json:"status"`
-	AssignedReviewers []string   `json:"assigned_reviewers"`
-	CreatedAt         *time.Time `json:"createdAt,omitempty"`
-	MergedAt          *time.Time `json:"mergedAt,omitempty"`
+	PullRequestID     string             `json:"pull_request_id"`
+	PullRequestName   string             `json:"pull_request_name"`
+	AuthorID          string             `json:"author_id"`
+	Status            string             `json:"status"`
+	TeamName          string             `json:"team_name,omitempty"`
+	Repository        string             `json:"repository,omitempty"`
+	ChangedFiles      []string           `json:"changed_files,omitempty"`
+	RequiredTags      []string           `json:"required_tags,omitempty"`
+	AssignmentMode    string             `json:"assignment_mode,omitempty"`
+	AssignedReviewers []string           `json:"assigned_reviewers"`
+	ReviewerMatches   []ReviewerMatchDTO `json:"reviewer_matches,omitempty"`
+	CreatedAt         *time.Time         `json:"createdAt,omitempty"`
+	MergedAt          *time.Time         `json:"mergedAt,omitempty"`
+}
+
+// ReviewerMatchDTO объясняет, почему ревьюер был выбран
+type ReviewerMatchDTO struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
 }
 
 type PullRequestShortDTO struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
-	Status          string `json:"status"`
+	PullRequestID   string     `json:"pull_request_id"`
+	PullRequestName string     `json:"pull_request_name"`
+	AuthorID        string     `json:"author_id"`
+	Status          string     `json:"status"`
+	Repository      string     `json:"repository,omitempty"`
+	CreatedAt       *time.Time `json:"createdAt,omitempty"`
 }
 
+const (
+	AssignmentModeDefault      = ""
+	AssignmentModeWorkingHours = "working_hours"
+)
+
 type ReassignReviewerRequest struct {
-	PullRequestID string `json:"pull_request_id"`
-	OldReviewerID string `json:"old_reviewer_id"`
+	PullRequestID  string `json:"pull_request_id"`
+	OldReviewerID  string `json:"old_reviewer_id"`
+	AssignmentMode string `json:"assignment_mode,omitempty"`
 }
 
 type ReassignReviewerResponse struct {
 	PR         PullRequestDTO `json:"pr"`
 	ReplacedBy string         `json:"replaced_by"`
+	Reason     string         `json:"reason,omitempty"`
 }
 
 type MergePRRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 }
+
+// причины, по которым участник команды не был выбран ревьюером
+const (
+	SkipReasonInactive        = "inactive"
+	SkipReasonUnavailable     = "unavailable"
+	SkipReasonAuthor          = "author"
+	SkipReasonAlreadyReviewer = "already_reviewer"
+	SkipReasonAtCap           = "at_cap"
+)
+
+type SkippedCandidateDTO struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+// шаги эскалации зависших ревью
+const (
+	EscalationReminded   = "reminded"
+	EscalationReassigned = "reassigned"
+	EscalationFailed     = "failed"
+)
+
+type EscalationDTO struct {
+	ReviewerID    string    `json:"reviewer_id"`
+	Action        string    `json:"action"`
+	NewReviewerID string    `json:"new_reviewer_id,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// NoCandidateError возвращается, когда ни один участник не подошел в ревьюеры.
+// Error() совпадает с кодом NO_CANDIDATE, поэтому сравнение по err.Error()
+// продолжает работать.
+type NoCandidateError struct {
+	Skipped []SkippedCandidateDTO
+}
+
+func (e *NoCandidateError) Error() string {
+	return ErrorCodeNoCandidate
+}
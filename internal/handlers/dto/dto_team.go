@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type TeamMemberDTO struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
@@ -9,4 +11,146 @@ type TeamMemberDTO struct {
 type TeamDTO struct {
 	TeamName string          `json:"team_name"`
 	Members  []TeamMemberDTO `json:"members"`
+
+	DefaultMaxOpenReviews int `json:"default_max_open_reviews,omitempty"`
+	// nil - действует значение по умолчанию, 0 - этап отключен для команды
+	ReviewReminderHours   *int `json:"review_reminder_hours,omitempty"`
+	ReviewEscalationHours *int `json:"review_escalation_hours,omitempty"`
+
+	// курсор следующей страницы участников, только в ответах /team/get
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// TeamSettingsRequest - незаданные (nil) поля не изменяются, 0 снимает ограничение
+type TeamSettingsRequest struct {
+	TeamName              string `json:"team_name"`
+	DefaultMaxOpenReviews *int   `json:"default_max_open_reviews"`
+	// SLA на ревью: через review_reminder_hours ревьюеру отправляется
+	// напоминание, через review_escalation_hours ревью переназначается;
+	// 0 отключает этап, ResetSLAHours возвращает значение по умолчанию
+	ReviewReminderHours   *int `json:"review_reminder_hours"`
+	ReviewEscalationHours *int `json:"review_escalation_hours"`
+}
+
+// ResetSLAHours в TeamSettingsRequest возвращает порогу значение по умолчанию
+const ResetSLAHours = -1
+
+type CodeownersDTO struct {
+	TeamName   string     `json:"team_name"`
+	Repository string     `json:"repository"`
+	Content    string     `json:"content"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+type RenameTeamRequest struct {
+	TeamName    string `json:"team_name"`
+	NewTeamName string `json:"new_team_name"`
+}
+
+// DeleteTeamRequest - без Force команда, на участников которой ссылаются OPEN
+// PR, не удаляется
+type DeleteTeamRequest struct {
+	TeamName string `json:"team_name"`
+	Force    bool   `json:"force"`
+}
+
+// AddTeamMemberRequest - IsActive nil оставляет флаг существующего
+// пользователя, новый пользователь создается активным
+type AddTeamMemberRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	IsActive *bool  `json:"is_active"`
+}
+
+type RemoveTeamMemberRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+}
+
+// MoveTeamMemberRequest - TeamName задает команду, в которую переходит пользователь
+type MoveTeamMemberRequest struct {
+	UserID   string `json:"user_id"`
+	TeamName string `json:"team_name"`
+}
+
+// ReassignmentDTO - результат переназначения одного OPEN-ревью пользователя,
+// покинувшего команду; Error - код ошибки, если ревьюер остался прежним
+type ReassignmentDTO struct {
+	UserID        string `json:"user_id"`
+	PullRequestID string `json:"pull_request_id"`
+	ReplacedBy    string `json:"replaced_by,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type TeamMembershipResponse struct {
+	Team       TeamDTO           `json:"team"`
+	Reassigned []ReassignmentDTO `json:"reassigned"`
+}
+
+type DeleteTeamResponse struct {
+	TeamName   string            `json:"team_name"`
+	Members    []string          `json:"members"`
+	Reassigned []ReassignmentDTO `json:"reassigned"`
+}
+
+// TeamHasOpenPRsError возвращается при удалении команды без force, если есть
+// OPEN PR команды или ее участников. Error() совпадает с кодом TEAM_HAS_OPEN_PRS.
+type TeamHasOpenPRsError struct {
+	PullRequestIDs []string
+}
+
+func (e *TeamHasOpenPRsError) Error() string {
+	return ErrorCodeTeamHasOpenPRs
+}
+
+// что делать с участниками, которых нет в списке синхронизации
+const (
+	SyncMissingDeactivate = "deactivate"
+	SyncMissingRemove     = "remove"
+)
+
+// TeamSyncRequest - желаемый полный состав команды. Missing - политика для
+// отсутствующих в списке участников (по умолчанию deactivate); DryRun только
+// считает изменения.
+type TeamSyncRequest struct {
+	TeamName string              `json:"team_name"`
+	Members  []TeamSyncMemberDTO `json:"members"`
+	Missing  string              `json:"missing"`
+	DryRun   bool                `json:"dry_run"`
+}
+
+// TeamSyncMemberDTO - участник желаемого состава; IsActive nil означает
+// активного участника.
+type TeamSyncMemberDTO struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// Member возвращает участника с примененным значением по умолчанию.
+func (m TeamSyncMemberDTO) Member() TeamMemberDTO {
+	return TeamMemberDTO{UserID: m.UserID, Username: m.Username, IsActive: m.IsActive == nil || *m.IsActive}
+}
+
+// TeamMemberChangeDTO - изменение одного участника. PreviousTeam - команда, из
+// которой пользователь перешел; Fields - измененные поля.
+type TeamMemberChangeDTO struct {
+	UserID       string   `json:"user_id"`
+	Username     string   `json:"username"`
+	IsActive     bool     `json:"is_active"`
+	PreviousTeam string   `json:"previous_team,omitempty"`
+	Fields       []string `json:"fields,omitempty"`
+}
+
+type TeamSyncResponse struct {
+	TeamName    string                `json:"team_name"`
+	DryRun      bool                  `json:"dry_run"`
+	TeamCreated bool                  `json:"team_created"`
+	Added       []TeamMemberChangeDTO `json:"added"`
+	Updated     []TeamMemberChangeDTO `json:"updated"`
+	Deactivated []TeamMemberChangeDTO `json:"deactivated"`
+	Removed     []TeamMemberChangeDTO `json:"removed"`
+	Unchanged   int                   `json:"unchanged"`
+	Reassigned  []ReassignmentDTO     `json:"reassigned"`
 }
@@ -1,11 +1,27 @@
 package dto
 
+import "time"
+
 type UserDTO struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
-	TeamID   int    `json:"team_id,omitempty"`
+	UserID    string   `json:"user_id"`
+	Username  string   `json:"username"`
+	TeamName  string   `json:"team_name"`
+	IsActive  bool     `json:"is_active"`
+	TeamID    int      `json:"team_id,omitempty"`
+	Skills    []string `json:"skills,omitempty"`
+	TimeZone  string   `json:"time_zone,omitempty"`
+	WorkStart string   `json:"work_start,omitempty"`
+	WorkEnd   string   `json:"work_end,omitempty"`
+
+	MaxOpenReviews int `json:"max_open_reviews,omitempty"`
+
+	ChatHandle string `json:"chat_handle,omitempty"`
+	ChatMuted  bool   `json:"chat_muted,omitempty"`
+	Email      string `json:"email,omitempty"`
+
+	DisplayName string `json:"display_name,omitempty"`
+	// логины во внешних системах по провайдеру (github, gitlab)
+	ExternalLogins map[string]string `json:"external_logins,omitempty"`
 }
 
 type SetIsActiveRequest struct {
@@ -13,6 +29,121 @@ type SetIsActiveRequest struct {
 	IsActive bool   `json:"is_active"`
 }
 
+// SetProfileRequest - незаданные (nil) поля не изменяются.
+// Рабочие часы задаются в формате "HH:MM" в часовом поясе пользователя,
+// пустые строки сбрасывают их.
+type SetProfileRequest struct {
+	UserID    string   `json:"user_id"`
+	Skills    []string `json:"skills"`
+	TimeZone  *string  `json:"time_zone"`
+	WorkStart *string  `json:"work_start"`
+	WorkEnd   *string  `json:"work_end"`
+	// 0 снимает личное ограничение, действует значение команды
+	MaxOpenReviews *int `json:"max_open_reviews"`
+	// имя в Slack/Mattermost для уведомлений; chat_muted отключает их
+	ChatHandle *string `json:"chat_handle"`
+	ChatMuted  *bool   `json:"chat_muted"`
+	// адрес для ежедневного дайджеста; пустая строка удаляет его
+	Email *string `json:"email"`
+	// username - имя в командах и событиях, display_name - необязательное
+	// отображаемое имя (пустая строка удаляет его)
+	Username    *string `json:"username"`
+	DisplayName *string `json:"display_name"`
+	// логины по провайдеру, как в /users/setExternalLogin; пустой логин
+	// отвязывает провайдера, не указанные провайдеры не изменяются
+	ExternalLogins map[string]string `json:"external_logins"`
+}
+
+// UpdateUserRequest - тело /users/update и PATCH /v2/users/{id}: флаг
+// активности и поля профиля, незаданные поля не изменяются; в v2 user_id
+// берется из пути.
+type UpdateUserRequest struct {
+	IsActive *bool `json:"is_active"`
+	SetProfileRequest
+}
+
 type UserResponse struct {
 	User UserDTO `json:"user"`
 }
+
+type DeleteUserRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// DeleteUserResponse - Reassigned - OPEN-ревью удаленного пользователя,
+// переназначенные перед удалением.
+type DeleteUserResponse struct {
+	UserID     string            `json:"user_id"`
+	Reassigned []ReassignmentDTO `json:"reassigned"`
+}
+
+// UserHasOpenPRsError возвращается при удалении автора OPEN PR. Error()
+// совпадает с кодом USER_HAS_OPEN_PRS.
+type UserHasOpenPRsError struct {
+	PullRequestIDs []string
+}
+
+func (e *UserHasOpenPRsError) Error() string {
+	return ErrorCodeUserHasOpenPRs
+}
+
+type UnavailabilityDTO struct {
+	ID       int       `json:"id,omitempty"`
+	UserID   string    `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+}
+
+// UpdateUnavailabilityRequest - тело /users/unavailability/update: те же поля,
+// но обязателен id, а user_id берется из существующей записи.
+type UpdateUnavailabilityRequest UnavailabilityDTO
+
+type DeleteUnavailabilityRequest struct {
+	ID int `json:"id"`
+}
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// ExternalLoginDTO связывает пользователя с логином на код-хостинге;
+// пустой login удаляет связь
+type ExternalLoginDTO struct {
+	UserID   string `json:"user_id"`
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+}
+
+// типы событий /users/reviewStream
+const (
+	ReviewStreamAssigned   = "assigned"
+	ReviewStreamUnassigned = "unassigned"
+	ReviewStreamMerged     = "merged"
+	// события после курсора Last-Event-ID уже удалены очисткой outbox
+	ReviewStreamReset = "reset"
+)
+
+// ReviewStreamResetDTO - данные события reset: поток продолжается с Cursor,
+// пропущенное состояние нужно перечитать через getReview.
+type ReviewStreamResetDTO struct {
+	Type   string `json:"type"`
+	Cursor string `json:"cursor"`
+}
+
+// ReviewStreamEventDTO - данные события SSE; ID совпадает с id события outbox,
+// Cursor передается в поле id SSE и используется как Last-Event-ID.
+type ReviewStreamEventDTO struct {
+	ID                int64     `json:"id"`
+	Cursor            string    `json:"cursor"`
+	Type              string    `json:"type"`
+	UserID            string    `json:"user_id"`
+	PullRequestID     string    `json:"pull_request_id"`
+	PullRequestName   string    `json:"pull_request_name"`
+	AuthorID          string    `json:"author_id"`
+	Repository        string    `json:"repository,omitempty"`
+	AssignedReviewers []string  `json:"assigned_reviewers"`
+	ReplacedBy        string    `json:"replaced_by,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+const (
+	WebhookStatusCreated   = "created"
+	WebhookStatusMerged    = "merged"
+	WebhookStatusIgnored   = "ignored"
+	WebhookStatusDuplicate = "duplicate"
+)
+
+// WebhookResultDTO - результат обработки входящего вебхука
+type WebhookResultDTO struct {
+	Status        string `json:"status"`
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
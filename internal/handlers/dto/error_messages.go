@@ -1,18 +1,27 @@
 package dto
 
 const (
-	ErrorCodeTeamExists          = "TEAM_EXISTS"
-	ErrorCodePRExists            = "PR_EXISTS"
-	ErrorCodePRMerged            = "PR_MERGED"
-	ErrorCodeNotAssigned         = "NOT_ASSIGNED"
-	ErrorCodeNoCandidate         = "NO_CANDIDATE"
-	ErrorCodeNotFound            = "NOT_FOUND"
-	ErrorCodeUserNotFound        = "USER_NOT_FOUND"
-	ErrorCodeTeamNotFound        = "TEAM_NOT_FOUND"
-	ErrorCodePullRequestNotFound = "PULL_REQUEST_NOT_FOUND"
-	ErrorMethodNotAllowed        = "METHOD_NOT_ALLOWED"
-	ErrorBadRequest              = "BAD_REQUEST"
-	ErrorInternalError           = "INTERNAL_ERROR"
-	TeamExistsError              = "TEAM_EXISTS"
-	TeamNotFoundError            = "TEAM_NOT_FOUND"
+	ErrorCodeTeamExists           = "TEAM_EXISTS"
+	ErrorCodePRExists             = "PR_EXISTS"
+	ErrorCodePRMerged             = "PR_MERGED"
+	ErrorCodeNotAssigned          = "NOT_ASSIGNED"
+	ErrorCodeNoCandidate          = "NO_CANDIDATE"
+	ErrorCodeNotFound             = "NOT_FOUND"
+	ErrorCodeUserNotFound         = "USER_NOT_FOUND"
+	ErrorCodeTeamNotFound         = "TEAM_NOT_FOUND"
+	ErrorCodePullRequestNotFound  = "PULL_REQUEST_NOT_FOUND"
+	ErrorMethodNotAllowed         = "METHOD_NOT_ALLOWED"
+	ErrorBadRequest               = "BAD_REQUEST"
+	ErrorInternalError            = "INTERNAL_ERROR"
+	ErrorCodeInvalidSignature     = "INVALID_SIGNATURE"
+	ErrorCodeLoginTaken           = "LOGIN_TAKEN"
+	ErrorCodeSubscriptionNotFound = "SUBSCRIPTION_NOT_FOUND"
+	ErrorCodeValidation           = "VALIDATION_ERROR"
+	ErrorCodeTeamHasOpenPRs       = "TEAM_HAS_OPEN_PRS"
+	ErrorCodeMemberOfOtherTeam    = "MEMBER_OF_OTHER_TEAM"
+	ErrorCodeImportFailed         = "IMPORT_FAILED"
+	ErrorCodeUserHasOpenPRs       = "USER_HAS_OPEN_PRS"
+	ErrorCodeOutboxDisabled       = "OUTBOX_DISABLED"
+	TeamExistsError               = "TEAM_EXISTS"
+	TeamNotFoundError             = "TEAM_NOT_FOUND"
 )
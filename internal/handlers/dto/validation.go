@@ -0,0 +1,456 @@
+package dto
+
+import (
+	"fmt"
+	"maps"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ограничения на поля запросов
+const (
+	MaxIDLength      = 128
+	MaxNameLength    = 255
+	MaxURLLength     = 2048
+	MaxTagLength     = 64
+	MaxTags          = 50
+	MaxChangedFiles  = 10000
+	MaxPathLength    = 1024
+	MaxTeamMembers   = 1000
+	MaxContentLength = 512 << 10
+)
+
+type FieldErrorDTO struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError перечисляет все поля запроса, не прошедшие проверку.
+// Error() совпадает с кодом VALIDATION_ERROR.
+type ValidationError struct {
+	Fields []FieldErrorDTO
+}
+
+func (e *ValidationError) Error() string {
+	return ErrorCodeValidation
+}
+
+// Validator накапливает ошибки полей; путь поля записывается так же, как в
+// JSON запроса: members[1].user_id.
+type Validator struct {
+	errs []FieldErrorDTO
+}
+
+func (v *Validator) Add(field, format string, args ...any) {
+	v.errs = append(v.errs, FieldErrorDTO{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err возвращает *ValidationError или nil, если ошибок нет.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.errs}
+}
+
+// Required проверяет, что строка не пустая, и возвращает результат проверки.
+func (v *Validator) Required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.Add(field, "is required")
+		return false
+	}
+	return true
+}
+
+// ID проверяет обязательный идентификатор: до MaxIDLength символов из букв,
+// цифр и ._:/#@!- (идентификаторы из вебхуков вида owner/repo#12).
+func (v *Validator) ID(field, value string) {
+	if !v.Required(field, value) {
+		return
+	}
+	if utf8.RuneCountInString(value) > MaxIDLength {
+		v.Add(field, "must be at most %d characters", MaxIDLength)
+		return
+	}
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("._:/#@!-", r) {
+			v.Add(field, "contains invalid character %q", r)
+			return
+		}
+	}
+}
+
+// Name проверяет обязательное имя: не длиннее MaxNameLength, без управляющих символов.
+func (v *Validator) Name(field, value string) {
+	if v.Required(field, value) {
+		v.Text(field, value, MaxNameLength)
+	}
+}
+
+// Text проверяет необязательную строку на длину и управляющие символы.
+func (v *Validator) Text(field, value string, maxLen int) {
+	if utf8.RuneCountInString(value) > maxLen {
+		v.Add(field, "must be at most %d characters", maxLen)
+		return
+	}
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		v.Add(field, "must not contain control characters")
+	}
+}
+
+func (v *Validator) NonNegative(field string, value *int) {
+	if value != nil && *value < 0 {
+		v.Add(field, "must not be negative")
+	}
+}
+
+func (v *Validator) Positive(field string, value int64) {
+	if value <= 0 {
+		v.Add(field, "is required")
+	}
+}
+
+func (v *Validator) OneOf(field, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		v.Add(field, "must be one of %s", strings.Join(quoteAll(allowed), ", "))
+	}
+}
+
+// Tags проверяет список тегов: не больше MaxTags, каждый не пустой и не длиннее MaxTagLength.
+func (v *Validator) Tags(field string, tags []string) {
+	if len(tags) > MaxTags {
+		v.Add(field, "must contain at most %d items", MaxTags)
+		return
+	}
+	for i, t := range tags {
+		f := fmt.Sprintf("%s[%d]", field, i)
+		if v.Required(f, t) {
+			v.Text(f, t, MaxTagLength)
+		}
+	}
+}
+
+// Clock проверяет время суток в формате HH:MM.
+func (v *Validator) Clock(field, value string) {
+	if _, err := time.Parse("15:04", value); err != nil {
+		v.Add(field, "must be a time of day in HH:MM format")
+	}
+}
+
+func quoteAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, s := range values {
+		out[i] = fmt.Sprintf("%q", s)
+	}
+	return out
+}
+
+// Email проверяет необязательный адрес без отображаемого имени.
+func (v *Validator) Email(field, value string) {
+	if value == "" {
+		return
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Name != "" || len(value) > MaxNameLength {
+		v.Add(field, "must be a valid email address")
+	}
+}
+
+func (t TeamDTO) Validate() error {
+	var v Validator
+	v.Name("team_name", t.TeamName)
+	v.members(t.Members)
+	v.NonNegative("default_max_open_reviews", &t.DefaultMaxOpenReviews)
+	v.NonNegative("review_reminder_hours", t.ReviewReminderHours)
+	v.NonNegative("review_escalation_hours", t.ReviewEscalationHours)
+	return v.Err()
+}
+
+func (r TeamSyncRequest) Validate() error {
+	var v Validator
+	v.Name("team_name", r.TeamName)
+	v.members(r.members())
+	if r.Missing != "" {
+		v.OneOf("missing", r.Missing, SyncMissingDeactivate, SyncMissingRemove)
+	}
+	return v.Err()
+}
+
+func (r TeamSyncRequest) members() []TeamMemberDTO {
+	members := make([]TeamMemberDTO, 0, len(r.Members))
+	for _, m := range r.Members {
+		members = append(members, m.Member())
+	}
+	return members
+}
+
+// members проверяет состав команды: идентификаторы, имена и повторы user_id.
+func (v *Validator) members(members []TeamMemberDTO) {
+	if len(members) > MaxTeamMembers {
+		v.Add("members", "must contain at most %d items", MaxTeamMembers)
+	}
+	seen := make(map[string]int, len(members))
+	for i, m := range members {
+		prefix := fmt.Sprintf("members[%d]", i)
+		v.ID(prefix+".user_id", m.UserID)
+		v.Name(prefix+".username", m.Username)
+		if first, ok := seen[m.UserID]; ok && m.UserID != "" {
+			v.Add(prefix+".user_id", "duplicates members[%d].user_id", first)
+			continue
+		}
+		seen[m.UserID] = i
+	}
+}
+
+func (r TeamSettingsRequest) Validate() error {
+	var v Validator
+	v.Name("team_name", r.TeamName)
+	v.NonNegative("default_max_open_reviews", r.DefaultMaxOpenReviews)
+	v.slaHours("review_reminder_hours", r.ReviewReminderHours)
+	v.slaHours("review_escalation_hours", r.ReviewEscalationHours)
+	return v.Err()
+}
+
+func (v *Validator) slaHours(field string, value *int) {
+	if value != nil && *value < ResetSLAHours {
+		v.Add(field, "must be %d (default), 0 (off) or positive", ResetSLAHours)
+	}
+}
+
+func (r RenameTeamRequest) Validate() error {
+	var v Validator
+	v.Name("team_name", r.TeamName)
+	v.Name("new_team_name", r.NewTeamName)
+	return v.Err()
+}
+
+func (r DeleteTeamRequest) Validate() error {
+	var v Validator
+	v.Name("team_name", r.TeamName)
+	return v.Err()
+}
+
+func (r AddTeamMemberRequest) Validate() error {
+	var v Validator
+	v.Name("team_name", r.TeamName)
+	v.ID("user_id", r.UserID)
+	v.Name("username", r.Username)
+	return v.Err()
+}
+
+func (r RemoveTeamMemberRequest) Validate() error {
+	var v Validator
+	v.Name("team_name", r.TeamName)
+	v.ID("user_id", r.UserID)
+	return v.Err()
+}
+
+func (r MoveTeamMemberRequest) Validate() error {
+	var v Validator
+	v.ID("user_id", r.UserID)
+	v.Name("team_name", r.TeamName)
+	return v.Err()
+}
+
+func (c CodeownersDTO) Validate() error {
+	var v Validator
+	v.Name("team_name", c.TeamName)
+	v.Name("repository", c.Repository)
+	if len(c.Content) > MaxContentLength {
+		v.Add("content", "must be at most %d bytes", MaxContentLength)
+	}
+	return v.Err()
+}
+
+func (r SetIsActiveRequest) Validate() error {
+	var v Validator
+	v.ID("user_id", r.UserID)
+	return v.Err()
+}
+
+func (r SetProfileRequest) Validate() error {
+	var v Validator
+	v.ID("user_id", r.UserID)
+	v.Tags("skills", r.Skills)
+	if r.TimeZone != nil {
+		if _, err := time.LoadLocation(*r.TimeZone); err != nil || *r.TimeZone == "" {
+			v.Add("time_zone", "unknown time zone")
+		}
+	}
+	if (r.WorkStart == nil) != (r.WorkEnd == nil) ||
+		r.WorkStart != nil && (*r.WorkStart == "") != (*r.WorkEnd == "") {
+		v.Add("work_end", "work_start and work_end must be set together")
+	} else if r.WorkStart != nil && *r.WorkStart != "" {
+		v.Clock("work_start", *r.WorkStart)
+		v.Clock("work_end", *r.WorkEnd)
+		if *r.WorkStart == *r.WorkEnd {
+			v.Add("work_end", "must differ from work_start")
+		}
+	}
+	v.NonNegative("max_open_reviews", r.MaxOpenReviews)
+	if r.ChatHandle != nil {
+		v.Text("chat_handle", *r.ChatHandle, MaxNameLength)
+	}
+	if r.Email != nil {
+		v.Email("email", *r.Email)
+	}
+	if r.Username != nil {
+		v.Name("username", *r.Username)
+	}
+	if r.DisplayName != nil {
+		v.Text("display_name", *r.DisplayName, MaxNameLength)
+	}
+	for _, provider := range slices.Sorted(maps.Keys(r.ExternalLogins)) {
+		field := "external_logins." + provider
+		v.OneOf(field, provider, ProviderGitHub, ProviderGitLab)
+		v.Text(field, r.ExternalLogins[provider], MaxNameLength)
+	}
+	return v.Err()
+}
+
+func (r DeleteUserRequest) Validate() error {
+	var v Validator
+	v.ID("user_id", r.UserID)
+	return v.Err()
+}
+
+func (u UnavailabilityDTO) Validate() error {
+	var v Validator
+	v.ID("user_id", u.UserID)
+	u.validatePeriod(&v)
+	return v.Err()
+}
+
+func (u UnavailabilityDTO) validatePeriod(v *Validator) {
+	if u.StartsAt.IsZero() {
+		v.Add("starts_at", "is required")
+	}
+	if u.EndsAt.IsZero() {
+		v.Add("ends_at", "is required")
+	} else if !u.EndsAt.After(u.StartsAt) {
+		v.Add("ends_at", "must be after starts_at")
+	}
+	v.Text("reason", u.Reason, MaxNameLength)
+}
+
+func (u UpdateUnavailabilityRequest) Validate() error {
+	var v Validator
+	v.Positive("id", int64(u.ID))
+	UnavailabilityDTO(u).validatePeriod(&v)
+	return v.Err()
+}
+
+func (r DeleteUnavailabilityRequest) Validate() error {
+	var v Validator
+	v.Positive("id", int64(r.ID))
+	return v.Err()
+}
+
+func (l ExternalLoginDTO) Validate() error {
+	var v Validator
+	v.ID("user_id", l.UserID)
+	v.OneOf("provider", l.Provider, ProviderGitHub, ProviderGitLab)
+	v.Text("login", l.Login, MaxNameLength)
+	return v.Err()
+}
+
+// Validate проверяет запрос на создание PR; status и assigned_reviewers
+// заполняет сервис, поэтому они не проверяются.
+func (p PullRequestDTO) Validate() error {
+	var v Validator
+	v.ID("pull_request_id", p.PullRequestID)
+	v.Name("pull_request_name", p.PullRequestName)
+	v.ID("author_id", p.AuthorID)
+	v.Text("team_name", p.TeamName, MaxNameLength)
+	v.Text("repository", p.Repository, MaxNameLength)
+	if len(p.ChangedFiles) > MaxChangedFiles {
+		v.Add("changed_files", "must contain at most %d items", MaxChangedFiles)
+	} else {
+		for i, f := range p.ChangedFiles {
+			field := fmt.Sprintf("changed_files[%d]", i)
+			if v.Required(field, f) {
+				v.Text(field, f, MaxPathLength)
+			}
+		}
+	}
+	v.Tags("required_tags", p.RequiredTags)
+	v.OneOf("assignment_mode", p.AssignmentMode, AssignmentModeDefault, AssignmentModeWorkingHours)
+	return v.Err()
+}
+
+func (r ReassignReviewerRequest) Validate() error {
+	var v Validator
+	v.ID("pull_request_id", r.PullRequestID)
+	v.ID("old_reviewer_id", r.OldReviewerID)
+	v.OneOf("assignment_mode", r.AssignmentMode, AssignmentModeDefault, AssignmentModeWorkingHours)
+	return v.Err()
+}
+
+func (r MergePRRequest) Validate() error {
+	var v Validator
+	v.ID("pull_request_id", r.PullRequestID)
+	return v.Err()
+}
+
+// ValidateEventTypes проверяет подписку; eventTypes - допустимые типы событий
+// (outbox.EventTypes), их передает обработчик, чтобы dto не зависел от outbox.
+func (s SubscriptionDTO) ValidateEventTypes(eventTypes []string) error {
+	var v Validator
+	if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.Add("url", "must be an absolute http(s) URL")
+	} else if len(s.URL) > MaxURLLength {
+		v.Add("url", "must be at most %d characters", MaxURLLength)
+	}
+	if v.Required("secret", s.Secret) {
+		v.Text("secret", s.Secret, MaxNameLength)
+	}
+	if len(s.EventTypes) == 0 {
+		v.Add("event_types", "is required")
+	}
+	for i, t := range s.EventTypes {
+		v.OneOf(fmt.Sprintf("event_types[%d]", i), t, eventTypes...)
+	}
+	return v.Err()
+}
+
+func (r DeleteSubscriptionRequest) Validate() error {
+	var v Validator
+	v.Positive("id", r.ID)
+	return v.Err()
+}
+
+func (r RecordDTO) Validate() error {
+	var v Validator
+	switch r.Type {
+	case RecordTeam:
+		v.Name("team_name", r.TeamName)
+	case RecordUser:
+		v.ID("user_id", r.UserID)
+		v.Name("username", r.Username)
+		v.Text("team_name", r.TeamName, MaxNameLength)
+		v.Email("email", r.Email)
+	case RecordPullRequest:
+		v.ID("pull_request_id", r.PullRequestID)
+		v.Name("pull_request_name", r.PullRequestName)
+		v.ID("author_id", r.AuthorID)
+		if r.Status != "" {
+			v.OneOf("status", r.Status, "OPEN", "MERGED")
+		}
+		v.Text("team_name", r.TeamName, MaxNameLength)
+		v.Text("repository", r.Repository, MaxNameLength)
+		if r.MergedAt != nil && r.Status != "MERGED" {
+			v.Add("merged_at", "is allowed only for MERGED pull requests")
+		}
+	case RecordReviewer:
+		v.ID("pull_request_id", r.PullRequestID)
+		v.ID("user_id", r.UserID)
+	default:
+		v.OneOf("type", r.Type, RecordTypes...)
+	}
+	return v.Err()
+}
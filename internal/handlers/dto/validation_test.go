@@ -0,0 +1,154 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func intPtr(v int) *int { return &v }
+
+// fields возвращает пути полей из ошибки валидации или nil.
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("unexpected error type %T: %v", err, err)
+	}
+	var out []string
+	for _, f := range verr.Fields {
+		out = append(out, f.Field)
+	}
+	return out
+}
+
+func TestTeamSettingsSLAHours(t *testing.T) {
+	tests := []struct {
+		name  string
+		hours *int
+		ok    bool
+	}{
+		{"unchanged", nil, true},
+		{"reset to default", intPtr(ResetSLAHours), true},
+		{"disabled", intPtr(0), true},
+		{"hours", intPtr(48), true},
+		{"below reset", intPtr(-2), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := TeamSettingsRequest{TeamName: "backend", ReviewReminderHours: tt.hours, ReviewEscalationHours: tt.hours}
+			got := fields(t, req.Validate())
+			if tt.ok && got != nil {
+				t.Errorf("unexpected errors %v", got)
+			}
+			if !tt.ok && len(got) != 2 {
+				t.Errorf("errors = %v, want both SLA fields", got)
+			}
+		})
+	}
+}
+
+func TestValidatorID(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "u1", ""},
+		{"webhook id", "github:owner/repo#12", ""},
+		{"allowed punctuation", "a._:/#@!-b", ""},
+		{"unicode letters", "пользователь1", ""},
+		{"empty", "", "is required"},
+		{"blank", "   ", "is required"},
+		{"space", "u 1", `contains invalid character ' '`},
+		{"quote", `u"1`, `contains invalid character '"'`},
+		{"control", "u\n1", `contains invalid character '\n'`},
+		{"max length", strings.Repeat("я", MaxIDLength), ""},
+		{"too long", strings.Repeat("я", MaxIDLength+1), fmt.Sprintf("must be at most %d characters", MaxIDLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v Validator
+			v.ID("user_id", tt.value)
+			got := ""
+			if len(v.errs) > 0 {
+				got = v.errs[0].Message
+			}
+			if got != tt.want {
+				t.Errorf("ID(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorTextLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(v *Validator)
+		want  []string
+	}{
+		{"name at limit", func(v *Validator) { v.Name("username", strings.Repeat("a", MaxNameLength)) }, nil},
+		{"name too long", func(v *Validator) { v.Name("username", strings.Repeat("a", MaxNameLength+1)) }, []string{"username"}},
+		{"name control", func(v *Validator) { v.Name("username", "a\tb") }, []string{"username"}},
+		{"text empty ok", func(v *Validator) { v.Text("title", "", 10) }, nil},
+		{"tags", func(v *Validator) { v.Tags("tags", []string{"go", "", strings.Repeat("t", MaxTagLength+1)}) }, []string{"tags[1]", "tags[2]"}},
+		{"too many tags", func(v *Validator) { v.Tags("tags", make([]string, MaxTags+1)) }, []string{"tags"}},
+		{"email", func(v *Validator) { v.Email("email", "Ivan <ivan@example.com>") }, []string{"email"}},
+		{"clock", func(v *Validator) { v.Clock("work_start", "25:00") }, []string{"work_start"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v Validator
+			tt.check(&v)
+			if got := fields(t, v.Err()); !slices.Equal(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeamMembersValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []TeamMemberDTO
+		want    []string
+	}{
+		{"ok", []TeamMemberDTO{{UserID: "u1", Username: "a"}, {UserID: "u2", Username: "b"}}, nil},
+		{"duplicate", []TeamMemberDTO{{UserID: "u1", Username: "a"}, {UserID: "u2", Username: "b"}, {UserID: "u1", Username: "c"}}, []string{"members[2].user_id"}},
+		{"empty ids are not duplicates", []TeamMemberDTO{{Username: "a"}, {Username: "b"}}, []string{"members[0].user_id", "members[1].user_id"}},
+		{"bad username", []TeamMemberDTO{{UserID: "u1"}}, []string{"members[0].username"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fields(t, TeamDTO{TeamName: "backend", Members: tt.members}.Validate())
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	many := make([]TeamMemberDTO, MaxTeamMembers+1)
+	for i := range many {
+		many[i] = TeamMemberDTO{UserID: fmt.Sprintf("u%d", i), Username: "x"}
+	}
+	if got := fields(t, TeamDTO{TeamName: "backend", Members: many}.Validate()); !slices.Equal(got, []string{"members"}) {
+		t.Errorf("too many members: fields = %v", got)
+	}
+}
+
+func TestSubscriptionEventTypes(t *testing.T) {
+	allowed := []string{"pr.created", "pr.merged"}
+	s := SubscriptionDTO{URL: "https://example.com/hook", Secret: "s", EventTypes: []string{"pr.created", "pr.deleted"}}
+	if got := fields(t, s.ValidateEventTypes(allowed)); !slices.Equal(got, []string{"event_types[1]"}) {
+		t.Errorf("fields = %v", got)
+	}
+	s = SubscriptionDTO{URL: "ftp://example.com", EventTypes: nil}
+	if got := fields(t, s.ValidateEventTypes(allowed)); !slices.Equal(got, []string{"url", "secret", "event_types"}) {
+		t.Errorf("fields = %v", got)
+	}
+}
@@ -0,0 +1,61 @@
+package openapi
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	_ "embed"
+	"net/http"
+)
+
+// openapi.json поддерживается вручную: при добавлении маршрута или поля DTO
+// его нужно дописать сюда (handlers/openapi_test.go это проверяет).
+//
+//go:embed openapi.json
+var spec []byte
+
+// SpecJSON возвращает встроенный документ OpenAPI 3.
+func SpecJSON() []byte {
+	return spec
+}
+
+// Spec отдает документ OpenAPI по GET /openapi.json.
+func Spec() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(spec)
+	}
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>PR Reviewer Assignment Service - API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+// Docs отдает страницу Swagger UI, которая загружает /openapi.json.
+func Docs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(docsPage))
+	}
+}
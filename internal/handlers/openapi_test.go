@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"AvitoInternship/internal/config"
+	"AvitoInternship/internal/handlers/openapi"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type specDoc struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]struct {
+				Enum []string `json:"enum"`
+			} `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) specDoc {
+	t.Helper()
+	var doc specDoc
+	if err := json.Unmarshal(openapi.SpecJSON(), &doc); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("openapi version = %q, want 3.x", doc.OpenAPI)
+	}
+	return doc
+}
+
+// Каждый зарегистрированный маршрут и метод описан в спецификации, и в ней нет
+// путей и методов, которых нет в роутере.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	doc := loadSpec(t)
+	registered := map[string]map[string]bool{}
+	all := append(routes(nil, &config.Config{}, nil), routesV2(nil, &config.Config{}, nil)...)
+	for _, rt := range all {
+		// {repository...} в шаблоне ServeMux - это {repository} в OpenAPI
+		path := strings.ReplaceAll(rt.Path, "...}", "}")
+		if registered[path] == nil {
+			registered[path] = map[string]bool{}
+		}
+		for _, m := range rt.Methods {
+			registered[path][strings.ToLower(m)] = true
+		}
+	}
+	for path, methods := range registered {
+		ops, ok := doc.Paths[path]
+		if !ok {
+			t.Errorf("route %s is missing from openapi.json", path)
+			continue
+		}
+		for m := range methods {
+			if _, ok := ops[m]; !ok {
+				t.Errorf("route %s %s is missing from openapi.json", strings.ToUpper(m), path)
+			}
+		}
+		for m := range ops {
+			if m != "parameters" && !methods[m] {
+				t.Errorf("openapi.json describes %s %s, which is not registered", strings.ToUpper(m), path)
+			}
+		}
+	}
+	for path := range doc.Paths {
+		if registered[path] == nil {
+			t.Errorf("openapi.json describes %s, which is not registered", path)
+		}
+	}
+}
+
+// Для каждой структуры с json-тегами из dto и common есть схема с тем же именем
+// и всеми полями.
+func TestOpenAPICoversDTOs(t *testing.T) {
+	doc := loadSpec(t)
+	for _, dir := range []string{"dto", "common"} {
+		for name, fields := range jsonStructs(t, dir) {
+			schema, ok := doc.Components.Schemas[name]
+			if !ok {
+				t.Errorf("schema %s (%s) is missing from openapi.json", name, dir)
+				continue
+			}
+			for _, f := range fields {
+				if _, ok := schema.Properties[f]; !ok {
+					t.Errorf("schema %s is missing property %q", name, f)
+				}
+			}
+			if len(schema.Properties) != len(fields) {
+				t.Errorf("schema %s has %d properties, %s.%s has %d json fields", name, len(schema.Properties), dir, name, len(fields))
+			}
+		}
+	}
+}
+
+// Все коды ошибок перечислены в ErrorBody.code.
+func TestOpenAPIErrorCodes(t *testing.T) {
+	doc := loadSpec(t)
+	enum := map[string]bool{}
+	for _, code := range doc.Components.Schemas["ErrorBody"].Properties["code"].Enum {
+		enum[code] = true
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filepath.Join("dto", "error_messages.go"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			for _, v := range spec.(*ast.ValueSpec).Values {
+				lit, ok := v.(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				code, _ := strconv.Unquote(lit.Value)
+				if !enum[code] {
+					t.Errorf("error code %s is missing from ErrorBody.code enum", code)
+				}
+			}
+		}
+	}
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	mux := SetupRouter(nil, &config.Config{}, nil)
+	for path, contentType := range map[string]string{
+		"/openapi.json": "application/json",
+		"/docs":         "text/html",
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", path, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, contentType) {
+			t.Errorf("GET %s Content-Type = %q, want %s", path, ct, contentType)
+		}
+	}
+}
+
+// jsonStructs возвращает поля (имена из json-тегов) структур пакета в dir,
+// у которых есть хотя бы один json-тег.
+func jsonStructs(t *testing.T, dir string) map[string][]string {
+	t.Helper()
+	fset := token.NewFileSet()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string][]string{}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			ts, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				return false
+			}
+			var fields []string
+			for _, field := range st.Fields.List {
+				if field.Tag == nil {
+					continue
+				}
+				tag, _ := strconv.Unquote(field.Tag.Value)
+				name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+				if name == "" || name == "-" {
+					continue
+				}
+				fields = append(fields, name)
+			}
+			if len(fields) > 0 {
+				out[ts.Name.Name] = fields
+			}
+			return false
+		})
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package pullRequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"AvitoInternship/internal/codeowners"
+
+	"github.com/lib/pq"
+)
+
+const selectCodeownersSQL = `SELECT content FROM codeowners WHERE repository = $1;`
+
+const selectOwnerUsersSQL = `
+SELECT u.id, COALESCE(t.name, ''), LOWER(COALESCE(u.email, ''))
+FROM "user" u
+LEFT JOIN team t ON t.id = u.team_id
+WHERE u.id = ANY($1) OR t.name = ANY($2) OR LOWER(u.email) = ANY($3);
+`
+
+type ownerUser struct {
+	team  string
+	email string
+}
+
+// ownerHits сопоставляет измененные файлы с CODEOWNERS репозитория и возвращает,
+// сколько файлов принадлежит каждому пользователю. Если для репозитория
+// CODEOWNERS не загружен, результат пуст.
+func (r *PullRequestRepository) ownerHits(ctx context.Context, repository string, files []string) (map[string]int, error) {
+	if repository == "" || len(files) == 0 {
+		return nil, nil
+	}
+	var content string
+	if err := r.db.QueryRowContext(ctx, selectCodeownersSQL, repository).Scan(&content); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	file, err := codeowners.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// владельцы в CODEOWNERS: @user_id, @org/team_name или email пользователя
+	ownersByFile := make([][]string, 0, len(files))
+	userSet := map[string]struct{}{}
+	teamSet := map[string]struct{}{}
+	emailSet := map[string]struct{}{}
+	for _, path := range files {
+		owners := file.Owners(path)
+		ownersByFile = append(ownersByFile, owners)
+		for _, o := range owners {
+			if !strings.HasPrefix(o, "@") {
+				emailSet[strings.ToLower(o)] = struct{}{}
+				continue
+			}
+			if _, team, ok := strings.Cut(o[1:], "/"); ok {
+				teamSet[team] = struct{}{}
+			} else {
+				userSet[o[1:]] = struct{}{}
+			}
+		}
+	}
+	if len(userSet) == 0 && len(teamSet) == 0 && len(emailSet) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, selectOwnerUsersSQL,
+		pq.Array(setKeys(userSet)), pq.Array(setKeys(teamSet)), pq.Array(setKeys(emailSet)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	users := map[string]ownerUser{}
+	for rows.Next() {
+		var uid string
+		var u ownerUser
+		if err := rows.Scan(&uid, &u.team, &u.email); err != nil {
+			return nil, err
+		}
+		users[uid] = u
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	hits := map[string]int{}
+	for _, owners := range ownersByFile {
+		for uid, u := range users {
+			for _, o := range owners {
+				if o == "@"+uid || (u.team != "" && strings.HasSuffix(o, "/"+u.team)) ||
+					(u.email != "" && strings.EqualFold(o, u.email)) {
+					hits[uid]++
+					break
+				}
+			}
+		}
+	}
+	return hits, nil
+}
+
+func setKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
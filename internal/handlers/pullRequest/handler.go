@@ -5,26 +5,20 @@ import (
 	"AvitoInternship/internal/handlers/dto"
 	"context"
 	"database/sql"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
-	"strings"
 )
 
-func Create(db *sql.DB) http.HandlerFunc {
+func Create(db *sql.DB, listener AssignmentListener) http.HandlerFunc {
 	repo := NewPullRequestRepository(db)
+	repo.SetListener(listener)
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
 			return
 		}
 		var req dto.PullRequestDTO
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "invalid request body")
-			return
-		}
-		if req.PullRequestName == "" || req.AuthorID == "" {
-			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "pull_request_name and author_id are required")
+		if !common.DecodeJSON(w, r, &req) {
 			return
 		}
 		ctx := r.Context()
@@ -38,6 +32,11 @@ func Create(db *sql.DB) http.HandlerFunc {
 				common.WriteError(w, http.StatusConflict, dto.ErrorCodePRExists, "PR id already exists")
 				return
 			}
+			var noCandidate *dto.NoCandidateError
+			if errors.As(err, &noCandidate) {
+				common.WriteErrorDetails(w, http.StatusConflict, dto.ErrorCodeNoCandidate, "no active reviewer candidate in team", noCandidate.Skipped)
+				return
+			}
 			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to create pull request")
 			return
 		}
@@ -54,12 +53,7 @@ func Merge(db *sql.DB) http.HandlerFunc {
 			return
 		}
 		var req dto.MergePRRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "invalid request body")
-			return
-		}
-		if req.PullRequestID == "" {
-			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "pull_request_id is required")
+		if !common.DecodeJSON(w, r, &req) {
 			return
 		}
 		ctx := r.Context()
@@ -76,26 +70,79 @@ func Merge(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-func Reassign(db *sql.DB) http.HandlerFunc {
-	prRepo := NewPullRequestRepository(db)
-	userRepository := &userRepository{db: db}
-	svc := NewPullRequestService(prRepo, userRepository)
+// List - GET /pullRequest/list
+//
+// Фильтры: status, author_id, repository, team_name, created_from и created_to
+// (RFC 3339, полуинтервал [from, to)). Сортировка sort: created_at, -created_at
+// (по умолчанию), id, -id. Страница - limit (по умолчанию 50) и cursor из
+// next_cursor предыдущего ответа.
+func List(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
+		if r.Method != http.MethodGet {
 			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
 			return
 		}
-		var req dto.ReassignReviewerRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "invalid request body")
+		var v dto.Validator
+		filter := common.ParsePullRequestFilter(r.URL.Query(), dto.DefaultPageLimit, dto.SortCreatedAtDesc, &v)
+		if err := v.Err(); err != nil {
+			common.WriteValidationError(w, err)
+			return
+		}
+		prs, next, err := ListPullRequests(r.Context(), db, filter)
+		if err != nil {
+			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to list pull requests")
+			return
+		}
+		response := map[string]interface{}{"pull_requests": prs}
+		if next != "" {
+			response["next_cursor"] = next
+		}
+		common.WriteJSON(w, http.StatusOK, response)
+	}
+}
+
+// Escalations - GET /pullRequest/escalations?pull_request_id=...
+func Escalations(db *sql.DB) http.HandlerFunc {
+	repo := NewPullRequestRepository(db)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+			return
+		}
+		prID := r.URL.Query().Get("pull_request_id")
+		if prID == "" {
+			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "pull_request_id is required")
 			return
 		}
-		if req.PullRequestID == "" || req.OldReviewerID == "" {
-			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "pull_request_id and old_reviewer_id are required")
+		list, err := repo.ListEscalations(r.Context(), prID)
+		if err != nil {
+			if err.Error() == dto.ErrorCodeNotFound {
+				common.WriteError(w, http.StatusNotFound, dto.ErrorCodeNotFound, "resource not found")
+				return
+			}
+			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to list escalations")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"pull_request_id": prID,
+			"escalations":     list,
+		})
+	}
+}
+
+func Reassign(db *sql.DB, listener AssignmentListener) http.HandlerFunc {
+	svc := NewService(db, listener)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+			return
+		}
+		var req dto.ReassignReviewerRequest
+		if !common.DecodeJSON(w, r, &req) {
 			return
 		}
 		ctx := r.Context()
-		res, err := svc.Reassign(ctx, req.PullRequestID, req.OldReviewerID)
+		res, err := svc.ReassignInMode(ctx, req.PullRequestID, req.OldReviewerID, req.AssignmentMode)
 		if err != nil {
 			if err.Error() == dto.ErrorCodeNotFound {
 				common.WriteError(w, http.StatusNotFound, dto.ErrorCodeNotFound, "resource not found")
@@ -105,6 +152,11 @@ func Reassign(db *sql.DB) http.HandlerFunc {
 				common.WriteError(w, http.StatusBadRequest, dto.ErrorCodePRMerged, "cannot reassign on merged PR")
 				return
 			}
+			var noCandidate *dto.NoCandidateError
+			if errors.As(err, &noCandidate) {
+				common.WriteErrorDetails(w, http.StatusConflict, dto.ErrorCodeNoCandidate, "no active replacement candidate in team", noCandidate.Skipped)
+				return
+			}
 			common.WriteError(w, http.StatusBadRequest, dto.ErrorInternalError, "failed to reassign reviewer")
 			return
 		}
@@ -112,44 +164,26 @@ func Reassign(db *sql.DB) http.HandlerFunc {
 		out := map[string]interface{}{
 			"pr":          res.PR,
 			"replaced_by": res.ReplacedBy,
+			"reason":      res.Reason,
 		}
 		common.WriteJSON(w, http.StatusOK, out)
 	}
 }
 
-type userRepository struct {
-	db *sql.DB
-}
+// userRepository читает пользователей в транзакции переназначения.
+type userRepository struct{}
 
-func (s *userRepository) GetByID(ctx context.Context, userID string) (*dto.UserDTO, error) {
+func (s *userRepository) GetByIDTx(ctx context.Context, tx *sql.Tx, userID string) (*dto.UserDTO, error) {
 	var id, name string
 	var teamID int
 	var isActive bool
-	row := s.db.QueryRowContext(ctx, `SELECT id, name, team_id, is_active FROM "user" WHERE id = $1`, userID)
+	row := tx.QueryRowContext(ctx, `SELECT id, name, COALESCE(team_id, 0), is_active FROM "user" WHERE id = $1`, userID)
 	if err := row.Scan(&id, &name, &teamID, &isActive); err != nil {
 		return nil, err
 	}
 	return &dto.UserDTO{UserID: id, Username: name, TeamID: teamID, IsActive: isActive}, nil
 }
 
-func (s *userRepository) GetRandomActiveTeammate(ctx context.Context, teamID int, exclude []string) (*dto.UserDTO, error) {
-	base := `SELECT id, name, team_id, is_active FROM "user" WHERE team_id = $1 AND is_active = TRUE`
-	args := []interface{}{teamID}
-	if len(exclude) > 0 {
-		var placeholders []string
-		for i := range exclude {
-			placeholders = append(placeholders, fmt.Sprintf("$%d", i+2))
-			args = append(args, exclude[i])
-		}
-		base = base + " AND id NOT IN (" + strings.Join(placeholders, ",") + ")"
-	}
-	base = base + " ORDER BY random() LIMIT 1"
-	row := s.db.QueryRowContext(ctx, base, args...)
-	var id, name string
-	var tid int
-	var isActive bool
-	if err := row.Scan(&id, &name, &tid, &isActive); err != nil {
-		return nil, err
-	}
-	return &dto.UserDTO{UserID: id, Username: name, TeamID: tid, IsActive: isActive}, nil
+func (s *userRepository) ListCandidatesTx(ctx context.Context, tx *sql.Tx, teamID int) ([]Candidate, error) {
+	return loadCandidates(ctx, tx, teamID, nil)
 }
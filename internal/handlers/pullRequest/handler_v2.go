@@ -0,0 +1,98 @@
+package pullRequest
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"database/sql"
+	"errors"
+	"net/http"
+)
+
+// CreateV2 - POST /v2/pull-requests
+func CreateV2(db *sql.DB, listener AssignmentListener) http.HandlerFunc {
+	repo := NewPullRequestRepository(db)
+	repo.SetListener(listener)
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.PullRequestDTO
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+		pr, err := repo.CreatePullRequest(r.Context(), req)
+		if err != nil {
+			writePullRequestError(w, err, "failed to create pull request")
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, map[string]*dto.PullRequestDTO{"pr": pr})
+	}
+}
+
+// MergeV2 - POST /v2/pull-requests/{id}/merge
+func MergeV2(db *sql.DB) http.HandlerFunc {
+	repo := NewPullRequestRepository(db)
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := dto.MergePRRequest{PullRequestID: r.PathValue("id")}
+		if !common.Validate(w, req) {
+			return
+		}
+		pr, err := repo.MergePullRequest(r.Context(), req.PullRequestID)
+		if err != nil {
+			writePullRequestError(w, err, "failed to merge pull request")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]*dto.PullRequestDTO{"pr": pr})
+	}
+}
+
+// ReassignV2 - POST /v2/pull-requests/{id}/reassign, тело - {old_reviewer_id, assignment_mode}
+func ReassignV2(db *sql.DB, listener AssignmentListener) http.HandlerFunc {
+	svc := NewService(db, listener)
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.ReassignReviewerRequest
+		if !common.Decode(w, r, &req) {
+			return
+		}
+		req.PullRequestID = r.PathValue("id")
+		if !common.Validate(w, req) {
+			return
+		}
+		res, err := svc.ReassignInMode(r.Context(), req.PullRequestID, req.OldReviewerID, req.AssignmentMode)
+		if err != nil {
+			writePullRequestError(w, err, "failed to reassign reviewer")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, res)
+	}
+}
+
+// EscalationsV2 - GET /v2/pull-requests/{id}/escalations
+func EscalationsV2(db *sql.DB) http.HandlerFunc {
+	repo := NewPullRequestRepository(db)
+	return func(w http.ResponseWriter, r *http.Request) {
+		prID := r.PathValue("id")
+		list, err := repo.ListEscalations(r.Context(), prID)
+		if err != nil {
+			writePullRequestError(w, err, "failed to list escalations")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"pull_request_id": prID,
+			"escalations":     list,
+		})
+	}
+}
+
+func writePullRequestError(w http.ResponseWriter, err error, message string) {
+	var noCandidate *dto.NoCandidateError
+	switch {
+	case errors.As(err, &noCandidate):
+		common.WriteErrorDetails(w, http.StatusConflict, dto.ErrorCodeNoCandidate, "no active reviewer candidate in team", noCandidate.Skipped)
+	case err.Error() == dto.ErrorCodeNotFound:
+		common.WriteError(w, http.StatusNotFound, dto.ErrorCodeNotFound, "resource not found")
+	case err.Error() == dto.ErrorCodePRExists:
+		common.WriteError(w, http.StatusConflict, dto.ErrorCodePRExists, "PR id already exists")
+	case err.Error() == dto.ErrorCodePRMerged:
+		common.WriteError(w, http.StatusConflict, dto.ErrorCodePRMerged, "cannot reassign on merged PR")
+	default:
+		common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, message)
+	}
+}
@@ -0,0 +1,26 @@
+package pullRequest
+
+// AssignmentListener получает уведомления об изменении ревьюеров после фиксации
+// транзакции. Реализация не должна блокировать вызывающего: долгую работу
+// (например, запросы к код-хостингу) следует выполнять асинхронно.
+type AssignmentListener interface {
+	ReviewersAssigned(prID string, reviewerIDs []string)
+	ReviewerReplaced(prID, oldReviewerID, newReviewerID string)
+}
+
+// SetListener подключает получателя уведомлений; nil отключает уведомления.
+func (r *PullRequestRepository) SetListener(l AssignmentListener) {
+	r.listener = l
+}
+
+func (r *PullRequestRepository) notifyAssigned(prID string, reviewerIDs []string) {
+	if r.listener != nil {
+		r.listener.ReviewersAssigned(prID, reviewerIDs)
+	}
+}
+
+func (r *PullRequestRepository) notifyReplaced(prID, oldReviewerID, newReviewerID string) {
+	if r.listener != nil {
+		r.listener.ReviewerReplaced(prID, oldReviewerID, newReviewerID)
+	}
+}
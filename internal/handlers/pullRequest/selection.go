@@ -0,0 +1,238 @@
+package pullRequest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+
+	"github.com/lib/pq"
+)
+
+// Candidate - участник, из которого выбирается ревьюер, вместе с данными для ранжирования.
+type Candidate struct {
+	UserID      string
+	IsActive    bool
+	Unavailable bool
+	Skills      []string
+	OpenReviews int
+	OwnedFiles  int
+	TimeZone    string
+	WorkStart   string
+	WorkEnd     string
+	// 0 - без ограничения
+	MaxOpenReviews int
+}
+
+type ReviewerPick struct {
+	UserID string
+	Reason string
+}
+
+// selectOptions - параметры одного выбора ревьюеров
+type selectOptions struct {
+	AuthorID string
+	// текущие ревьюеры PR
+	Exclude      []string
+	RequiredTags []string
+	Mode         string
+	Now          time.Time
+}
+
+// участники команды плюс явно перечисленные пользователи (например, владельцы
+// из CODEOWNERS из других команд), в случайном порядке; пользователи без
+// команды (удаленные из команды) ревьюерами не назначаются
+const selectCandidatesSQL = `
+SELECT
+    u.id,
+    u.is_active,
+    EXISTS (SELECT 1
+              FROM user_unavailability ua
+             WHERE ua.user_id = u.id AND ua.starts_at <= NOW() AND ua.ends_at > NOW()),
+    ARRAY(SELECT s.tag FROM user_skill s WHERE s.user_id = u.id ORDER BY s.tag),
+    (SELECT COUNT(*)
+       FROM pull_request_reviewer prr
+       JOIN pull_request pr ON pr.id = prr.pr_id
+      WHERE prr.user_id = u.id AND pr.status = 'OPEN'),
+    u.time_zone,
+    COALESCE(u.work_start, ''),
+    COALESCE(u.work_end, ''),
+    COALESCE(u.max_open_reviews, t.default_max_open_reviews, 0)
+FROM "user" u
+LEFT JOIN team t ON t.id = u.team_id
+WHERE u.team_id = $1 OR (u.id = ANY($2) AND u.team_id IS NOT NULL)
+ORDER BY random();
+`
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func loadCandidates(ctx context.Context, q querier, teamID int, extra []string) ([]Candidate, error) {
+	rows, err := q.QueryContext(ctx, selectCandidatesSQL, teamID, pq.Array(extra))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Candidate
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.UserID, &c.IsActive, &c.Unavailable, pq.Array(&c.Skills), &c.OpenReviews,
+			&c.TimeZone, &c.WorkStart, &c.WorkEnd, &c.MaxOpenReviews); err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// InWorkingHours сообщает, находится ли кандидат в рабочих часах в момент now.
+// Если рабочие часы не заданы, кандидат считается доступным в любое время.
+func (c Candidate) InWorkingHours(now time.Time) bool {
+	if c.WorkStart == "" || c.WorkEnd == "" {
+		return true
+	}
+	loc, err := common.LoadLocation(c.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start, err := common.ParseClock(c.WorkStart)
+	if err != nil {
+		return true
+	}
+	end, err := common.ParseClock(c.WorkEnd)
+	if err != nil {
+		return true
+	}
+	return common.WithinHours(now, loc, start, end)
+}
+
+// pickReviewers выбирает до n ревьюеров среди активных, не находящихся в
+// отпуске и не достигших лимита OPEN-ревью кандидатов, кроме автора и текущих
+// ревьюеров. Для остальных возвращается причина пропуска. Приоритет: в режиме
+// working_hours - те, у кого сейчас рабочее время; затем владельцы измененных
+// файлов, совпадение навыков с требуемыми тегами PR и меньшая текущая нагрузка.
+// При равенстве сохраняется случайный порядок загрузки.
+func pickReviewers(cands []Candidate, opts selectOptions, n int) ([]ReviewerPick, []dto.SkippedCandidateDTO) {
+	excluded := make(map[string]struct{}, len(opts.Exclude))
+	for _, id := range opts.Exclude {
+		excluded[id] = struct{}{}
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	byHours := opts.Mode == dto.AssignmentModeWorkingHours
+
+	type scored struct {
+		c       Candidate
+		matched []string
+		inHours bool
+	}
+	var pool []scored
+	var skipped []dto.SkippedCandidateDTO
+	for _, c := range cands {
+		if reason := skipReason(c, opts.AuthorID, excluded); reason != "" {
+			skipped = append(skipped, dto.SkippedCandidateDTO{UserID: c.UserID, Reason: reason})
+			continue
+		}
+		pool = append(pool, scored{
+			c:       c,
+			matched: intersectTags(c.Skills, opts.RequiredTags),
+			inHours: !byHours || c.InWorkingHours(opts.Now),
+		})
+	}
+
+	sort.SliceStable(pool, func(i, j int) bool {
+		a, b := pool[i], pool[j]
+		if a.inHours != b.inHours {
+			return a.inHours
+		}
+		if a.c.OwnedFiles != b.c.OwnedFiles {
+			return a.c.OwnedFiles > b.c.OwnedFiles
+		}
+		if len(a.matched) != len(b.matched) {
+			return len(a.matched) > len(b.matched)
+		}
+		return a.c.OpenReviews < b.c.OpenReviews
+	})
+
+	if len(pool) > n {
+		pool = pool[:n]
+	}
+	picks := make([]ReviewerPick, 0, len(pool))
+	for _, p := range pool {
+		reason := matchReason(p.c, p.matched, len(opts.RequiredTags))
+		if byHours {
+			if p.inHours {
+				reason = "within working hours; " + reason
+			} else {
+				reason = "outside working hours (not enough reviewers within working hours); " + reason
+			}
+		}
+		picks = append(picks, ReviewerPick{UserID: p.c.UserID, Reason: reason})
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].UserID < skipped[j].UserID })
+	return picks, skipped
+}
+
+func skipReason(c Candidate, authorID string, excluded map[string]struct{}) string {
+	switch _, isReviewer := excluded[c.UserID]; {
+	case c.UserID == authorID:
+		return dto.SkipReasonAuthor
+	case isReviewer:
+		return dto.SkipReasonAlreadyReviewer
+	case !c.IsActive:
+		return dto.SkipReasonInactive
+	case c.Unavailable:
+		return dto.SkipReasonUnavailable
+	case c.MaxOpenReviews > 0 && c.OpenReviews >= c.MaxOpenReviews:
+		return dto.SkipReasonAtCap
+	}
+	return ""
+}
+
+func matchReason(c Candidate, matched []string, required int) string {
+	var parts []string
+	if c.OwnedFiles > 0 {
+		parts = append(parts, fmt.Sprintf("codeowner of %d changed file(s)", c.OwnedFiles))
+	}
+	if required > 0 {
+		if len(matched) > 0 {
+			parts = append(parts, fmt.Sprintf("skills %d/%d: %s", len(matched), required, strings.Join(matched, ", ")))
+		} else {
+			parts = append(parts, "no matching skills")
+		}
+	}
+	parts = append(parts, fmt.Sprintf("%d open review(s)", c.OpenReviews))
+	return strings.Join(parts, "; ")
+}
+
+func intersectTags(skills, required []string) []string {
+	var out []string
+	for _, r := range required {
+		for _, s := range skills {
+			if s == r {
+				out = append(out, r)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func pickIDs(picks []ReviewerPick) []string {
+	ids := make([]string, 0, len(picks))
+	for _, p := range picks {
+		ids = append(ids, p.UserID)
+	}
+	return ids
+}
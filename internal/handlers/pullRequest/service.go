@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"log"
 
 	dto "AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/outbox"
 )
 
 type PullRequestService struct {
@@ -17,85 +19,248 @@ func NewPullRequestService(prRepo *PullRequestRepository, userRepo UserRepo) *Pu
 	return &PullRequestService{prRepo: prRepo, userRepo: userRepo}
 }
 
+// NewService собирает сервис поверх одной базы, как это делают HTTP-обработчики.
+func NewService(db *sql.DB, listener AssignmentListener) *PullRequestService {
+	prRepo := NewPullRequestRepository(db)
+	prRepo.SetListener(listener)
+	return NewPullRequestService(prRepo, &userRepository{})
+}
+
 type UserRepo interface {
-	GetByID(ctx context.Context, userID string) (*dto.UserDTO, error)
-	GetRandomActiveTeammate(ctx context.Context, teamID int, exclude []string) (*dto.UserDTO, error)
+	GetByIDTx(ctx context.Context, tx *sql.Tx, userID string) (*dto.UserDTO, error)
+	ListCandidatesTx(ctx context.Context, tx *sql.Tx, teamID int) ([]Candidate, error)
 }
 
-func (s *PullRequestService) reassignReviewerTx(ctx context.Context, prID string, oldReviewerID string, out **dto.ReassignReviewerResponse) error {
+func (s *PullRequestService) reassignReviewerTx(ctx context.Context, prID string, oldReviewerID string, mode string, out **dto.ReassignReviewerResponse) error {
 	tx, err := s.prRepo.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	resp, err := s.reassignInTx(ctx, tx, prID, oldReviewerID, mode)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.prRepo.notifyReplaced(prID, oldReviewerID, resp.ReplacedBy)
+	*out = resp
+	return nil
+}
+
+// reassignInTx заменяет ревьюера в транзакции tx. Ошибки PR (NOT_FOUND,
+// PR_MERGED, NO_CANDIDATE) возвращаются до первой записи, поэтому после них
+// транзакцию можно продолжать.
+func (s *PullRequestService) reassignInTx(ctx context.Context, tx *sql.Tx, prID, oldReviewerID, mode string) (*dto.ReassignReviewerResponse, error) {
 	pr, err := s.prRepo.GetByIDForUpdateTx(ctx, tx, prID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return errors.New(dto.ErrorCodeNotFound)
+			return nil, errors.New(dto.ErrorCodeNotFound)
 		}
-		return err
+		return nil, err
 	}
 
 	if pr.Status == "MERGED" {
-		return errors.New(dto.ErrorCodePRMerged)
+		return nil, errors.New(dto.ErrorCodePRMerged)
 	}
 
 	if !pr.HasReviewer(oldReviewerID) {
-		return errors.New(dto.ErrorCodeNotFound)
+		return nil, errors.New(dto.ErrorCodeNotFound)
 	}
 
-	oldUser, err := s.userRepo.GetByID(ctx, oldReviewerID)
+	oldUser, err := s.userRepo.GetByIDTx(ctx, tx, oldReviewerID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return errors.New(dto.ErrorCodeNotFound)
+			return nil, errors.New(dto.ErrorCodeNotFound)
 		}
-		return err
+		return nil, err
+	}
+	teamID := oldUser.TeamID
+	if pr.TeamID.Valid {
+		teamID = int(pr.TeamID.Int64)
 	}
-	exclude := append(pr.ReviewerIDs[:0:0], pr.ReviewerIDs...)
-	exclude = append(exclude, oldReviewerID)
-	exclude = append(exclude, pr.AuthorID)
-	replacement, err := s.userRepo.GetRandomActiveTeammate(ctx, oldUser.TeamID, exclude)
+	candidates, err := s.userRepo.ListCandidatesTx(ctx, tx, teamID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return errors.New(dto.ErrorCodeNoCandidate)
-		}
-		return err
+		return nil, err
 	}
-	if replacement.UserID == pr.AuthorID {
-		return errors.New(dto.ErrorCodeNoCandidate)
+	picks, skipped := pickReviewers(candidates, selectOptions{
+		AuthorID:     pr.AuthorID,
+		Exclude:      pr.ReviewerIDs,
+		RequiredTags: pr.RequiredTags,
+		Mode:         mode,
+	}, 1)
+	if len(picks) == 0 {
+		return nil, &dto.NoCandidateError{Skipped: skipped}
 	}
+	replacement := picks[0]
 
 	if err := s.prRepo.ReassignReviewerTx(ctx, tx, pr.ID, oldReviewerID, replacement.UserID); err != nil {
-		return err
+		return nil, err
 	}
 
 	reviewers, err := s.prRepo.ListReviewersTx(ctx, tx, pr.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return err
+	if err := outbox.Write(ctx, tx, outbox.PullRequestAggregate(pr.ID), outbox.EventReviewerReassigned, outbox.ReassignPayload{
+		PullRequestID: pr.ID,
+		Title:         pr.Title,
+		AuthorID:      pr.AuthorID,
+		OldReviewerID: oldReviewerID,
+		NewReviewerID: replacement.UserID,
+		Reviewers:     reviewers,
+	}); err != nil {
+		return nil, err
 	}
 
-	*out = &dto.ReassignReviewerResponse{
+	return &dto.ReassignReviewerResponse{
 		PR: dto.PullRequestDTO{
 			PullRequestID:     pr.ID,
 			PullRequestName:   pr.Title,
 			AuthorID:          pr.AuthorID,
 			Status:            pr.Status,
+			RequiredTags:      pr.RequiredTags,
 			AssignedReviewers: reviewers,
 		},
 		ReplacedBy: replacement.UserID,
-	}
-	return nil
+		Reason:     replacement.Reason,
+	}, nil
 }
 
 func (s *PullRequestService) Reassign(ctx context.Context, prID, oldReviewerID string) (*dto.ReassignReviewerResponse, error) {
+	return s.ReassignInMode(ctx, prID, oldReviewerID, dto.AssignmentModeDefault)
+}
+
+// ReassignInMode - то же, что Reassign, но с явным режимом выбора ревьюера
+// (например, dto.AssignmentModeWorkingHours).
+func (s *PullRequestService) ReassignInMode(ctx context.Context, prID, oldReviewerID, mode string) (*dto.ReassignReviewerResponse, error) {
 	var out *dto.ReassignReviewerResponse
-	if err := s.reassignReviewerTx(ctx, prID, oldReviewerID, &out); err != nil {
+	if err := s.reassignReviewerTx(ctx, prID, oldReviewerID, mode, &out); err != nil {
 		return nil, err
 	}
 	return out, nil
 }
+
+type ReassignResult struct {
+	PullRequestID string
+	ReplacedBy    string
+	Err           error
+}
+
+// ReassignOpenReviews переназначает все OPEN PR, где пользователь указан ревьюером.
+// Ошибка отдельного PR (например, NO_CANDIDATE) не прерывает обработку остальных.
+func (s *PullRequestService) ReassignOpenReviews(ctx context.Context, userID string) ([]ReassignResult, error) {
+	prIDs, err := s.prRepo.ListOpenReviewIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reassignEach(ctx, userID, prIDs), nil
+}
+
+// ReassignOpenReviewsTx - то же, что ReassignOpenReviews, но в транзакции
+// вызывающего: переназначения фиксируются или откатываются вместе с ней.
+// Ошибки PR (NO_CANDIDATE и т.п.) попадают в результат, остальные прерывают
+// обработку, и транзакцию нужно откатить. После фиксации вызывающий отправляет
+// уведомления через NotifyReassigned.
+func (s *PullRequestService) ReassignOpenReviewsTx(ctx context.Context, tx *sql.Tx, userID string) ([]ReassignResult, error) {
+	prIDs, err := listIDs(ctx, tx, selectOpenReviewIDsSQL, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reassignEachTx(ctx, tx, userID, prIDs)
+}
+
+// ReassignTeamReviewsTx - то же, что ReassignOpenReviewsTx, но только для OPEN
+// PR команды teamID (например, команды, из которой пользователь перешел в другую).
+func (s *PullRequestService) ReassignTeamReviewsTx(ctx context.Context, tx *sql.Tx, userID string, teamID int) ([]ReassignResult, error) {
+	prIDs, err := listIDs(ctx, tx, selectOpenTeamReviewIDsSQL, userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reassignEachTx(ctx, tx, userID, prIDs)
+}
+
+// ReassignOtherTeamsReviewsTx - то же, что ReassignOpenReviewsTx, но только для
+// OPEN PR других команд, чем teamID; PR без команды пропускаются.
+func (s *PullRequestService) ReassignOtherTeamsReviewsTx(ctx context.Context, tx *sql.Tx, userID string, teamID int) ([]ReassignResult, error) {
+	prIDs, err := listIDs(ctx, tx, selectOpenOtherTeamsReviewIDsSQL, userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reassignEachTx(ctx, tx, userID, prIDs)
+}
+
+// NotifyReassigned отправляет уведомления о заменах ревьюера userID, сделанных
+// в уже зафиксированной транзакции.
+func (s *PullRequestService) NotifyReassigned(userID string, results []ReassignResult) {
+	for _, res := range results {
+		if res.Err == nil {
+			s.prRepo.notifyReplaced(res.PullRequestID, userID, res.ReplacedBy)
+		}
+	}
+}
+
+// AppendReassignments добавляет к out результаты переназначения ревью
+// пользователя userID. Ожидаемые ошибки отдаются кодом, остальные пишутся в лог
+// и отдаются как INTERNAL_ERROR.
+func AppendReassignments(out []dto.ReassignmentDTO, userID string, results []ReassignResult) []dto.ReassignmentDTO {
+	for _, res := range results {
+		r := dto.ReassignmentDTO{UserID: userID, PullRequestID: res.PullRequestID, ReplacedBy: res.ReplacedBy}
+		if res.Err != nil {
+			if isReassignError(res.Err) {
+				r.Error = res.Err.Error()
+			} else {
+				log.Printf("reassign %s in PR %s: %v", userID, res.PullRequestID, res.Err)
+				r.Error = dto.ErrorInternalError
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// isReassignError сообщает, что переназначение не выполнено из-за состояния PR,
+// а не из-за сбоя.
+func isReassignError(err error) bool {
+	switch err.Error() {
+	case dto.ErrorCodeNoCandidate, dto.ErrorCodeNotFound, dto.ErrorCodePRMerged:
+		return true
+	}
+	return false
+}
+
+func (s *PullRequestService) reassignEach(ctx context.Context, userID string, prIDs []string) []ReassignResult {
+	results := make([]ReassignResult, 0, len(prIDs))
+	for _, prID := range prIDs {
+		res := ReassignResult{PullRequestID: prID}
+		out, err := s.Reassign(ctx, prID, userID)
+		if err != nil {
+			res.Err = err
+		} else {
+			res.ReplacedBy = out.ReplacedBy
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func (s *PullRequestService) reassignEachTx(ctx context.Context, tx *sql.Tx, userID string, prIDs []string) ([]ReassignResult, error) {
+	results := make([]ReassignResult, 0, len(prIDs))
+	for _, prID := range prIDs {
+		res := ReassignResult{PullRequestID: prID}
+		out, err := s.reassignInTx(ctx, tx, prID, userID, dto.AssignmentModeDefault)
+		switch {
+		case err == nil:
+			res.ReplacedBy = out.ReplacedBy
+		case isReassignError(err):
+			res.Err = err
+		default:
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
@@ -0,0 +1,97 @@
+package pullRequest
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	dto "AvitoInternship/internal/handlers/dto"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// команда-владелец PR - pr.team_id, а если он не задан, команда автора;
+// автора удаленного пользователя уже нет
+const listPullRequestsFromSQL = `
+FROM pull_request pr
+LEFT JOIN "user" author ON author.id = pr.author_id
+LEFT JOIN team t ON t.id = COALESCE(pr.team_id, author.team_id)`
+
+// ListPullRequests возвращает страницу PR по фильтру и курсор следующей
+// страницы ("" - страница последняя).
+func ListPullRequests(ctx context.Context, db *sql.DB, f dto.PullRequestFilter) ([]dto.PullRequestShortDTO, string, error) {
+	var where []string
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.ReviewerID != "" {
+		add("EXISTS (SELECT 1 FROM pull_request_reviewer prr WHERE prr.pr_id = pr.id AND prr.user_id = $%d)", f.ReviewerID)
+	}
+	if f.AuthorID != "" {
+		add("pr.author_id = $%d", f.AuthorID)
+	}
+	if f.Status != "" {
+		add("pr.status = $%d", f.Status)
+	}
+	if f.Repository != "" {
+		add("pr.repository = $%d", f.Repository)
+	}
+	if f.TeamName != "" {
+		add("t.name = $%d", f.TeamName)
+	}
+	if f.CreatedFrom != nil {
+		add("pr.created_at >= $%d", f.CreatedFrom.UTC())
+	}
+	if f.CreatedTo != nil {
+		add("pr.created_at < $%d", f.CreatedTo.UTC())
+	}
+
+	key := "pr.id"
+	if strings.TrimPrefix(f.Page.Sort, "-") == dto.SortCreatedAt {
+		key = "pr.created_at"
+	}
+	where, args, order := common.Keyset(where, args, key, "timestamp", "pr.id", strings.HasPrefix(f.Page.Sort, "-"), f.Page.After)
+
+	query := "SELECT pr.id, pr.title, pr.author_id, pr.status, COALESCE(pr.repository, ''), pr.created_at" + listPullRequestsFromSQL
+	if len(where) > 0 {
+		query += "\nWHERE " + strings.Join(where, " AND ")
+	}
+	query += "\nORDER BY " + order
+	if f.Page.Limit > 0 {
+		query += fmt.Sprintf("\nLIMIT %d", f.Page.Limit+1)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	result := make([]dto.PullRequestShortDTO, 0)
+	for rows.Next() {
+		var pr dto.PullRequestShortDTO
+		var createdAt time.Time
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.Repository, &createdAt); err != nil {
+			return nil, "", err
+		}
+		pr.CreatedAt = &createdAt
+		result = append(result, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if f.Page.Limit > 0 && len(result) > f.Page.Limit {
+		result = result[:f.Page.Limit]
+		last := result[len(result)-1]
+		next = common.EncodeCursor(dto.Cursor{
+			Sort: f.Page.Sort,
+			Key:  last.CreatedAt.Format(time.RFC3339Nano),
+			ID:   last.PullRequestID,
+		})
+	}
+	return result, next, nil
+}
@@ -6,34 +6,76 @@ import (
 	"errors"
 	"time"
 
+	"AvitoInternship/internal/handlers/common"
 	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/outbox"
 
 	"github.com/lib/pq"
 )
 
 type PullRequestRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	listener AssignmentListener
 }
 
 func NewPullRequestRepository(db *sql.DB) *PullRequestRepository {
 	return &PullRequestRepository{db: db}
 }
 
+// до фиксации PR автора нельзя удалить: удаление ждет эту блокировку и затем
+// видит OPEN PR, а удаленный раньше автор не находится
+const lockAuthorSQL = `SELECT TRUE FROM "user" WHERE id = $1 AND deleted_at IS NULL FOR KEY SHARE;`
+
 const (
-	insertPRSQL        = `INSERT INTO pull_request(id, title, author_id, status) VALUES ($1, $2, $3, $4);`
-	insertReviewerSQL  = `INSERT INTO pull_request_reviewer(pr_id, user_id) VALUES ($1, $2);`
-	selectUserTeamSQL  = `SELECT team_id FROM "user" WHERE id = $1;`
-	selectReviewersSQL = `SELECT id FROM "user" WHERE team_id = $1 AND id != $2 AND is_active = TRUE ORDER BY random() LIMIT 2;`
-	selectPRByIDSQL    = `SELECT id, title, author_id, status, created_at, updated_at FROM pull_request WHERE id = $1;`
-	updatePRStatusSQL  = `UPDATE pull_request SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING id, title, author_id, status, created_at, updated_at;`
+	insertPRSQL       = `INSERT INTO pull_request(id, title, author_id, status, team_id, repository) VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6);`
+	insertReviewerSQL = `INSERT INTO pull_request_reviewer(pr_id, user_id) VALUES ($1, $2);`
+	selectUserTeamSQL = `SELECT COALESCE(team_id, 0) FROM "user" WHERE id = $1 AND deleted_at IS NULL;`
+	selectTeamIDSQL   = `SELECT id FROM team WHERE name = $1;`
+	insertTagSQL      = `INSERT INTO pull_request_tag(pr_id, tag) VALUES ($1, $2);`
+	selectPRByIDSQL   = `
+SELECT pr.id, pr.title, pr.author_id, pr.status, pr.created_at, pr.updated_at, COALESCE(t.name, ''), COALESCE(pr.repository, '')
+FROM pull_request pr
+LEFT JOIN team t ON t.id = pr.team_id
+WHERE pr.id = $1;`
+	updatePRStatusSQL      = `UPDATE pull_request SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING id, title, author_id, status, created_at, updated_at, COALESCE(repository, '');`
+	selectOpenReviewIDsSQL = `
+SELECT pr.id
+FROM pull_request pr
+JOIN pull_request_reviewer prr ON prr.pr_id = pr.id
+WHERE prr.user_id = $1 AND pr.status = 'OPEN'
+ORDER BY pr.id;`
+	// PR команды - pr.team_id, а для PR без него - команда автора
+	selectOpenTeamReviewIDsSQL = `
+SELECT pr.id
+FROM pull_request pr
+JOIN pull_request_reviewer prr ON prr.pr_id = pr.id
+LEFT JOIN "user" author ON author.id = pr.author_id
+WHERE prr.user_id = $1 AND pr.status = 'OPEN' AND COALESCE(pr.team_id, author.team_id) = $2
+ORDER BY pr.id;`
+	// PR других команд; PR без команды не входят
+	selectOpenOtherTeamsReviewIDsSQL = `
+SELECT pr.id
+FROM pull_request pr
+JOIN pull_request_reviewer prr ON prr.pr_id = pr.id
+WHERE prr.user_id = $1 AND pr.status = 'OPEN' AND pr.team_id <> $2
+ORDER BY pr.id;`
+	selectEscalationsSQL = `
+SELECT reviewer_id, action, COALESCE(new_reviewer_id, ''), COALESCE(error, ''), created_at
+FROM review_escalation
+WHERE pr_id = $1
+ORDER BY id;`
 )
 
+const maxReviewers = 2
+
 type PRRecord struct {
-	ID          string
-	Title       string
-	AuthorID    string
-	Status      string
-	ReviewerIDs []string
+	ID           string
+	Title        string
+	AuthorID     string
+	Status       string
+	TeamID       sql.NullInt64
+	ReviewerIDs  []string
+	RequiredTags []string
 }
 
 func (pr *PRRecord) HasReviewer(id string) bool {
@@ -47,8 +89,8 @@ func (pr *PRRecord) HasReviewer(id string) bool {
 
 func (r *PullRequestRepository) GetByIDForUpdateTx(ctx context.Context, tx *sql.Tx, prID string) (*PRRecord, error) {
 	var rec PRRecord
-	row := tx.QueryRowContext(ctx, `SELECT id, title, author_id, status FROM pull_request WHERE id = $1 FOR UPDATE`, prID)
-	if err := row.Scan(&rec.ID, &rec.Title, &rec.AuthorID, &rec.Status); err != nil {
+	row := tx.QueryRowContext(ctx, `SELECT id, title, author_id, status, team_id FROM pull_request WHERE id = $1 FOR UPDATE`, prID)
+	if err := row.Scan(&rec.ID, &rec.Title, &rec.AuthorID, &rec.Status, &rec.TeamID); err != nil {
 		return nil, err
 	}
 
@@ -67,6 +109,22 @@ func (r *PullRequestRepository) GetByIDForUpdateTx(ctx context.Context, tx *sql.
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+
+	tagRows, err := tx.QueryContext(ctx, `SELECT tag FROM pull_request_tag WHERE pr_id = $1 ORDER BY tag`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var tag string
+		if err := tagRows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		rec.RequiredTags = append(rec.RequiredTags, tag)
+	}
+	if err := tagRows.Err(); err != nil {
+		return nil, err
+	}
 	return &rec, nil
 }
 
@@ -91,6 +149,30 @@ func (r *PullRequestRepository) ReassignReviewerTx(ctx context.Context, tx *sql.
 	return nil
 }
 
+func (r *PullRequestRepository) ListOpenReviewIDs(ctx context.Context, userID string) ([]string, error) {
+	return listIDs(ctx, r.db, selectOpenReviewIDsSQL, userID)
+}
+
+func listIDs(ctx context.Context, q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 func (r *PullRequestRepository) ListReviewersTx(ctx context.Context, tx *sql.Tx, prID string) ([]string, error) {
 	rows, err := tx.QueryContext(ctx, `SELECT user_id FROM pull_request_reviewer WHERE pr_id = $1 ORDER BY user_id`, prID)
 	if err != nil {
@@ -119,31 +201,51 @@ func (r *PullRequestRepository) CreatePullRequest(ctx context.Context, payload d
 		}
 		return nil, err
 	}
+	// PR в чужой репозиторий ревьюят участники команды-владельца
+	if payload.TeamName != "" {
+		if err := r.db.QueryRowContext(ctx, selectTeamIDSQL, payload.TeamName).Scan(&teamID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, errors.New("NOT_FOUND")
+			}
+			return nil, err
+		}
+	}
 
-	rows, err := r.db.QueryContext(ctx, selectReviewersSQL, teamID, payload.AuthorID)
+	owners, err := r.ownerHits(ctx, payload.Repository, payload.ChangedFiles)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var reviewers []string
-	for rows.Next() {
-		var uid string
-		if err := rows.Scan(&uid); err != nil {
-			return nil, err
-		}
-		reviewers = append(reviewers, uid)
-	}
-	if err := rows.Err(); err != nil {
+	candidates, err := loadCandidates(ctx, r.db, teamID, setKeys(owners))
+	if err != nil {
 		return nil, err
 	}
+	for i := range candidates {
+		candidates[i].OwnedFiles = owners[candidates[i].UserID]
+	}
+	requiredTags := common.NormalizeTags(payload.RequiredTags)
+	picks, skipped := pickReviewers(candidates, selectOptions{
+		AuthorID:     payload.AuthorID,
+		RequiredTags: requiredTags,
+		Mode:         payload.AssignmentMode,
+	}, maxReviewers)
+	if len(picks) == 0 {
+		return nil, &dto.NoCandidateError{Skipped: skipped}
+	}
+	reviewers := pickIDs(picks)
 
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = tx.Rollback() }()
-	if _, err := tx.ExecContext(ctx, insertPRSQL, payload.PullRequestID, payload.PullRequestName, payload.AuthorID, "OPEN"); err != nil {
+	if err := tx.QueryRowContext(ctx, lockAuthorSQL, payload.AuthorID).Scan(new(bool)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("NOT_FOUND")
+		}
+		return nil, err
+	}
+	repository := sql.NullString{String: payload.Repository, Valid: payload.Repository != ""}
+	if _, err := tx.ExecContext(ctx, insertPRSQL, payload.PullRequestID, payload.PullRequestName, payload.AuthorID, "OPEN", teamID, repository); err != nil {
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
 			return nil, errors.New("PR_EXISTS")
 		}
@@ -155,14 +257,31 @@ func (r *PullRequestRepository) CreatePullRequest(ctx context.Context, payload d
 			return nil, err
 		}
 	}
+	for _, tag := range requiredTags {
+		if _, err := tx.ExecContext(ctx, insertTagSQL, payload.PullRequestID, tag); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := outbox.Write(ctx, tx, outbox.PullRequestAggregate(payload.PullRequestID), outbox.EventPRCreated, outbox.PullRequestPayload{
+		PullRequestID: payload.PullRequestID,
+		Title:         payload.PullRequestName,
+		AuthorID:      payload.AuthorID,
+		Status:        "OPEN",
+		Repository:    payload.Repository,
+		Reviewers:     reviewers,
+	}); err != nil {
+		return nil, err
+	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
+	r.notifyAssigned(payload.PullRequestID, reviewers)
 
-	var id, title, authorID, status string
+	var id, title, authorID, status, teamName, repo string
 	var createdAt, updatedAt sql.NullTime
-	if err := r.db.QueryRowContext(ctx, selectPRByIDSQL, payload.PullRequestID).Scan(&id, &title, &authorID, &status, &createdAt, &updatedAt); err != nil {
+	if err := r.db.QueryRowContext(ctx, selectPRByIDSQL, payload.PullRequestID).Scan(&id, &title, &authorID, &status, &createdAt, &updatedAt, &teamName, &repo); err != nil {
 		return nil, err
 	}
 
@@ -171,8 +290,14 @@ func (r *PullRequestRepository) CreatePullRequest(ctx context.Context, payload d
 		PullRequestName:   title,
 		AuthorID:          authorID,
 		Status:            status,
+		TeamName:          teamName,
+		Repository:        repo,
+		RequiredTags:      requiredTags,
 		AssignedReviewers: reviewers,
 	}
+	for _, p := range picks {
+		res.ReviewerMatches = append(res.ReviewerMatches, dto.ReviewerMatchDTO{UserID: p.UserID, Reason: p.Reason})
+	}
 	if createdAt.Valid {
 		t := createdAt.Time
 		res.CreatedAt = &t
@@ -181,30 +306,47 @@ func (r *PullRequestRepository) CreatePullRequest(ctx context.Context, payload d
 }
 
 func (r *PullRequestRepository) MergePullRequest(ctx context.Context, pullRequestID string) (*dto.PullRequestDTO, error) {
-	var id, title, authorID, status string
-	var createdAt, updatedAt sql.NullTime
-	err := r.db.QueryRowContext(ctx, updatePRStatusSQL, "MERGED", pullRequestID).Scan(&id, &title, &authorID, &status, &createdAt, &updatedAt)
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var prevStatus string
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM pull_request WHERE id = $1 FOR UPDATE`, pullRequestID).Scan(&prevStatus); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New("NOT_FOUND")
 		}
 		return nil, err
 	}
 
-	revRows, err := r.db.QueryContext(ctx, `SELECT user_id FROM pull_request_reviewer WHERE pr_id = $1 ORDER BY user_id;`, id)
+	var id, title, authorID, status, repo string
+	var createdAt, updatedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, updatePRStatusSQL, "MERGED", pullRequestID).Scan(&id, &title, &authorID, &status, &createdAt, &updatedAt, &repo)
 	if err != nil {
 		return nil, err
 	}
-	defer revRows.Close()
-	var reviewers []string
-	for revRows.Next() {
-		var uid string
-		if err := revRows.Scan(&uid); err != nil {
+
+	reviewers, err := r.ListReviewersTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// повторный merge идемпотентен и событие не порождает
+	if prevStatus != "MERGED" {
+		if err := outbox.Write(ctx, tx, outbox.PullRequestAggregate(id), outbox.EventPRMerged, outbox.PullRequestPayload{
+			PullRequestID: id,
+			Title:         title,
+			AuthorID:      authorID,
+			Status:        status,
+			Repository:    repo,
+			Reviewers:     reviewers,
+		}); err != nil {
 			return nil, err
 		}
-		reviewers = append(reviewers, uid)
 	}
-	if err := revRows.Err(); err != nil {
+
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
@@ -219,8 +361,34 @@ func (r *PullRequestRepository) MergePullRequest(ctx context.Context, pullReques
 		PullRequestName:   title,
 		AuthorID:          authorID,
 		Status:            status,
+		Repository:        repo,
 		AssignedReviewers: reviewers,
 		MergedAt:          mergedAt,
 	}
 	return res, nil
 }
+
+func (r *PullRequestRepository) ListEscalations(ctx context.Context, prID string) ([]dto.EscalationDTO, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pull_request WHERE id = $1)`, prID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New(dto.ErrorCodeNotFound)
+	}
+
+	rows, err := r.db.QueryContext(ctx, selectEscalationsSQL, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]dto.EscalationDTO, 0)
+	for rows.Next() {
+		var e dto.EscalationDTO
+		if err := rows.Scan(&e.ReviewerID, &e.Action, &e.NewReviewerID, &e.Error, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
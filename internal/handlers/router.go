@@ -1,25 +1,166 @@
 package handlers
 
 import (
+	"AvitoInternship/internal/config"
+	"AvitoInternship/internal/handlers/admin"
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/openapi"
 	"AvitoInternship/internal/handlers/pullRequest"
+	"AvitoInternship/internal/handlers/subscription"
 	"AvitoInternship/internal/handlers/team"
 	"AvitoInternship/internal/handlers/user"
+	"AvitoInternship/internal/handlers/webhook"
 	"database/sql"
 	"net/http"
+	"slices"
+	"strings"
 )
 
-func SetupRouter(db *sql.DB) *http.ServeMux {
-	mux := http.NewServeMux()
+// route описывает один путь API. Methods - методы, которые принимает Handler
+// (остальные он отклоняет с METHOD_NOT_ALLOWED); по этому списку тесты сверяют
+// маршруты с openapi.json.
+type route struct {
+	Path    string
+	Methods []string
+	Handler http.HandlerFunc
+}
+
+var (
+	get     = []string{http.MethodGet}
+	post    = []string{http.MethodPost}
+	put     = []string{http.MethodPut}
+	patch   = []string{http.MethodPatch}
+	del     = []string{http.MethodDelete}
+	getPost = []string{http.MethodGet, http.MethodPost}
+)
+
+func routes(db *sql.DB, cfg *config.Config, listener pullRequest.AssignmentListener) []route {
+	return []route{
+		{"/users/get", get, user.Get(db)},
+		{"/users/update", post, user.Update(db)},
+		{"/users/delete", post, user.Delete(db, listener)},
+		{"/users/setIsActive", post, user.SetIsActive(db)},
+		{"/users/setProfile", post, user.SetProfile(db)},
+		{"/users/setExternalLogin", post, user.SetExternalLogin(db)},
+		{"/users/getReview", get, user.GetReview(db)},
+		{"/users/list", get, user.List(db)},
+		{"/users/reviewStream", get, user.ReviewStream(db)},
+		{"/users/unavailability/add", post, user.AddUnavailability(db)},
+		{"/users/unavailability/update", post, user.UpdateUnavailability(db)},
+		{"/users/unavailability/delete", post, user.DeleteUnavailability(db)},
+		{"/users/unavailability/list", get, user.ListUnavailability(db)},
 
-	mux.HandleFunc("/users/setIsActive", user.SetIsActive(db))
-	mux.HandleFunc("/users/getReview", user.GetReview(db))
+		{"/team/add", post, team.AddTeam(db)},
+		{"/team/get", get, team.GetTeam(db)},
+		{"/team/setSettings", post, team.SetSettings(db)},
+		{"/team/codeowners", getPost, team.Codeowners(db)},
+		{"/team/rename", post, team.Rename(db)},
+		{"/team/delete", post, team.Delete(db, listener)},
+		{"/team/members/add", post, team.AddMember(db, listener)},
+		{"/team/members/remove", post, team.RemoveMember(db, listener)},
+		{"/team/members/move", post, team.MoveMember(db, listener)},
+		{"/team/sync", put, team.Sync(db, listener)},
 
-	mux.HandleFunc("/team/add", team.AddTeam(db))
-	mux.HandleFunc("/team/get", team.GetTeam(db))
+		{"/pullRequest/create", post, pullRequest.Create(db, listener)},
+		{"/pullRequest/merge", post, pullRequest.Merge(db)},
+		{"/pullRequest/reassign", post, pullRequest.Reassign(db, listener)},
+		{"/pullRequest/list", get, pullRequest.List(db)},
+		{"/pullRequest/escalations", get, pullRequest.Escalations(db)},
 
-	mux.HandleFunc("/pullRequest/create", pullRequest.Create(db))
-	mux.HandleFunc("/pullRequest/merge", pullRequest.Merge(db))
-	mux.HandleFunc("/pullRequest/reassign", pullRequest.Reassign(db))
+		{"/webhooks/github", post, webhook.GitHub(db, cfg.GITHUB_WEBHOOK_SECRET, listener)},
+		{"/webhooks/gitlab", post, webhook.GitLab(db, cfg.GITLAB_WEBHOOK_TOKEN, listener)},
+
+		{"/admin/export", get, admin.Export(db)},
+		{"/admin/import", post, admin.Import(db)},
+
+		{"/subscriptions/add", post, subscription.Add(db, cfg.OUTBOX_ENABLED)},
+		{"/subscriptions/list", get, subscription.List(db)},
+		{"/subscriptions/delete", post, subscription.Delete(db)},
+		{"/subscriptions/deliveries", get, subscription.Deliveries(db)},
+
+		{"/openapi.json", get, openapi.Spec()},
+		{"/docs", get, openapi.Docs()},
+	}
+}
 
+// routesV2 - REST API /v2 поверх тех же репозиториев и сервисов. Пути
+// регистрируются с методом (шаблоны ServeMux Go 1.22), поэтому обработчики
+// метод не проверяют, а параметры пути читают через r.PathValue.
+func routesV2(db *sql.DB, cfg *config.Config, listener pullRequest.AssignmentListener) []route {
+	return []route{
+		{"/v2/teams", post, team.CreateV2(db)},
+		{"/v2/teams/{name}", get, team.GetV2(db)},
+		{"/v2/teams/{name}", patch, team.UpdateV2(db)},
+		{"/v2/teams/{name}", del, team.DeleteV2(db, listener)},
+		{"/v2/teams/{name}/rename", post, team.RenameV2(db)},
+		{"/v2/teams/{name}/members", put, team.SyncV2(db, listener)},
+		{"/v2/teams/{name}/members/{user_id}", put, team.PutMemberV2(db, listener)},
+		{"/v2/teams/{name}/members/{user_id}", del, team.DeleteMemberV2(db, listener)},
+		{"/v2/codeowners/{repository...}", get, team.GetCodeownersV2(db)},
+		{"/v2/codeowners/{repository...}", put, team.PutCodeownersV2(db)},
+
+		{"/v2/users", get, user.List(db)},
+		{"/v2/users/{id}", get, user.GetV2(db)},
+		{"/v2/users/{id}", patch, user.UpdateV2(db)},
+		{"/v2/users/{id}", del, user.DeleteV2(db, listener)},
+		{"/v2/users/{id}/reviews", get, user.GetReviewsV2(db)},
+		{"/v2/users/{id}/review-stream", get, user.ReviewStreamV2(db)},
+		{"/v2/users/{id}/external-logins/{provider}", put, user.PutExternalLoginV2(db)},
+		{"/v2/users/{id}/external-logins/{provider}", del, user.DeleteExternalLoginV2(db)},
+		{"/v2/users/{id}/unavailability", post, user.AddUnavailabilityV2(db)},
+		{"/v2/users/{id}/unavailability", get, user.ListUnavailabilityV2(db)},
+		{"/v2/unavailability/{id}", put, user.UpdateUnavailabilityV2(db)},
+		{"/v2/unavailability/{id}", del, user.DeleteUnavailabilityV2(db)},
+
+		{"/v2/pull-requests", post, pullRequest.CreateV2(db, listener)},
+		{"/v2/pull-requests", get, pullRequest.List(db)},
+		{"/v2/pull-requests/{id}/merge", post, pullRequest.MergeV2(db)},
+		{"/v2/pull-requests/{id}/reassign", post, pullRequest.ReassignV2(db, listener)},
+		{"/v2/pull-requests/{id}/escalations", get, pullRequest.EscalationsV2(db)},
+
+		{"/v2/subscriptions", post, subscription.Add(db, cfg.OUTBOX_ENABLED)},
+		{"/v2/subscriptions", get, subscription.List(db)},
+		{"/v2/subscriptions/{id}", del, subscription.DeleteV2(db)},
+		{"/v2/subscriptions/{id}/deliveries", get, subscription.DeliveriesV2(db)},
+	}
+}
+
+// SetupRouter регистрирует обработчики API. listener получает уведомления о
+// назначении ревьюеров (может быть nil).
+func SetupRouter(db *sql.DB, cfg *config.Config, listener pullRequest.AssignmentListener) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, rt := range routes(db, cfg, listener) {
+		mux.HandleFunc(rt.Path, rt.Handler)
+	}
+	allowed := map[string][]string{}
+	var paths []string
+	for _, rt := range routesV2(db, cfg, listener) {
+		for _, m := range rt.Methods {
+			mux.HandleFunc(m+" "+rt.Path, rt.Handler)
+		}
+		if _, ok := allowed[rt.Path]; !ok {
+			paths = append(paths, rt.Path)
+		}
+		allowed[rt.Path] = append(allowed[rt.Path], rt.Methods...)
+	}
+	// шаблон без метода ловит остальные методы: иначе ServeMux ответит 405
+	// текстом, а не ErrorResponse
+	for _, path := range paths {
+		mux.HandleFunc(path, methodNotAllowed(allowed[path]))
+	}
 	return mux
 }
+
+func methodNotAllowed(methods []string) http.HandlerFunc {
+	methods = slices.Clone(methods)
+	if slices.Contains(methods, http.MethodGet) {
+		methods = append(methods, http.MethodHead)
+	}
+	slices.Sort(methods)
+	allow := strings.Join(methods, ", ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+	}
+}
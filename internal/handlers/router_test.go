@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"AvitoInternship/internal/config"
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestV2MethodNotAllowedIsJSON(t *testing.T) {
+	router := SetupRouter(nil, &config.Config{}, nil)
+	tests := []struct {
+		method, path, allow string
+	}{
+		{http.MethodPost, "/v2/users/u1", "DELETE, GET, HEAD, PATCH"},
+		{http.MethodGet, "/v2/pull-requests/pr-1/merge", "POST"},
+		{http.MethodDelete, "/v2/codeowners/org/repo", "GET, HEAD, PUT"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("%s %s: status = %d", tt.method, tt.path, rec.Code)
+		}
+		if got := rec.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s: Allow = %q, want %q", tt.method, tt.path, got, tt.allow)
+		}
+		var resp common.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error.Code != dto.ErrorMethodNotAllowed {
+			t.Errorf("%s %s: body %q", tt.method, tt.path, rec.Body)
+		}
+	}
+}
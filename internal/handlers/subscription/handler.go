@@ -0,0 +1,152 @@
+package subscription
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/outbox"
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+// addRequest проверяет типы событий подписки по outbox.EventTypes.
+type addRequest struct {
+	dto.SubscriptionDTO
+}
+
+func (r *addRequest) Validate() error {
+	return r.SubscriptionDTO.ValidateEventTypes(outbox.EventTypes)
+}
+
+// Add - POST /subscriptions/add. События доставляет диспетчер outbox, поэтому
+// без OUTBOX_ENABLED подписка не создается: она никогда бы не сработала.
+func Add(db *sql.DB, outboxEnabled bool) http.HandlerFunc {
+	repo := NewSubscriptionRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+			return
+		}
+		if !outboxEnabled {
+			common.WriteError(w, http.StatusConflict, dto.ErrorCodeOutboxDisabled, "event delivery is disabled: set OUTBOX_ENABLED=true")
+			return
+		}
+
+		var req addRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+		slices.Sort(req.EventTypes)
+		req.EventTypes = slices.Compact(req.EventTypes)
+
+		s, err := repo.Add(r.Context(), req.SubscriptionDTO)
+		if err != nil {
+			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to add subscription")
+			return
+		}
+
+		common.WriteJSON(w, http.StatusCreated, map[string]dto.SubscriptionDTO{"subscription": *s})
+	}
+}
+
+// List - GET /subscriptions/list
+func List(db *sql.DB) http.HandlerFunc {
+	repo := NewSubscriptionRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		list, err := repo.List(r.Context())
+		if err != nil {
+			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to list subscriptions")
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, map[string][]dto.SubscriptionDTO{"subscriptions": list})
+	}
+}
+
+// Delete - POST /subscriptions/delete
+func Delete(db *sql.DB) http.HandlerFunc {
+	repo := NewSubscriptionRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		var req dto.DeleteSubscriptionRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		if err := repo.Delete(r.Context(), req.ID); err != nil {
+			if err.Error() == dto.ErrorCodeSubscriptionNotFound {
+				common.WriteError(w, http.StatusNotFound, dto.ErrorCodeSubscriptionNotFound, "subscription not found")
+				return
+			}
+			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to delete subscription")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Deliveries - GET /subscriptions/deliveries?subscription_id=...&limit=...
+func Deliveries(db *sql.DB) http.HandlerFunc {
+	repo := NewSubscriptionRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("subscription_id"), 10, 64)
+		if err != nil || id <= 0 {
+			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "subscription_id is required")
+			return
+		}
+		writeDeliveries(w, r, repo, id)
+	}
+}
+
+// writeDeliveries отдает историю доставок подписки id с учетом параметра limit.
+func writeDeliveries(w http.ResponseWriter, r *http.Request, repo *SubscriptionRepository, id int64) {
+	limit := defaultDeliveriesLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxDeliveriesLimit {
+			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "limit must be between 1 and 500")
+			return
+		}
+	}
+
+	list, err := repo.ListDeliveries(r.Context(), id, limit)
+	if err != nil {
+		if err.Error() == dto.ErrorCodeSubscriptionNotFound {
+			common.WriteError(w, http.StatusNotFound, dto.ErrorCodeSubscriptionNotFound, "subscription not found")
+			return
+		}
+		common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to list deliveries")
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"subscription_id": id,
+		"deliveries":      list,
+	})
+}
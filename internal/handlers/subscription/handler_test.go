@@ -0,0 +1,32 @@
+package subscription
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+)
+
+func TestAddRejectedWhenOutboxDisabled(t *testing.T) {
+	body := `{"url":"https://example.com/hook","secret":"s","event_types":["pr.created"]}`
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions/add", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	// БД не нужна: запрос отклоняется до обращения к репозиторию
+	Add(nil, false).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	var resp common.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error.Code != dto.ErrorCodeOutboxDisabled {
+		t.Errorf("code = %s, want %s", resp.Error.Code, dto.ErrorCodeOutboxDisabled)
+	}
+}
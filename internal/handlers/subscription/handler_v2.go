@@ -0,0 +1,47 @@
+package subscription
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"database/sql"
+	"net/http"
+	"strconv"
+)
+
+// POST /v2/subscriptions и GET /v2/subscriptions обслуживают Add и List.
+
+// DeleteV2 - DELETE /v2/subscriptions/{id}
+func DeleteV2(db *sql.DB) http.HandlerFunc {
+	repo := NewSubscriptionRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.DeleteSubscriptionRequest
+		req.ID, _ = strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if !common.Validate(w, req) {
+			return
+		}
+		if err := repo.Delete(r.Context(), req.ID); err != nil {
+			if err.Error() == dto.ErrorCodeSubscriptionNotFound {
+				common.WriteError(w, http.StatusNotFound, dto.ErrorCodeSubscriptionNotFound, "subscription not found")
+				return
+			}
+			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to delete subscription")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeliveriesV2 - GET /v2/subscriptions/{id}/deliveries?limit=...
+func DeliveriesV2(db *sql.DB) http.HandlerFunc {
+	repo := NewSubscriptionRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id <= 0 {
+			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "subscription id must be a positive integer")
+			return
+		}
+		writeDeliveries(w, r, repo, id)
+	}
+}
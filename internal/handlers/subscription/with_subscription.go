@@ -0,0 +1,128 @@
+package subscription
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type SubscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+const insertSubscriptionSQL = `
+INSERT INTO webhook_subscription(url, secret, event_types) VALUES ($1, $2, $3)
+RETURNING id, created_at;
+`
+
+const selectSubscriptionsSQL = `
+SELECT id, url, event_types, created_at FROM webhook_subscription ORDER BY id;
+`
+
+const deleteSubscriptionSQL = `
+DELETE FROM webhook_subscription WHERE id = $1;
+`
+
+const existsSubscriptionSQL = `
+SELECT EXISTS (SELECT 1 FROM webhook_subscription WHERE id = $1);
+`
+
+const selectDeliveriesSQL = `
+SELECT id, subscription_id, event_id, event_type, status, attempts, response_status,
+       COALESCE(last_error, ''), created_at, next_attempt_at, delivered_at
+FROM webhook_subscription_delivery
+WHERE subscription_id = $1
+ORDER BY id DESC
+LIMIT $2;
+`
+
+func (r *SubscriptionRepository) Add(ctx context.Context, s dto.SubscriptionDTO) (*dto.SubscriptionDTO, error) {
+	err := r.db.QueryRowContext(ctx, insertSubscriptionSQL, s.URL, s.Secret, pq.Array(s.EventTypes)).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	s.Secret = ""
+	return &s, nil
+}
+
+func (r *SubscriptionRepository) List(ctx context.Context) ([]dto.SubscriptionDTO, error) {
+	rows, err := r.db.QueryContext(ctx, selectSubscriptionsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]dto.SubscriptionDTO, 0)
+	for rows.Next() {
+		var s dto.SubscriptionDTO
+		if err := rows.Scan(&s.ID, &s.URL, pq.Array(&s.EventTypes), &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+func (r *SubscriptionRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, deleteSubscriptionSQL, id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New(dto.ErrorCodeSubscriptionNotFound)
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]dto.SubscriptionDeliveryDTO, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, existsSubscriptionSQL, subscriptionID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New(dto.ErrorCodeSubscriptionNotFound)
+	}
+
+	rows, err := r.db.QueryContext(ctx, selectDeliveriesSQL, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]dto.SubscriptionDeliveryDTO, 0)
+	for rows.Next() {
+		var d dto.SubscriptionDeliveryDTO
+		var respStatus sql.NullInt64
+		var nextAttemptAt time.Time
+		var deliveredAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+			&respStatus, &d.LastError, &d.CreatedAt, &nextAttemptAt, &deliveredAt); err != nil {
+			return nil, err
+		}
+		if respStatus.Valid {
+			v := int(respStatus.Int64)
+			d.ResponseStatus = &v
+		}
+		if d.Status == dto.DeliveryStatusPending {
+			d.NextAttemptAt = &nextAttemptAt
+		}
+		if deliveredAt.Valid {
+			t := deliveredAt.Time
+			d.DeliveredAt = &t
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
@@ -1,10 +1,10 @@
 package team
 
 import (
+	"AvitoInternship/internal/codeowners"
 	"AvitoInternship/internal/handlers/common"
 	"AvitoInternship/internal/handlers/dto"
 	"database/sql"
-	"encoding/json"
 	"net/http"
 )
 
@@ -18,13 +18,7 @@ func AddTeam(db *sql.DB) http.HandlerFunc {
 		}
 
 		var req dto.TeamDTO
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			common.WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
-			return
-		}
-
-		if req.TeamName == "" {
-			common.WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name is required")
+		if !common.DecodeJSON(w, r, &req) {
 			return
 		}
 
@@ -45,6 +39,9 @@ func AddTeam(db *sql.DB) http.HandlerFunc {
 }
 
 // GetTeam - GET /team/get?team_name=...
+//
+// Участников можно фильтровать (active_only) и листать (sort, limit, cursor);
+// без limit и cursor возвращаются все.
 func GetTeam(db *sql.DB) http.HandlerFunc {
 	repo := NewTeamRepository(db)
 
@@ -60,8 +57,15 @@ func GetTeam(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		var v dto.Validator
+		filter := common.ParseUserFilter(r.URL.Query(), 0, &v)
+		if err := v.Err(); err != nil {
+			common.WriteValidationError(w, err)
+			return
+		}
+
 		ctx := r.Context()
-		team, err := repo.GetTeam(ctx, teamName)
+		team, err := repo.GetTeamPage(ctx, teamName, filter)
 		if err != nil {
 			if err.Error() == dto.TeamNotFoundError {
 				common.WriteError(w, http.StatusNotFound, dto.ErrorCodeNotFound, "resource not found")
@@ -74,3 +78,81 @@ func GetTeam(db *sql.DB) http.HandlerFunc {
 		common.WriteJSON(w, http.StatusOK, team)
 	}
 }
+
+// SetSettings - POST /team/setSettings
+func SetSettings(db *sql.DB) http.HandlerFunc {
+	repo := NewTeamRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.TeamSettingsRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		team, err := repo.UpdateSettings(r.Context(), req)
+		if err != nil {
+			if err.Error() == dto.TeamNotFoundError {
+				common.WriteError(w, http.StatusNotFound, dto.ErrorCodeNotFound, "resource not found")
+				return
+			}
+			common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to update team settings")
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, map[string]dto.TeamDTO{"team": *team})
+	}
+}
+
+// Codeowners - GET /team/codeowners?repository=..., POST /team/codeowners
+func Codeowners(db *sql.DB) http.HandlerFunc {
+	repo := NewTeamRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			repository := r.URL.Query().Get("repository")
+			if repository == "" {
+				common.WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "repository is required")
+				return
+			}
+			c, err := repo.GetCodeowners(r.Context(), repository)
+			if err != nil {
+				if err.Error() == dto.ErrorCodeNotFound {
+					common.WriteError(w, http.StatusNotFound, dto.ErrorCodeNotFound, "resource not found")
+					return
+				}
+				common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get codeowners")
+				return
+			}
+			common.WriteJSON(w, http.StatusOK, map[string]dto.CodeownersDTO{"codeowners": *c})
+
+		case http.MethodPost:
+			var req dto.CodeownersDTO
+			if !common.DecodeJSON(w, r, &req) {
+				return
+			}
+			if _, err := codeowners.Parse(req.Content); err != nil {
+				common.WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid CODEOWNERS: "+err.Error())
+				return
+			}
+			c, err := repo.SetCodeowners(r.Context(), req)
+			if err != nil {
+				if err.Error() == dto.TeamNotFoundError {
+					common.WriteError(w, http.StatusNotFound, dto.ErrorCodeNotFound, "resource not found")
+					return
+				}
+				common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to save codeowners")
+				return
+			}
+			common.WriteJSON(w, http.StatusOK, map[string]dto.CodeownersDTO{"codeowners": *c})
+
+		default:
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+		}
+	}
+}
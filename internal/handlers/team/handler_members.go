@@ -0,0 +1,163 @@
+package team
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"database/sql"
+	"net/http"
+)
+
+// Rename - POST /team/rename
+func Rename(db *sql.DB) http.HandlerFunc {
+	repo := NewTeamRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.RenameTeamRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		team, err := repo.RenameTeam(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to rename team")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]dto.TeamDTO{"team": *team})
+	}
+}
+
+// Delete - POST /team/delete
+//
+// Если на команду или ее участников ссылаются OPEN PR, возвращает 409
+// TEAM_HAS_OPEN_PRS со списком PR; с force=true команда удаляется, а ревью
+// бывших участников в OPEN PR других команд переназначаются.
+func Delete(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewMembershipService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.DeleteTeamRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		resp, err := svc.DeleteTeam(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to delete team")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// AddMember - POST /team/members/add
+//
+// Создает пользователя или добавляет в команду пользователя без команды;
+// участника другой команды не переводит (409 MEMBER_OF_OTHER_TEAM).
+func AddMember(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewMembershipService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.AddTeamMemberRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		resp, err := svc.AddMember(r.Context(), req, false)
+		if err != nil {
+			writeTeamError(w, err, "failed to add team member")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// RemoveMember - POST /team/members/remove
+func RemoveMember(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewMembershipService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.RemoveTeamMemberRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		resp, err := svc.RemoveMember(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to remove team member")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// MoveMember - POST /team/members/move
+func MoveMember(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewMembershipService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.MoveTeamMemberRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		resp, err := svc.MoveMember(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to move team member")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// Sync - PUT /team/sync
+//
+// Идемпотентная синхронизация состава: повторный вызов с тем же списком ничего
+// не меняет. С dry_run=true только возвращает diff.
+func Sync(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewMembershipService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.TeamSyncRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		resp, err := svc.SyncTeam(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to sync team")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,246 @@
+package team
+
+import (
+	"AvitoInternship/internal/codeowners"
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// Обработчики /v2: те же операции, что и в v1, но ресурс задается путем, а
+// метод проверяет роутер.
+
+// CreateV2 - POST /v2/teams
+func CreateV2(db *sql.DB) http.HandlerFunc {
+	repo := NewTeamRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.TeamDTO
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+		team, err := repo.AddTeam(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to add team")
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, map[string]dto.TeamDTO{"team": *team})
+	}
+}
+
+// GetV2 - GET /v2/teams/{name}?active_only=...&sort=...&limit=...&cursor=...
+func GetV2(db *sql.DB) http.HandlerFunc {
+	repo := NewTeamRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var v dto.Validator
+		filter := common.ParseUserFilter(r.URL.Query(), 0, &v)
+		if err := v.Err(); err != nil {
+			common.WriteValidationError(w, err)
+			return
+		}
+		team, err := repo.GetTeamPage(r.Context(), r.PathValue("name"), filter)
+		if err != nil {
+			writeTeamError(w, err, "failed to get team")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]dto.TeamDTO{"team": *team})
+	}
+}
+
+// UpdateV2 - PATCH /v2/teams/{name}, тело - TeamSettingsRequest без team_name
+func UpdateV2(db *sql.DB) http.HandlerFunc {
+	repo := NewTeamRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.TeamSettingsRequest
+		if !common.Decode(w, r, &req) {
+			return
+		}
+		req.TeamName = r.PathValue("name")
+		if !common.Validate(w, req) {
+			return
+		}
+		team, err := repo.UpdateSettings(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to update team settings")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]dto.TeamDTO{"team": *team})
+	}
+}
+
+// DeleteV2 - DELETE /v2/teams/{name}?force=true
+func DeleteV2(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewMembershipService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := dto.DeleteTeamRequest{TeamName: r.PathValue("name")}
+		if s := r.URL.Query().Get("force"); s != "" {
+			force, err := strconv.ParseBool(s)
+			if err != nil {
+				common.WriteValidationError(w, &dto.ValidationError{Fields: []dto.FieldErrorDTO{{Field: "force", Message: "must be true or false"}}})
+				return
+			}
+			req.Force = force
+		}
+		if !common.Validate(w, req) {
+			return
+		}
+		resp, err := svc.DeleteTeam(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to delete team")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// RenameV2 - POST /v2/teams/{name}/rename, тело - {new_team_name}
+func RenameV2(db *sql.DB) http.HandlerFunc {
+	repo := NewTeamRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.RenameTeamRequest
+		if !common.Decode(w, r, &req) {
+			return
+		}
+		req.TeamName = r.PathValue("name")
+		if !common.Validate(w, req) {
+			return
+		}
+		team, err := repo.RenameTeam(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to rename team")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]dto.TeamDTO{"team": *team})
+	}
+}
+
+// SyncV2 - PUT /v2/teams/{name}/members, тело - TeamSyncRequest без team_name
+func SyncV2(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewMembershipService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.TeamSyncRequest
+		if !common.Decode(w, r, &req) {
+			return
+		}
+		req.TeamName = r.PathValue("name")
+		if !common.Validate(w, req) {
+			return
+		}
+		resp, err := svc.SyncTeam(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to sync team")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// PutMemberV2 - PUT /v2/teams/{name}/members/{user_id}, тело - {username, is_active}
+//
+// В отличие от /team/members/add участника другой команды переводит.
+func PutMemberV2(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewMembershipService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.AddTeamMemberRequest
+		if !common.Decode(w, r, &req) {
+			return
+		}
+		req.TeamName = r.PathValue("name")
+		req.UserID = r.PathValue("user_id")
+		if !common.Validate(w, req) {
+			return
+		}
+		resp, err := svc.AddMember(r.Context(), req, true)
+		if err != nil {
+			writeTeamError(w, err, "failed to add team member")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// DeleteMemberV2 - DELETE /v2/teams/{name}/members/{user_id}
+func DeleteMemberV2(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewMembershipService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := dto.RemoveTeamMemberRequest{TeamName: r.PathValue("name"), UserID: r.PathValue("user_id")}
+		if !common.Validate(w, req) {
+			return
+		}
+		resp, err := svc.RemoveMember(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to remove team member")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// GetCodeownersV2 - GET /v2/codeowners/{repository...}
+func GetCodeownersV2(db *sql.DB) http.HandlerFunc {
+	repo := NewTeamRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := repo.GetCodeowners(r.Context(), r.PathValue("repository"))
+		if err != nil {
+			writeTeamError(w, err, "failed to get codeowners")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]dto.CodeownersDTO{"codeowners": *c})
+	}
+}
+
+// PutCodeownersV2 - PUT /v2/codeowners/{repository...}, тело - {team_name, content}
+func PutCodeownersV2(db *sql.DB) http.HandlerFunc {
+	repo := NewTeamRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.CodeownersDTO
+		if !common.Decode(w, r, &req) {
+			return
+		}
+		req.Repository = r.PathValue("repository")
+		if !common.Validate(w, req) {
+			return
+		}
+		if _, err := codeowners.Parse(req.Content); err != nil {
+			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "invalid CODEOWNERS: "+err.Error())
+			return
+		}
+		c, err := repo.SetCodeowners(r.Context(), req)
+		if err != nil {
+			writeTeamError(w, err, "failed to save codeowners")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]dto.CodeownersDTO{"codeowners": *c})
+	}
+}
+
+func writeTeamError(w http.ResponseWriter, err error, message string) {
+	var openPRs *dto.TeamHasOpenPRsError
+	if errors.As(err, &openPRs) {
+		common.WriteErrorDetails(w, http.StatusConflict, dto.ErrorCodeTeamHasOpenPRs, "team has open pull requests", openPRs.PullRequestIDs)
+		return
+	}
+	switch err.Error() {
+	case dto.TeamExistsError:
+		common.WriteError(w, http.StatusConflict, dto.ErrorCodeTeamExists, "team_name already exists")
+	case dto.TeamNotFoundError, dto.ErrorCodeNotFound:
+		common.WriteError(w, http.StatusNotFound, dto.ErrorCodeNotFound, "resource not found")
+	case dto.ErrorCodeMemberOfOtherTeam:
+		common.WriteError(w, http.StatusConflict, dto.ErrorCodeMemberOfOtherTeam, "user is a member of another team")
+	default:
+		common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, message)
+	}
+}
@@ -0,0 +1,179 @@
+package team
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"context"
+	"database/sql"
+)
+
+// MembershipService меняет состав команд и переназначает OPEN-ревью
+// пользователей, которые из команды ушли. Изменение состава и переназначения
+// выполняются одной транзакцией: ушедший участник уже не может стать
+// кандидатом, а сбой переназначения откатывает и изменение состава.
+// Уведомления о заменах отправляются после фиксации.
+type MembershipService struct {
+	repo *TeamRepository
+	prs  *pullRequest.PullRequestService
+}
+
+func NewMembershipService(db *sql.DB, listener pullRequest.AssignmentListener) *MembershipService {
+	return &MembershipService{repo: NewTeamRepository(db), prs: pullRequest.NewService(db, listener)}
+}
+
+// userReassignments - переназначения ревью одного пользователя в транзакции
+type userReassignments struct {
+	userID  string
+	results []pullRequest.ReassignResult
+}
+
+// AddMember добавляет пользователя в команду. При allowMove участник другой
+// команды переводится, и его ревью в PR прежней команды переназначаются.
+func (s *MembershipService) AddMember(ctx context.Context, req dto.AddTeamMemberRequest, allowMove bool) (*dto.TeamMembershipResponse, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	prevTeamID, teamID, err := s.repo.AttachMember(ctx, tx, req, allowMove)
+	if err != nil {
+		return nil, err
+	}
+	var batches []userReassignments
+	if prevTeamID != 0 && prevTeamID != teamID {
+		results, err := s.prs.ReassignTeamReviewsTx(ctx, tx, req.UserID, prevTeamID)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, userReassignments{userID: req.UserID, results: results})
+	}
+	reassigned, err := s.commit(tx, batches)
+	if err != nil {
+		return nil, err
+	}
+	return s.membership(ctx, req.TeamName, reassigned)
+}
+
+// MoveMember переводит существующего пользователя в команду req.TeamName.
+func (s *MembershipService) MoveMember(ctx context.Context, req dto.MoveTeamMemberRequest) (*dto.TeamMembershipResponse, error) {
+	return s.AddMember(ctx, dto.AddTeamMemberRequest{TeamName: req.TeamName, UserID: req.UserID}, true)
+}
+
+// RemoveMember оставляет пользователя без команды и переназначает все его
+// OPEN-ревью.
+func (s *MembershipService) RemoveMember(ctx context.Context, req dto.RemoveTeamMemberRequest) (*dto.TeamMembershipResponse, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.repo.RemoveMember(ctx, tx, req); err != nil {
+		return nil, err
+	}
+	results, err := s.prs.ReassignOpenReviewsTx(ctx, tx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	reassigned, err := s.commit(tx, []userReassignments{{userID: req.UserID, results: results}})
+	if err != nil {
+		return nil, err
+	}
+	return s.membership(ctx, req.TeamName, reassigned)
+}
+
+// DeleteTeam удаляет команду. При force ревью бывших участников в OPEN PR
+// других команд переназначаются до удаления, пока кандидаты берутся из
+// команды-владельца PR. Ревью в PR самой команды остаются за прежними
+// ревьюерами: замену брать не из кого.
+func (s *MembershipService) DeleteTeam(ctx context.Context, req dto.DeleteTeamRequest) (*dto.DeleteTeamResponse, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	teamID, members, err := s.repo.LockTeamForDelete(ctx, tx, req.TeamName, req.Force)
+	if err != nil {
+		return nil, err
+	}
+	batches := make([]userReassignments, 0, len(members))
+	for _, userID := range members {
+		results, err := s.prs.ReassignOtherTeamsReviewsTx(ctx, tx, userID, teamID)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, userReassignments{userID: userID, results: results})
+	}
+	if err := s.repo.DeleteTeam(ctx, tx, teamID); err != nil {
+		return nil, err
+	}
+	reassigned, err := s.commit(tx, batches)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.DeleteTeamResponse{TeamName: req.TeamName, Members: members, Reassigned: reassigned}, nil
+}
+
+// SyncTeam приводит состав команды к списку из запроса. В той же транзакции
+// переназначаются OPEN-ревью удаленных участников и ревью перешедших из других
+// команд в PR прежних команд; деактивированные участники остаются ревьюерами,
+// как и при /users/setIsActive. При DryRun транзакция откатывается.
+func (s *MembershipService) SyncTeam(ctx context.Context, req dto.TeamSyncRequest) (*dto.TeamSyncResponse, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	resp, moves, err := s.repo.SyncTeam(ctx, tx, req)
+	if err != nil || req.DryRun {
+		return resp, err
+	}
+	batches := make([]userReassignments, 0, len(resp.Removed)+len(moves))
+	for _, m := range resp.Removed {
+		results, err := s.prs.ReassignOpenReviewsTx(ctx, tx, m.UserID)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, userReassignments{userID: m.UserID, results: results})
+	}
+	for _, m := range moves {
+		results, err := s.prs.ReassignTeamReviewsTx(ctx, tx, m.userID, m.prevTeamID)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, userReassignments{userID: m.userID, results: results})
+	}
+	if resp.Reassigned, err = s.commit(tx, batches); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *MembershipService) begin(ctx context.Context) (*sql.Tx, error) {
+	return s.repo.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+}
+
+// commit фиксирует tx, отправляет уведомления о заменах и возвращает
+// переназначения для ответа.
+func (s *MembershipService) commit(tx *sql.Tx, batches []userReassignments) ([]dto.ReassignmentDTO, error) {
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	reassigned := make([]dto.ReassignmentDTO, 0)
+	for _, b := range batches {
+		s.prs.NotifyReassigned(b.userID, b.results)
+		reassigned = pullRequest.AppendReassignments(reassigned, b.userID, b.results)
+	}
+	return reassigned, nil
+}
+
+func (s *MembershipService) membership(ctx context.Context, teamName string, reassigned []dto.ReassignmentDTO) (*dto.TeamMembershipResponse, error) {
+	team, err := s.repo.GetTeam(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TeamMembershipResponse{Team: *team, Reassigned: reassigned}, nil
+}
@@ -0,0 +1,282 @@
+package team
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"AvitoInternship/internal/handlers/dto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type replacement struct{ prID, oldID, newID string }
+
+type recordingListener struct{ replaced []replacement }
+
+func (l *recordingListener) ReviewersAssigned(string, []string) {}
+
+func (l *recordingListener) ReviewerReplaced(prID, oldReviewerID, newReviewerID string) {
+	l.replaced = append(l.replaced, replacement{prID, oldReviewerID, newReviewerID})
+}
+
+func rows(columns []string, values ...[]driver.Value) *sqlmock.Rows {
+	r := sqlmock.NewRows(columns)
+	for _, v := range values {
+		r.AddRow(v...)
+	}
+	return r
+}
+
+// expectLockPR ожидает чтение PR под блокировкой в начале переназначения.
+func expectLockPR(mock sqlmock.Sqlmock, prID string, teamID int64, reviewers ...string) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author_id, status, team_id FROM pull_request WHERE id = $1 FOR UPDATE`)).
+		WithArgs(prID).WillReturnRows(rows([]string{"id", "title", "author_id", "status", "team_id"}, []driver.Value{prID, "Fix", "a1", "OPEN", teamID}))
+	r := sqlmock.NewRows([]string{"user_id"})
+	for _, id := range reviewers {
+		r.AddRow(id)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id FROM pull_request_reviewer WHERE pr_id = $1 FOR UPDATE`)).
+		WithArgs(prID).WillReturnRows(r)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT tag FROM pull_request_tag WHERE pr_id = $1`)).
+		WithArgs(prID).WillReturnRows(sqlmock.NewRows([]string{"tag"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, COALESCE(team_id, 0), is_active FROM "user" WHERE id = $1`)).
+		WithArgs(reviewers[0]).WillReturnRows(rows([]string{"id", "name", "team_id", "is_active"}, []driver.Value{reviewers[0], "Ivan", 7, true}))
+}
+
+func expectCandidates(mock sqlmock.Sqlmock, ids ...string) {
+	r := sqlmock.NewRows([]string{"id", "is_active", "unavailable", "skills", "open_reviews", "time_zone", "work_start", "work_end", "max_open_reviews"})
+	for _, id := range ids {
+		r.AddRow(id, true, false, "{}", 0, "UTC", "", "", 0)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`ORDER BY random()`)).WillReturnRows(r)
+}
+
+func TestDeleteTeamForceReassignsOtherTeamsReviewsInTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	listener := &recordingListener{}
+	svc := NewMembershipService(db, listener)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockTeamSQL)).WithArgs("backend").
+		WillReturnRows(rows([]string{"id"}, []driver.Value{7}))
+	mock.ExpectQuery(regexp.QuoteMeta(lockTeamMembersSQL)).WithArgs(7).
+		WillReturnRows(rows([]string{"id"}, []driver.Value{"u1"}))
+	// ревью в PR самой команды не переназначаются: в выборку входят только PR других команд
+	mock.ExpectQuery(regexp.QuoteMeta(`AND pr.team_id <> $2`)).WithArgs("u1", 7).
+		WillReturnRows(rows([]string{"id"}, []driver.Value{"pr-1"}, []driver.Value{"pr-2"}))
+
+	expectLockPR(mock, "pr-1", 9, "u1")
+	expectCandidates(mock, "u3")
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM pull_request_reviewer WHERE pr_id = $1 AND user_id = $2`)).
+		WithArgs("pr-1", "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO pull_request_reviewer(pr_id, user_id)`)).
+		WithArgs("pr-1", "u3").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE pull_request SET updated_at = NOW()`)).
+		WithArgs("pr-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id FROM pull_request_reviewer WHERE pr_id = $1 ORDER BY user_id`)).
+		WithArgs("pr-1").WillReturnRows(rows([]string{"user_id"}, []driver.Value{"u3"}))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_event")).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// замены нет: ошибка попадает в ответ и не прерывает удаление
+	expectLockPR(mock, "pr-2", 9, "u1", "a2")
+	expectCandidates(mock)
+
+	for _, query := range []string{detachTeamMembersSQL, detachTeamPullRequestsSQL, deleteTeamCodeownersSQL, deleteTeamSQL} {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	resp, err := svc.DeleteTeam(context.Background(), dto.DeleteTeamRequest{TeamName: "backend", Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []dto.ReassignmentDTO{
+		{UserID: "u1", PullRequestID: "pr-1", ReplacedBy: "u3"},
+		{UserID: "u1", PullRequestID: "pr-2", Error: dto.ErrorCodeNoCandidate},
+	}
+	if !reflect.DeepEqual(resp.Members, []string{"u1"}) || !reflect.DeepEqual(resp.Reassigned, want) {
+		t.Errorf("resp = %+v", resp)
+	}
+	if want := []replacement{{"pr-1", "u1", "u3"}}; !reflect.DeepEqual(listener.replaced, want) {
+		t.Errorf("notified %v, want %v", listener.replaced, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteTeamWithoutForceRejectsOpenPRs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	svc := NewMembershipService(db, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockTeamSQL)).WithArgs("backend").
+		WillReturnRows(rows([]string{"id"}, []driver.Value{7}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT pr.id`)).WithArgs(7).
+		WillReturnRows(rows([]string{"id"}, []driver.Value{"pr-1"}))
+	mock.ExpectRollback()
+
+	_, err = svc.DeleteTeam(context.Background(), dto.DeleteTeamRequest{TeamName: "backend"})
+	var openErr *dto.TeamHasOpenPRsError
+	if !errors.As(err, &openErr) || !reflect.DeepEqual(openErr.PullRequestIDs, []string{"pr-1"}) {
+		t.Fatalf("err = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRemoveMemberRollsBackOnReassignFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	listener := &recordingListener{}
+	svc := NewMembershipService(db, listener)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(shareTeamSQL)).WithArgs("backend").
+		WillReturnRows(rows([]string{"id"}, []driver.Value{7}))
+	mock.ExpectExec(regexp.QuoteMeta(removeMemberSQL)).WithArgs("u1", 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE prr.user_id = $1 AND pr.status = 'OPEN'`)).WithArgs("u1").
+		WillReturnRows(rows([]string{"id"}, []driver.Value{"pr-1"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM pull_request WHERE id = $1 FOR UPDATE`)).WithArgs("pr-1").
+		WillReturnError(errors.New("deadlock detected"))
+	// без Commit: участник остается в команде
+	mock.ExpectRollback()
+
+	if _, err := svc.RemoveMember(context.Background(), dto.RemoveTeamMemberRequest{TeamName: "backend", UserID: "u1"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(listener.replaced) != 0 {
+		t.Errorf("notified %v", listener.replaced)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+var syncUserColumns = []string{"id", "name", "is_active", "team_id", "team_name"}
+
+func syncRequest(t *testing.T, body string) dto.TeamSyncRequest {
+	t.Helper()
+	var req dto.TeamSyncRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestSyncTeamIsIdempotent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	svc := NewMembershipService(db, nil)
+
+	// состав уже совпадает с запросом: ни одной записи, только фиксация
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockTeamSQL)).WithArgs("backend").
+		WillReturnRows(rows([]string{"id"}, []driver.Value{7}))
+	mock.ExpectQuery(regexp.QuoteMeta(selectSyncUsersSQL)).WillReturnRows(rows(syncUserColumns,
+		[]driver.Value{"u1", "Ivan", true, 7, "backend"},
+		[]driver.Value{"u2", "Petr", false, 7, "backend"}))
+	mock.ExpectCommit()
+
+	// у u1 is_active не указан и по умолчанию true
+	req := syncRequest(t, `{"team_name":"backend","members":[{"user_id":"u1","username":"Ivan"},{"user_id":"u2","username":"Petr","is_active":false}]}`)
+	resp, err := svc.SyncTeam(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Unchanged != 2 || len(resp.Added)+len(resp.Updated)+len(resp.Deactivated)+len(resp.Removed)+len(resp.Reassigned) != 0 {
+		t.Errorf("resp = %+v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSyncTeamDryRunWritesNothing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	svc := NewMembershipService(db, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lockTeamSQL)).WithArgs("backend").
+		WillReturnRows(rows([]string{"id"}, []driver.Value{7}))
+	mock.ExpectQuery(regexp.QuoteMeta(selectSyncUsersSQL)).WillReturnRows(rows(syncUserColumns,
+		[]driver.Value{"u1", "Ivan", false, 7, "backend"},
+		[]driver.Value{"u2", "Petr", true, 7, "backend"},
+		[]driver.Value{"u3", "Olga", true, 9, "frontend"}))
+	// без Commit и без записей: изменения только считаются
+	mock.ExpectRollback()
+
+	req := syncRequest(t, `{"team_name":"backend","missing":"remove","dry_run":true,
+		"members":[{"user_id":"u1","username":"Ivan"},{"user_id":"u3","username":"Olga"},{"user_id":"u4","username":"Anna"}]}`)
+	resp, err := svc.SyncTeam(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &dto.TeamSyncResponse{
+		TeamName: "backend",
+		DryRun:   true,
+		Added: []dto.TeamMemberChangeDTO{
+			{UserID: "u3", Username: "Olga", IsActive: true, PreviousTeam: "frontend"},
+			{UserID: "u4", Username: "Anna", IsActive: true},
+		},
+		Updated:     []dto.TeamMemberChangeDTO{{UserID: "u1", Username: "Ivan", IsActive: true, Fields: []string{"is_active"}}},
+		Deactivated: []dto.TeamMemberChangeDTO{},
+		Removed:     []dto.TeamMemberChangeDTO{{UserID: "u2", Username: "Petr", IsActive: true}},
+		Reassigned:  []dto.ReassignmentDTO{},
+	}
+	if !reflect.DeepEqual(resp, want) {
+		t.Errorf("resp = %+v\nwant %+v", resp, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddTeamRestoresDeletedUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(insertTeamSQL)).WithArgs("backend").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	// u1 - надгробие удаленного пользователя: upsert снимает deleted_at
+	mock.ExpectExec(regexp.QuoteMeta("team_id = EXCLUDED.team_id, deleted_at = NULL")).
+		WithArgs("u1", "Ivan", true, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	team := dto.TeamDTO{TeamName: "backend", Members: []dto.TeamMemberDTO{{UserID: "u1", Username: "Ivan", IsActive: true}}}
+	if _, err := NewTeamRepository(db).AddTeam(context.Background(), team); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,216 @@
+package team
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const renameTeamSQL = `
+UPDATE team SET name = $2 WHERE name = $1;
+`
+
+const lockTeamSQL = `
+SELECT id FROM team WHERE name = $1 FOR UPDATE;
+`
+
+const shareTeamSQL = `
+SELECT id FROM team WHERE name = $1 FOR SHARE;
+`
+
+// OPEN PR команды, ее участников как авторов и PR, где участники - ревьюеры
+const selectTeamOpenPRsSQL = `
+SELECT pr.id
+FROM pull_request pr
+LEFT JOIN "user" author ON author.id = pr.author_id
+WHERE pr.status = 'OPEN'
+  AND (COALESCE(pr.team_id, author.team_id) = $1
+       OR author.team_id = $1
+       OR EXISTS (SELECT 1
+                    FROM pull_request_reviewer prr
+                    JOIN "user" u ON u.id = prr.user_id
+                   WHERE prr.pr_id = pr.id AND u.team_id = $1))
+ORDER BY pr.id;
+`
+
+const lockTeamMembersSQL = `
+SELECT id FROM "user" WHERE team_id = $1 ORDER BY id FOR UPDATE;
+`
+
+const detachTeamMembersSQL = `
+UPDATE "user" SET team_id = NULL WHERE team_id = $1;
+`
+
+const detachTeamPullRequestsSQL = `
+UPDATE pull_request SET team_id = NULL WHERE team_id = $1;
+`
+
+const deleteTeamCodeownersSQL = `
+DELETE FROM codeowners WHERE team_id = $1;
+`
+
+const deleteTeamSQL = `
+DELETE FROM team WHERE id = $1;
+`
+
+const selectUserTeamForUpdateSQL = `
+SELECT COALESCE(team_id, 0) FROM "user" WHERE id = $1 FOR UPDATE;
+`
+
+const insertMemberSQL = `
+INSERT INTO "user"(id, name, is_active, team_id) VALUES ($1, $2, COALESCE($3, TRUE), $4);
+`
+
+// удаленный пользователь (надгробие) восстанавливается активным, как новый
+const updateMemberSQL = `
+UPDATE "user"
+SET name = COALESCE(NULLIF($2, ''), name),
+    is_active = COALESCE($3, is_active OR deleted_at IS NOT NULL),
+    team_id = $4,
+    deleted_at = NULL
+WHERE id = $1;
+`
+
+const removeMemberSQL = `
+UPDATE "user" SET team_id = NULL WHERE id = $1 AND team_id = $2;
+`
+
+func (r *TeamRepository) RenameTeam(ctx context.Context, req dto.RenameTeamRequest) (*dto.TeamDTO, error) {
+	res, err := r.db.ExecContext(ctx, renameTeamSQL, req.TeamName, req.NewTeamName)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+			return nil, errors.New(dto.TeamExistsError)
+		}
+		return nil, err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, errors.New(dto.TeamNotFoundError)
+	}
+	return r.GetTeam(ctx, req.NewTeamName)
+}
+
+// LockTeamForDelete блокирует команду и ее участников перед удалением и
+// возвращает id команды и участников. Если есть OPEN PR команды или
+// участников, без force возвращается *dto.TeamHasOpenPRsError.
+func (r *TeamRepository) LockTeamForDelete(ctx context.Context, tx *sql.Tx, teamName string, force bool) (int, []string, error) {
+	var teamID int
+	if err := tx.QueryRowContext(ctx, lockTeamSQL, teamName).Scan(&teamID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, errors.New(dto.TeamNotFoundError)
+		}
+		return 0, nil, err
+	}
+
+	if !force {
+		openPRs, err := queryIDs(ctx, tx, selectTeamOpenPRsSQL, teamID)
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(openPRs) > 0 {
+			return 0, nil, &dto.TeamHasOpenPRsError{PullRequestIDs: openPRs}
+		}
+	}
+
+	members, err := queryIDs(ctx, tx, lockTeamMembersSQL, teamID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return teamID, members, nil
+}
+
+// DeleteTeam удаляет команду: участники остаются без команды, PR команды - без
+// команды-владельца, CODEOWNERS команды удаляются.
+func (r *TeamRepository) DeleteTeam(ctx context.Context, tx *sql.Tx, teamID int) error {
+	for _, query := range []string{detachTeamMembersSQL, detachTeamPullRequestsSQL, deleteTeamCodeownersSQL, deleteTeamSQL} {
+		if _, err := tx.ExecContext(ctx, query, teamID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttachMember добавляет пользователя в команду, создавая его при
+// необходимости, и возвращает id прежней и новой команды (0 - пользователь был
+// без команды). Участника другой команды переводит только при allowMove, иначе
+// возвращает MEMBER_OF_OTHER_TEAM. Пустой Username не меняет имя, но
+// несуществующего пользователя без имени не создает.
+func (r *TeamRepository) AttachMember(ctx context.Context, tx *sql.Tx, req dto.AddTeamMemberRequest, allowMove bool) (int, int, error) {
+	var teamID int
+	if err := tx.QueryRowContext(ctx, shareTeamSQL, req.TeamName).Scan(&teamID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, errors.New(dto.TeamNotFoundError)
+		}
+		return 0, 0, err
+	}
+
+	var prevTeamID int
+	err := tx.QueryRowContext(ctx, selectUserTeamForUpdateSQL, req.UserID).Scan(&prevTeamID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if req.Username == "" {
+			return 0, 0, errors.New(dto.ErrorCodeNotFound)
+		}
+		if _, err := tx.ExecContext(ctx, insertMemberSQL, req.UserID, req.Username, req.IsActive, teamID); err != nil {
+			return 0, 0, err
+		}
+	case err != nil:
+		return 0, 0, err
+	default:
+		if prevTeamID != 0 && prevTeamID != teamID && !allowMove {
+			return 0, 0, errors.New(dto.ErrorCodeMemberOfOtherTeam)
+		}
+		if _, err := tx.ExecContext(ctx, updateMemberSQL, req.UserID, req.Username, req.IsActive, teamID); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return prevTeamID, teamID, nil
+}
+
+// RemoveMember оставляет пользователя без команды; если он не участник
+// команды, возвращает NOT_FOUND.
+func (r *TeamRepository) RemoveMember(ctx context.Context, tx *sql.Tx, req dto.RemoveTeamMemberRequest) error {
+	var teamID int
+	if err := tx.QueryRowContext(ctx, shareTeamSQL, req.TeamName).Scan(&teamID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(dto.TeamNotFoundError)
+		}
+		return err
+	}
+	res, err := tx.ExecContext(ctx, removeMemberSQL, req.UserID, teamID)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return errors.New(dto.ErrorCodeNotFound)
+	}
+	return nil
+}
+
+func queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,202 @@
+package team
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/outbox"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const insertTeamIfMissingSQL = `
+INSERT INTO team(name) VALUES ($1) ON CONFLICT (name) DO NOTHING;
+`
+
+// текущие участники команды и пользователи из желаемого списка
+const selectSyncUsersSQL = `
+SELECT u.id, u.name, u.is_active, COALESCE(u.team_id, 0), COALESCE(t.name, '')
+FROM "user" u
+LEFT JOIN team t ON t.id = u.team_id
+WHERE u.team_id = $1 OR u.id = ANY($2)
+ORDER BY u.id
+FOR UPDATE OF u;
+`
+
+const updateSyncMemberSQL = `
+UPDATE "user" SET name = $2, is_active = $3, team_id = $4, deleted_at = NULL WHERE id = $1;
+`
+
+type syncUser struct {
+	name     string
+	isActive bool
+	teamID   int
+	teamName string
+}
+
+// memberMove - пользователь, перешедший в команду из другой команды
+type memberMove struct {
+	userID     string
+	prevTeamID int
+}
+
+// SyncTeam приводит состав команды к req.Members в транзакции tx, создавая
+// команду при необходимости, и возвращает diff и переходы из других команд.
+// При req.DryRun изменения не применяются, только считаются. Изменение флага
+// активности пишет в outbox те же события, что и /users/setIsActive.
+func (r *TeamRepository) SyncTeam(ctx context.Context, tx *sql.Tx, req dto.TeamSyncRequest) (*dto.TeamSyncResponse, []memberMove, error) {
+	resp := &dto.TeamSyncResponse{
+		TeamName:    req.TeamName,
+		DryRun:      req.DryRun,
+		Added:       make([]dto.TeamMemberChangeDTO, 0),
+		Updated:     make([]dto.TeamMemberChangeDTO, 0),
+		Deactivated: make([]dto.TeamMemberChangeDTO, 0),
+		Removed:     make([]dto.TeamMemberChangeDTO, 0),
+		Reassigned:  make([]dto.ReassignmentDTO, 0),
+	}
+
+	var teamID int
+	err := tx.QueryRowContext(ctx, lockTeamSQL, req.TeamName).Scan(&teamID)
+	if errors.Is(err, sql.ErrNoRows) {
+		resp.TeamCreated = true
+		if !req.DryRun {
+			if _, err := tx.ExecContext(ctx, insertTeamIfMissingSQL, req.TeamName); err != nil {
+				return nil, nil, err
+			}
+			err = tx.QueryRowContext(ctx, lockTeamSQL, req.TeamName).Scan(&teamID)
+		} else {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0, len(req.Members))
+	for _, m := range req.Members {
+		ids = append(ids, m.UserID)
+	}
+	users, order, err := selectSyncUsers(ctx, tx, teamID, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var moves []memberMove
+	desired := make(map[string]struct{}, len(req.Members))
+	for _, sm := range req.Members {
+		m := sm.Member()
+		desired[m.UserID] = struct{}{}
+		change := dto.TeamMemberChangeDTO{UserID: m.UserID, Username: m.Username, IsActive: m.IsActive}
+		u, ok := users[m.UserID]
+		if !ok {
+			resp.Added = append(resp.Added, change)
+			if !req.DryRun {
+				if _, err := tx.ExecContext(ctx, insertMemberSQL, m.UserID, m.Username, m.IsActive, teamID); err != nil {
+					return nil, nil, err
+				}
+			}
+			continue
+		}
+
+		if u.name != m.Username {
+			change.Fields = append(change.Fields, "username")
+		}
+		if u.isActive != m.IsActive {
+			change.Fields = append(change.Fields, "is_active")
+		}
+		switch {
+		case u.teamID != teamID || teamID == 0:
+			change.PreviousTeam = u.teamName
+			resp.Added = append(resp.Added, change)
+			if u.teamID != 0 {
+				moves = append(moves, memberMove{userID: m.UserID, prevTeamID: u.teamID})
+			}
+		case len(change.Fields) > 0:
+			resp.Updated = append(resp.Updated, change)
+		default:
+			resp.Unchanged++
+			continue
+		}
+		if !req.DryRun {
+			if err := applySyncMember(ctx, tx, teamID, req.TeamName, m, u.isActive); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	for _, id := range order {
+		u := users[id]
+		if _, ok := desired[id]; ok || u.teamID != teamID || teamID == 0 {
+			continue
+		}
+		change := dto.TeamMemberChangeDTO{UserID: id, Username: u.name, IsActive: u.isActive}
+		if req.Missing == dto.SyncMissingRemove {
+			resp.Removed = append(resp.Removed, change)
+			if !req.DryRun {
+				if _, err := tx.ExecContext(ctx, removeMemberSQL, id, teamID); err != nil {
+					return nil, nil, err
+				}
+			}
+			continue
+		}
+		if !u.isActive {
+			resp.Unchanged++
+			continue
+		}
+		change.IsActive = false
+		change.Fields = []string{"is_active"}
+		resp.Deactivated = append(resp.Deactivated, change)
+		if !req.DryRun {
+			m := dto.TeamMemberDTO{UserID: id, Username: u.name, IsActive: false}
+			if err := applySyncMember(ctx, tx, teamID, req.TeamName, m, u.isActive); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return resp, moves, nil
+}
+
+func selectSyncUsers(ctx context.Context, tx *sql.Tx, teamID int, ids []string) (map[string]syncUser, []string, error) {
+	rows, err := tx.QueryContext(ctx, selectSyncUsersSQL, teamID, pq.Array(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]syncUser)
+	var order []string
+	for rows.Next() {
+		var id string
+		var u syncUser
+		if err := rows.Scan(&id, &u.name, &u.isActive, &u.teamID, &u.teamName); err != nil {
+			return nil, nil, err
+		}
+		users[id] = u
+		order = append(order, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return users, order, nil
+}
+
+func applySyncMember(ctx context.Context, tx *sql.Tx, teamID int, teamName string, m dto.TeamMemberDTO, wasActive bool) error {
+	if _, err := tx.ExecContext(ctx, updateSyncMemberSQL, m.UserID, m.Username, m.IsActive, teamID); err != nil {
+		return err
+	}
+	if wasActive == m.IsActive {
+		return nil
+	}
+	eventType := outbox.EventUserDeactivated
+	if m.IsActive {
+		eventType = outbox.EventUserActivated
+	}
+	return outbox.Write(ctx, tx, outbox.UserAggregate(m.UserID), eventType, outbox.UserPayload{
+		UserID:   m.UserID,
+		Username: m.Username,
+		TeamName: teamName,
+		IsActive: m.IsActive,
+	})
+}
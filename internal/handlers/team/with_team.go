@@ -2,9 +2,11 @@ package team
 
 import (
 	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/user"
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -21,17 +23,44 @@ const insertTeamSQL = `
 INSERT INTO team(name) VALUES ($1) RETURNING id;
 `
 
+// повторное добавление удаленного пользователя восстанавливает надгробие:
+// MERGED PR снова ссылаются на действующего пользователя
 const insertUserSQL = `
 INSERT INTO "user"(id, name, is_active, team_id) VALUES ($1, $2, $3, $4)
-ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, is_active = EXCLUDED.is_active, team_id = EXCLUDED.team_id;
+ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, is_active = EXCLUDED.is_active, team_id = EXCLUDED.team_id, deleted_at = NULL;
 `
 
 const selectTeamIDSQL = `
 SELECT id FROM team WHERE name = $1;
 `
 
-const selectUsersByTeamSQL = `
-SELECT id, name, is_active FROM "user" WHERE team_id = $1 ORDER BY id;
+const selectTeamSQL = `
+SELECT id, COALESCE(default_max_open_reviews, 0), review_reminder_hours, review_escalation_hours
+FROM team WHERE name = $1;
+`
+
+const updateTeamMaxOpenReviewsSQL = `
+UPDATE team SET default_max_open_reviews = NULLIF($2, 0) WHERE id = $1;
+`
+
+const updateTeamReviewSLASQL = `
+UPDATE team
+SET review_reminder_hours = CASE WHEN $2 THEN NULLIF($3, -1) ELSE review_reminder_hours END,
+    review_escalation_hours = CASE WHEN $4 THEN NULLIF($5, -1) ELSE review_escalation_hours END
+WHERE id = $1;
+`
+
+const upsertCodeownersSQL = `
+INSERT INTO codeowners(repository, team_id, content) VALUES ($1, $2, $3)
+ON CONFLICT (repository) DO UPDATE SET team_id = EXCLUDED.team_id, content = EXCLUDED.content, updated_at = NOW()
+RETURNING updated_at;
+`
+
+const selectCodeownersSQL = `
+SELECT t.name, c.repository, c.content, c.updated_at
+FROM codeowners c
+JOIN team t ON t.id = c.team_id
+WHERE c.repository = $1;
 `
 
 func (r *TeamRepository) AddTeam(ctx context.Context, t dto.TeamDTO) (*dto.TeamDTO, error) {
@@ -64,32 +93,107 @@ func (r *TeamRepository) AddTeam(ctx context.Context, t dto.TeamDTO) (*dto.TeamD
 }
 
 func (r *TeamRepository) GetTeam(ctx context.Context, teamName string) (*dto.TeamDTO, error) {
+	return r.GetTeamPage(ctx, teamName, dto.UserFilter{Page: dto.PageRequest{Sort: dto.SortID}})
+}
+
+// GetTeamPage возвращает команду со страницей участников по фильтру f
+// (f.TeamName не используется); курсор следующей страницы - в NextCursor.
+func (r *TeamRepository) GetTeamPage(ctx context.Context, teamName string, f dto.UserFilter) (*dto.TeamDTO, error) {
 	var teamID int
-	err := r.db.QueryRowContext(ctx, selectTeamIDSQL, teamName).Scan(&teamID)
+	t := dto.TeamDTO{TeamName: teamName}
+	var reminderHours, escalationHours sql.NullInt64
+	err := r.db.QueryRowContext(ctx, selectTeamSQL, teamName).Scan(&teamID, &t.DefaultMaxOpenReviews, &reminderHours, &escalationHours)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errors.New(dto.TeamNotFoundError)
 		}
 		return nil, err
 	}
+	t.ReviewReminderHours = nullIntPtr(reminderHours)
+	t.ReviewEscalationHours = nullIntPtr(escalationHours)
 
-	rows, err := r.db.QueryContext(ctx, selectUsersByTeamSQL, teamID)
+	f.TeamName = teamName
+	users, next, err := user.ListUsers(ctx, r.db, f)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	members := make([]dto.TeamMemberDTO, 0, len(users))
+	for _, u := range users {
+		members = append(members, dto.TeamMemberDTO{UserID: u.UserID, Username: u.Username, IsActive: u.IsActive})
+	}
 
-	members := make([]dto.TeamMemberDTO, 0)
-	for rows.Next() {
-		var m dto.TeamMemberDTO
-		if err := rows.Scan(&m.UserID, &m.Username, &m.IsActive); err != nil {
+	t.Members = members
+	t.NextCursor = next
+	return &t, nil
+}
+
+func (r *TeamRepository) UpdateSettings(ctx context.Context, req dto.TeamSettingsRequest) (*dto.TeamDTO, error) {
+	var teamID int
+	err := r.db.QueryRowContext(ctx, selectTeamIDSQL, req.TeamName).Scan(&teamID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New(dto.TeamNotFoundError)
+		}
+		return nil, err
+	}
+	if req.DefaultMaxOpenReviews != nil {
+		if _, err := r.db.ExecContext(ctx, updateTeamMaxOpenReviewsSQL, teamID, *req.DefaultMaxOpenReviews); err != nil {
+			return nil, err
+		}
+	}
+	if req.ReviewReminderHours != nil || req.ReviewEscalationHours != nil {
+		if _, err := r.db.ExecContext(ctx, updateTeamReviewSLASQL, teamID,
+			req.ReviewReminderHours != nil, intOrZero(req.ReviewReminderHours),
+			req.ReviewEscalationHours != nil, intOrZero(req.ReviewEscalationHours)); err != nil {
 			return nil, err
 		}
-		members = append(members, m)
 	}
-	if err := rows.Err(); err != nil {
+	return r.GetTeam(ctx, req.TeamName)
+}
+
+func intOrZero(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+func (r *TeamRepository) SetCodeowners(ctx context.Context, c dto.CodeownersDTO) (*dto.CodeownersDTO, error) {
+	var teamID int
+	err := r.db.QueryRowContext(ctx, selectTeamIDSQL, c.TeamName).Scan(&teamID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New(dto.TeamNotFoundError)
+		}
+		return nil, err
+	}
+
+	var updatedAt time.Time
+	if err := r.db.QueryRowContext(ctx, upsertCodeownersSQL, c.Repository, teamID, c.Content).Scan(&updatedAt); err != nil {
 		return nil, err
 	}
+	c.UpdatedAt = &updatedAt
+	return &c, nil
+}
 
-	return &dto.TeamDTO{TeamName: teamName, Members: members}, nil
+func (r *TeamRepository) GetCodeowners(ctx context.Context, repository string) (*dto.CodeownersDTO, error) {
+	var c dto.CodeownersDTO
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, selectCodeownersSQL, repository).Scan(&c.TeamName, &c.Repository, &c.Content, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New(dto.ErrorCodeNotFound)
+		}
+		return nil, err
+	}
+	c.UpdatedAt = &updatedAt
+	return &c, nil
 }
@@ -3,9 +3,10 @@ package user
 import (
 	"AvitoInternship/internal/handlers/common"
 	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
 	"database/sql"
-	"encoding/json"
 	"net/http"
+	"strings"
 )
 
 const (
@@ -23,13 +24,7 @@ func SetIsActive(db *sql.DB) http.HandlerFunc {
 		}
 
 		var req dto.SetIsActiveRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			common.WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
-			return
-		}
-
-		if req.UserID == "" {
-			common.WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
+		if !common.DecodeJSON(w, r, &req) {
 			return
 		}
 
@@ -57,7 +52,74 @@ func SetIsActive(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-func GetReview(db *sql.DB) http.HandlerFunc {
+// SetProfile - POST /users/setProfile
+func SetProfile(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.SetProfileRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+		if req.ChatHandle != nil {
+			handle := strings.TrimPrefix(strings.TrimSpace(*req.ChatHandle), "@")
+			req.ChatHandle = &handle
+		}
+
+		user, err := repo.SetProfile(r.Context(), req)
+		if err != nil {
+			if err.Error() == userNotFoundError {
+				common.WriteError(w, http.StatusNotFound, ErrorCodeNotFound, "resource not found")
+				return
+			}
+			common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to update user profile")
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, dto.UserResponse{User: *user})
+	}
+}
+
+// SetExternalLogin - POST /users/setExternalLogin
+func SetExternalLogin(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.ExternalLoginDTO
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		login, err := repo.SetExternalLogin(r.Context(), req)
+		if err != nil {
+			if err.Error() == userNotFoundError {
+				common.WriteError(w, http.StatusNotFound, ErrorCodeNotFound, "resource not found")
+				return
+			}
+			if err.Error() == dto.ErrorCodeLoginTaken {
+				common.WriteError(w, http.StatusConflict, dto.ErrorCodeLoginTaken, "login is already linked to another user")
+				return
+			}
+			common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to set external login")
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, map[string]dto.ExternalLoginDTO{"external_login": *login})
+	}
+}
+
+// Get - GET /users/get?user_id=...
+func Get(db *sql.DB) http.HandlerFunc {
 	repo := NewUserRepository(db)
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -71,19 +133,138 @@ func GetReview(db *sql.DB) http.HandlerFunc {
 			common.WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
 			return
 		}
+		user, err := repo.GetProfile(r.Context(), userID)
+		if err != nil {
+			writeUserError(w, err, "failed to get user")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, dto.UserResponse{User: *user})
+	}
+}
 
-		ctx := r.Context()
-		prs, err := repo.GetReviewPullRequests(ctx, userID)
+// Update - POST /users/update
+//
+// То же, что PATCH /v2/users/{id}: флаг активности и поля профиля, включая
+// username, display_name, email и external_logins.
+func Update(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.UpdateUserRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+		updateUser(w, r, repo, req)
+	}
+}
+
+// Delete - POST /users/delete
+//
+// Автора OPEN PR удалить нельзя: 409 USER_HAS_OPEN_PRS со списком PR. OPEN-ревью
+// пользователя переназначаются в транзакции удаления.
+func Delete(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewUserService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.DeleteUserRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		resp, err := svc.DeleteUser(r.Context(), req.UserID)
 		if err != nil {
-			common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get pull requests")
+			writeUserError(w, err, "failed to delete user")
 			return
 		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// GetReview - GET /users/getReview?user_id=...
+//
+// Фильтры и страницы - как у /pullRequest/list; без limit и cursor
+// возвращаются все PR, отсортированные по id.
+func GetReview(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
 
-		response := map[string]interface{}{
-			"user_id":       userID,
-			"pull_requests": prs,
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
 		}
 
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			common.WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
+			return
+		}
+		writeReviews(w, r, repo, userID)
+	}
+}
+
+func writeReviews(w http.ResponseWriter, r *http.Request, repo *UserRepository, userID string) {
+	var v dto.Validator
+	filter := common.ParsePullRequestFilter(r.URL.Query(), 0, dto.SortID, &v)
+	if err := v.Err(); err != nil {
+		common.WriteValidationError(w, err)
+		return
+	}
+	filter.ReviewerID = userID
+
+	prs, next, err := repo.ListReviewPullRequests(r.Context(), filter)
+	if err != nil {
+		common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to get pull requests")
+		return
+	}
+
+	response := map[string]interface{}{
+		"user_id":       userID,
+		"pull_requests": prs,
+	}
+	if next != "" {
+		response["next_cursor"] = next
+	}
+
+	common.WriteJSON(w, http.StatusOK, response)
+}
+
+// List - GET /users/list?team_name=...&active_only=...&sort=...&limit=...&cursor=...
+func List(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var v dto.Validator
+		filter := common.ParseUserFilter(r.URL.Query(), dto.DefaultPageLimit, &v)
+		if err := v.Err(); err != nil {
+			common.WriteValidationError(w, err)
+			return
+		}
+
+		users, next, err := repo.ListUsers(r.Context(), filter)
+		if err != nil {
+			common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list users")
+			return
+		}
+
+		response := map[string]interface{}{"users": users}
+		if next != "" {
+			response["next_cursor"] = next
+		}
 		common.WriteJSON(w, http.StatusOK, response)
 	}
 }
@@ -0,0 +1,322 @@
+package user
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	reviewStreamBatch     = 500
+	reviewStreamPoll      = time.Second
+	reviewStreamHeartbeat = 15 * time.Second
+	// сколько события могут ждать долгую транзакцию, держащую xmin
+	reviewStreamMaxHold = 30 * time.Second
+	// непрочитанные пачки соединения; медленный клиент отключается и
+	// переподключается с Last-Event-ID
+	reviewStreamBuffer = 16
+)
+
+// ReviewStream - GET /users/reviewStream?user_id=...
+//
+// Server-Sent Events о назначении, снятии и merge PR, где пользователь
+// ревьюер. События читаются из outbox в порядке фиксации транзакций, поэтому
+// переподключение с Last-Event-ID (заголовок или параметр last_event_id)
+// продолжает поток без пропусков; без него отдаются только новые события.
+// Если событие курсора уже удалено очисткой outbox, отправляется reset и поток
+// начинается с новых событий. Раз в 15 секунд отправляется комментарий-heartbeat.
+func ReviewStream(db *sql.DB) http.HandlerFunc {
+	stream := reviewStream(db, func(r *http.Request) string { return r.URL.Query().Get("user_id") })
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+			return
+		}
+		stream(w, r)
+	}
+}
+
+// ReviewStreamV2 - GET /v2/users/{id}/review-stream
+func ReviewStreamV2(db *sql.DB) http.HandlerFunc {
+	return reviewStream(db, func(r *http.Request) string { return r.PathValue("id") })
+}
+
+func reviewStream(db *sql.DB, userIDOf func(*http.Request) string) http.HandlerFunc {
+	repo := NewUserRepository(db)
+	hub := newReviewStreamHub(repo)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := userIDOf(r)
+		if userID == "" {
+			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "user_id is required")
+			return
+		}
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+		var after ReviewCursor
+		if lastEventID != "" {
+			c, err := ParseReviewCursor(lastEventID)
+			if err != nil {
+				common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "invalid Last-Event-ID")
+				return
+			}
+			after = c
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "streaming unsupported")
+			return
+		}
+
+		ctx := r.Context()
+		exists, err := repo.Exists(ctx, userID)
+		if err != nil {
+			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to open stream")
+			return
+		}
+		if !exists {
+			common.WriteError(w, http.StatusNotFound, ErrorCodeNotFound, "resource not found")
+			return
+		}
+		reset := false
+		if lastEventID != "" {
+			retained, err := repo.CursorRetained(ctx, after)
+			if err != nil {
+				common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to open stream")
+				return
+			}
+			reset = !retained
+		}
+		if lastEventID == "" || reset {
+			if after, err = repo.StreamStart(ctx); err != nil {
+				common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to open stream")
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "retry: %d\n\n", reviewStreamPoll.Milliseconds()*3)
+		if reset {
+			// пропущенные события удалены: клиент перечитывает состояние
+			// через getReview и продолжает с нового курсора
+			data, err := json.Marshal(dto.ReviewStreamResetDTO{Type: dto.ReviewStreamReset, Cursor: after.String()})
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", after, dto.ReviewStreamReset, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		sub := hub.subscribe(userID, after)
+		defer hub.unsubscribe(sub)
+		heartbeat := time.NewTicker(reviewStreamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case events, ok := <-sub.events:
+				if !ok {
+					return
+				}
+				for _, e := range events {
+					data, err := json.Marshal(e)
+					if err != nil {
+						return
+					}
+					if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.Cursor, e.Type, data); err != nil {
+						return
+					}
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+type reviewSubscriber struct {
+	userID string
+	// меняется только опросом хаба
+	after  ReviewCursor
+	events chan []dto.ReviewStreamEventDTO
+}
+
+// reviewStreamHub опрашивает outbox одним запросом за тик для всех открытых
+// потоков обработчика и раздает события соединениям. Опрос идет, пока есть
+// хотя бы одно соединение.
+//
+// Событие транзакции не младше xmin ждет, пока xmin его пропустит; если xmin
+// держит одна долгая транзакция дольше reviewStreamMaxHold, хаб читает все
+// зафиксированные события. Событие транзакции, которая в это время
+// зафиксируется с меньшим курсором, в поток не попадет.
+type reviewStreamHub struct {
+	repo *UserRepository
+
+	mu      sync.Mutex
+	subs    map[*reviewSubscriber]struct{}
+	running bool
+
+	// xmin, который держит события, и с какого момента
+	heldXmin  uint64
+	heldSince time.Time
+}
+
+func newReviewStreamHub(repo *UserRepository) *reviewStreamHub {
+	return &reviewStreamHub{repo: repo, subs: map[*reviewSubscriber]struct{}{}}
+}
+
+func (h *reviewStreamHub) subscribe(userID string, after ReviewCursor) *reviewSubscriber {
+	s := &reviewSubscriber{userID: userID, after: after, events: make(chan []dto.ReviewStreamEventDTO, reviewStreamBuffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[s] = struct{}{}
+	if !h.running {
+		h.running = true
+		go h.run()
+	}
+	return s
+}
+
+func (h *reviewStreamHub) unsubscribe(s *reviewSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.events)
+	}
+}
+
+func (h *reviewStreamHub) run() {
+	ticker := time.NewTicker(reviewStreamPoll)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.mu.Lock()
+		if len(h.subs) == 0 {
+			h.running = false
+			h.mu.Unlock()
+			return
+		}
+		subs := make([]*reviewSubscriber, 0, len(h.subs))
+		for s := range h.subs {
+			subs = append(subs, s)
+		}
+		h.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*reviewStreamPoll)
+		if err := h.poll(ctx, subs); err != nil {
+			log.Println("review stream poll failed:", err)
+		}
+		cancel()
+	}
+}
+
+// poll читает события после самого раннего курсора соединений и отдает
+// каждому соединению его события после его курсора.
+func (h *reviewStreamHub) poll(ctx context.Context, subs []*reviewSubscriber) error {
+	all, err := h.overHeld(ctx)
+	if err != nil {
+		return err
+	}
+	from := subs[0].after
+	for _, s := range subs[1:] {
+		if s.after.less(from) {
+			from = s.after
+		}
+	}
+	for {
+		events, err := h.repo.ReviewEvents(ctx, from, all, reviewStreamBatch)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		last := events[len(events)-1].cursor
+		for _, s := range subs {
+			if err := h.deliver(s, events, last); err != nil {
+				return err
+			}
+		}
+		// пока есть полные пачки, догоняем без ожидания
+		if len(events) < reviewStreamBatch {
+			return nil
+		}
+		from = last
+	}
+}
+
+// overHeld сообщает, что события дольше reviewStreamMaxHold ждут один и тот
+// же xmin.
+func (h *reviewStreamHub) overHeld(ctx context.Context) (bool, error) {
+	xmin, held, err := h.repo.StreamGate(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !held {
+		h.heldXmin, h.heldSince = 0, time.Time{}
+		return false, nil
+	}
+	if xmin != h.heldXmin || h.heldSince.IsZero() {
+		h.heldXmin, h.heldSince = xmin, time.Now()
+	}
+	return time.Since(h.heldSince) > reviewStreamMaxHold, nil
+}
+
+func (h *reviewStreamHub) deliver(s *reviewSubscriber, events []reviewEvent, last ReviewCursor) error {
+	var batch []dto.ReviewStreamEventDTO
+	for _, e := range events {
+		if !s.after.less(e.cursor) {
+			continue
+		}
+		ev, ok, err := reviewStreamEvent(e.Event, s.userID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			ev.Cursor = e.cursor.String()
+			batch = append(batch, ev)
+		}
+	}
+	if s.after.less(last) {
+		s.after = last
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; !ok {
+		return nil
+	}
+	select {
+	case s.events <- batch:
+	default:
+		log.Printf("review stream %s: client is too slow, disconnecting", s.userID)
+		delete(h.subs, s)
+		close(s.events)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package user
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"database/sql"
+	"net/http"
+)
+
+// AddUnavailability - POST /users/unavailability/add
+func AddUnavailability(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.UnavailabilityDTO
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		u, err := repo.AddUnavailability(r.Context(), req)
+		if err != nil {
+			if err.Error() == userNotFoundError {
+				common.WriteError(w, http.StatusNotFound, ErrorCodeNotFound, "resource not found")
+				return
+			}
+			common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to add unavailability")
+			return
+		}
+
+		common.WriteJSON(w, http.StatusCreated, map[string]dto.UnavailabilityDTO{"unavailability": *u})
+	}
+}
+
+// UpdateUnavailability - POST /users/unavailability/update
+func UpdateUnavailability(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.UpdateUnavailabilityRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		u, err := repo.UpdateUnavailability(r.Context(), dto.UnavailabilityDTO(req))
+		if err != nil {
+			if err.Error() == dto.ErrorCodeNotFound {
+				common.WriteError(w, http.StatusNotFound, ErrorCodeNotFound, "resource not found")
+				return
+			}
+			common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to update unavailability")
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, map[string]dto.UnavailabilityDTO{"unavailability": *u})
+	}
+}
+
+// DeleteUnavailability - POST /users/unavailability/delete
+func DeleteUnavailability(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		var req dto.DeleteUnavailabilityRequest
+		if !common.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		if err := repo.DeleteUnavailability(r.Context(), req.ID); err != nil {
+			if err.Error() == dto.ErrorCodeNotFound {
+				common.WriteError(w, http.StatusNotFound, ErrorCodeNotFound, "resource not found")
+				return
+			}
+			common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to delete unavailability")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListUnavailability - GET /users/unavailability/list?user_id=...
+func ListUnavailability(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			common.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+			return
+		}
+
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			common.WriteError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
+			return
+		}
+
+		list, err := repo.ListUnavailability(r.Context(), userID)
+		if err != nil {
+			common.WriteError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list unavailability")
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"user_id":        userID,
+			"unavailability": list,
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package user
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GetV2 - GET /v2/users/{id}
+func GetV2(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := repo.GetProfile(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeUserError(w, err, "failed to get user")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, dto.UserResponse{User: *user})
+	}
+}
+
+// UpdateV2 - PATCH /v2/users/{id}
+func UpdateV2(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.UpdateUserRequest
+		if !common.Decode(w, r, &req) {
+			return
+		}
+		// пользователь задается только путем: user_id в теле мог бы указывать на другого
+		if req.UserID != "" {
+			common.WriteValidationError(w, &dto.ValidationError{Fields: []dto.FieldErrorDTO{
+				{Field: "user_id", Message: "must not be set: the user is taken from the path"},
+			}})
+			return
+		}
+		req.UserID = r.PathValue("id")
+		if !common.Validate(w, req.SetProfileRequest) {
+			return
+		}
+		updateUser(w, r, repo, req)
+	}
+}
+
+// DeleteV2 - DELETE /v2/users/{id}
+func DeleteV2(db *sql.DB, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	svc := NewUserService(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := dto.DeleteUserRequest{UserID: r.PathValue("id")}
+		if !common.Validate(w, req) {
+			return
+		}
+		resp, err := svc.DeleteUser(r.Context(), req.UserID)
+		if err != nil {
+			writeUserError(w, err, "failed to delete user")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+// updateUser объединяет setIsActive и setProfile в одной транзакции; событие
+// user.activated/deactivated пишется так же, как в /users/setIsActive.
+func updateUser(w http.ResponseWriter, r *http.Request, repo *UserRepository, req dto.UpdateUserRequest) {
+	if req.ChatHandle != nil {
+		handle := strings.TrimPrefix(strings.TrimSpace(*req.ChatHandle), "@")
+		req.ChatHandle = &handle
+	}
+
+	user, err := repo.UpdateUser(r.Context(), req)
+	if err != nil {
+		writeUserError(w, err, "failed to update user")
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, dto.UserResponse{User: *user})
+}
+
+// GetReviewsV2 - GET /v2/users/{id}/reviews
+func GetReviewsV2(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReviews(w, r, repo, r.PathValue("id"))
+	}
+}
+
+// PutExternalLoginV2 - PUT /v2/users/{id}/external-logins/{provider}, тело - {login}
+func PutExternalLoginV2(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.ExternalLoginDTO
+		if !common.Decode(w, r, &req) {
+			return
+		}
+		req.UserID = r.PathValue("id")
+		req.Provider = r.PathValue("provider")
+		if !common.Validate(w, req) {
+			return
+		}
+		login, err := repo.SetExternalLogin(r.Context(), req)
+		if err != nil {
+			writeUserError(w, err, "failed to set external login")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]dto.ExternalLoginDTO{"external_login": *login})
+	}
+}
+
+// DeleteExternalLoginV2 - DELETE /v2/users/{id}/external-logins/{provider}
+func DeleteExternalLoginV2(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := dto.ExternalLoginDTO{UserID: r.PathValue("id"), Provider: r.PathValue("provider")}
+		if !common.Validate(w, req) {
+			return
+		}
+		if _, err := repo.SetExternalLogin(r.Context(), req); err != nil {
+			writeUserError(w, err, "failed to delete external login")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AddUnavailabilityV2 - POST /v2/users/{id}/unavailability
+func AddUnavailabilityV2(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.UnavailabilityDTO
+		if !common.Decode(w, r, &req) {
+			return
+		}
+		req.UserID = r.PathValue("id")
+		if !common.Validate(w, req) {
+			return
+		}
+		u, err := repo.AddUnavailability(r.Context(), req)
+		if err != nil {
+			writeUserError(w, err, "failed to add unavailability")
+			return
+		}
+		common.WriteJSON(w, http.StatusCreated, map[string]dto.UnavailabilityDTO{"unavailability": *u})
+	}
+}
+
+// ListUnavailabilityV2 - GET /v2/users/{id}/unavailability
+func ListUnavailabilityV2(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.PathValue("id")
+		list, err := repo.ListUnavailability(r.Context(), userID)
+		if err != nil {
+			writeUserError(w, err, "failed to list unavailability")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"user_id":        userID,
+			"unavailability": list,
+		})
+	}
+}
+
+// UpdateUnavailabilityV2 - PUT /v2/unavailability/{id}
+func UpdateUnavailabilityV2(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.UpdateUnavailabilityRequest
+		if !common.Decode(w, r, &req) {
+			return
+		}
+		req.ID, _ = strconv.Atoi(r.PathValue("id"))
+		if !common.Validate(w, req) {
+			return
+		}
+		u, err := repo.UpdateUnavailability(r.Context(), dto.UnavailabilityDTO(req))
+		if err != nil {
+			writeUserError(w, err, "failed to update unavailability")
+			return
+		}
+		common.WriteJSON(w, http.StatusOK, map[string]dto.UnavailabilityDTO{"unavailability": *u})
+	}
+}
+
+// DeleteUnavailabilityV2 - DELETE /v2/unavailability/{id}
+func DeleteUnavailabilityV2(db *sql.DB) http.HandlerFunc {
+	repo := NewUserRepository(db)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req dto.DeleteUnavailabilityRequest
+		req.ID, _ = strconv.Atoi(r.PathValue("id"))
+		if !common.Validate(w, req) {
+			return
+		}
+		if err := repo.DeleteUnavailability(r.Context(), req.ID); err != nil {
+			writeUserError(w, err, "failed to delete unavailability")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeUserError(w http.ResponseWriter, err error, message string) {
+	var openPRs *dto.UserHasOpenPRsError
+	if errors.As(err, &openPRs) {
+		common.WriteErrorDetails(w, http.StatusConflict, dto.ErrorCodeUserHasOpenPRs, "user is the author of open pull requests", openPRs.PullRequestIDs)
+		return
+	}
+	switch err.Error() {
+	case userNotFoundError, dto.ErrorCodeNotFound:
+		common.WriteError(w, http.StatusNotFound, ErrorCodeNotFound, "resource not found")
+	case dto.ErrorCodeLoginTaken:
+		common.WriteError(w, http.StatusConflict, dto.ErrorCodeLoginTaken, "login is already linked to another user")
+	default:
+		common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, message)
+	}
+}
@@ -0,0 +1,72 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestUpdateV2RejectsUserIDInBody(t *testing.T) {
+	mux := http.NewServeMux()
+	// БД не нужна: запрос отклоняется до обращения к репозиторию
+	mux.HandleFunc("PATCH /v2/users/{id}", UpdateV2(nil))
+	req := httptest.NewRequest(http.MethodPatch, "/v2/users/u1", strings.NewReader(`{"user_id":"u2","is_active":false}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var resp common.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error.Code != dto.ErrorCodeValidation || !strings.Contains(rec.Body.String(), `"field":"user_id"`) {
+		t.Errorf("body = %s", rec.Body)
+	}
+}
+
+func TestUpdateUserRollsBackActivityOnProfileError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := NewUserRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT is_active FROM "user" WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"is_active"}).AddRow(true))
+	mock.ExpectExec(regexp.QuoteMeta(`SET is_active = $1`)).
+		WithArgs(false, "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`COALESCE(t.name, '') AS team_name`)).
+		WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "team_name", "is_active"}).AddRow("u1", "Ivan", "backend", false))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_event")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT TRUE FROM "user" WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`)).
+		WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET name = $2 WHERE id = $1`)).
+		WithArgs("u1", "Ivan2").WillReturnError(errors.New("deadlock detected"))
+	// без Commit: флаг активности и событие откатываются вместе с профилем
+	mock.ExpectRollback()
+
+	active := false
+	name := "Ivan2"
+	req := dto.UpdateUserRequest{IsActive: &active, SetProfileRequest: dto.SetProfileRequest{UserID: "u1", Username: &name}}
+	if _, err := repo.UpdateUser(context.Background(), req); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,224 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestReviewCursor(t *testing.T) {
+	c := ReviewCursor{TxID: 7421, ID: 15}
+	if got := c.String(); got != "7421-15" {
+		t.Errorf("String() = %q", got)
+	}
+	parsed, err := ParseReviewCursor(c.String())
+	if err != nil || parsed != c {
+		t.Errorf("ParseReviewCursor(%q) = %v, %v", c.String(), parsed, err)
+	}
+	for _, bad := range []string{"", "15", "a-1", "1-b", "1--1", "-1-1"} {
+		if _, err := ParseReviewCursor(bad); err == nil {
+			t.Errorf("ParseReviewCursor(%q) succeeded", bad)
+		}
+	}
+}
+
+var reviewEventColumns = []string{"id", "txid", "event_type", "payload", "created_at"}
+
+func TestReviewEventsReadsCommitOrderedCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := NewUserRepository(db)
+
+	created := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	// событие 9 зафиксировано позже события 12 и идет после него
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (txid, id) > ($1::xid8, $2)")).
+		WithArgs("100", int64(12), false, 10).
+		WillReturnRows(sqlmock.NewRows(reviewEventColumns).
+			AddRow(9, "101", "reviewer.reassigned", []byte(`{"pull_request_id":"pr-1","old_reviewer_id":"u2","new_reviewer_id":"u3","assigned_reviewers":["u3"]}`), created).
+			AddRow(13, "102", "pr.merged", []byte(`{"pull_request_id":"pr-2","assigned_reviewers":["u2"]}`), created))
+
+	events, err := repo.ReviewEvents(context.Background(), ReviewCursor{TxID: 100, ID: 12}, false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].cursor != (ReviewCursor{TxID: 101, ID: 9}) || events[1].cursor != (ReviewCursor{TxID: 102, ID: 13}) {
+		t.Fatalf("events = %+v", events)
+	}
+	if e, ok, err := reviewStreamEvent(events[0].Event, "u2"); err != nil || !ok || e.Type != dto.ReviewStreamUnassigned || e.ReplacedBy != "u3" {
+		t.Errorf("u2 events[0] = %+v, %v, %v", e, ok, err)
+	}
+	if e, ok, err := reviewStreamEvent(events[0].Event, "u3"); err != nil || !ok || e.Type != dto.ReviewStreamAssigned {
+		t.Errorf("u3 events[0] = %+v, %v, %v", e, ok, err)
+	}
+	// чужие события в поток пользователя не попадают
+	if _, ok, err := reviewStreamEvent(events[1].Event, "u3"); err != nil || ok {
+		t.Errorf("u3 events[1] = %v, %v", ok, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviewStreamHubFansOutOneQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	hub := newReviewStreamHub(NewUserRepository(db))
+	u2 := &reviewSubscriber{userID: "u2", after: ReviewCursor{TxID: 100}, events: make(chan []dto.ReviewStreamEventDTO, 1)}
+	u3 := &reviewSubscriber{userID: "u3", after: ReviewCursor{TxID: 101, ID: 9}, events: make(chan []dto.ReviewStreamEventDTO, 1)}
+	hub.subs[u2], hub.subs[u3] = struct{}{}, struct{}{}
+
+	mock.ExpectQuery(regexp.QuoteMeta(selectStreamGateSQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"xmin", "held"}).AddRow("103", false))
+	// одна выборка от самого раннего курсора на оба соединения
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (txid, id) > ($1::xid8, $2)")).
+		WithArgs("100", int64(0), false, reviewStreamBatch).
+		WillReturnRows(sqlmock.NewRows(reviewEventColumns).
+			AddRow(9, "101", "reviewer.reassigned", []byte(`{"pull_request_id":"pr-1","old_reviewer_id":"u2","new_reviewer_id":"u3","assigned_reviewers":["u3"]}`), time.Now()).
+			AddRow(13, "102", "pr.merged", []byte(`{"pull_request_id":"pr-1","assigned_reviewers":["u3"]}`), time.Now()))
+
+	if err := hub.poll(context.Background(), []*reviewSubscriber{u2, u3}); err != nil {
+		t.Fatal(err)
+	}
+	if batch := <-u2.events; len(batch) != 1 || batch[0].Type != dto.ReviewStreamUnassigned {
+		t.Errorf("u2 batch = %+v", batch)
+	}
+	// событие 9 уже было у u3 до переподключения
+	if batch := <-u3.events; len(batch) != 1 || batch[0].Type != dto.ReviewStreamMerged || batch[0].Cursor != "102-13" {
+		t.Errorf("u3 batch = %+v", batch)
+	}
+	if want := (ReviewCursor{TxID: 102, ID: 13}); u2.after != want || u3.after != want {
+		t.Errorf("after = %v, %v", u2.after, u3.after)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviewStreamHubStopsWaitingForLongTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	hub := newReviewStreamHub(NewUserRepository(db))
+
+	held := sqlmock.NewRows([]string{"xmin", "held"}).AddRow("103", true)
+	mock.ExpectQuery(regexp.QuoteMeta(selectStreamGateSQL)).WillReturnRows(held)
+	if all, err := hub.overHeld(context.Background()); err != nil || all {
+		t.Fatalf("first hold: %v, %v", all, err)
+	}
+	// тот же xmin держит события дольше reviewStreamMaxHold
+	hub.heldSince = time.Now().Add(-reviewStreamMaxHold - time.Second)
+	mock.ExpectQuery(regexp.QuoteMeta(selectStreamGateSQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"xmin", "held"}).AddRow("103", true))
+	if all, err := hub.overHeld(context.Background()); err != nil || !all {
+		t.Fatalf("long hold: %v, %v", all, err)
+	}
+	// xmin сдвинулся: снова ждем
+	mock.ExpectQuery(regexp.QuoteMeta(selectStreamGateSQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"xmin", "held"}).AddRow("110", true))
+	if all, err := hub.overHeld(context.Background()); err != nil || all {
+		t.Fatalf("new xmin: %v, %v", all, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviewStreamRejectsInvalidLastEventID(t *testing.T) {
+	for _, id := range []string{"15", "x-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/users/reviewStream?user_id=u1", nil)
+		req.Header.Set("Last-Event-ID", id)
+		rec := httptest.NewRecorder()
+		ReviewStream(nil).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Last-Event-ID %q: status = %d", id, rec.Code)
+		}
+		var resp common.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error.Code != dto.ErrorBadRequest {
+			t.Errorf("Last-Event-ID %q: body %s", id, rec.Body)
+		}
+	}
+}
+
+func TestReviewStreamSendsEventsFromSnapshotStart(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM "user" WHERE id = $1 AND deleted_at IS NULL)`)).
+		WithArgs("u2").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_snapshot_xmin(pg_current_snapshot())::text")).
+		WillReturnRows(sqlmock.NewRows([]string{"xmin"}).AddRow("500"))
+	mock.ExpectQuery(regexp.QuoteMeta(selectStreamGateSQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"xmin", "held"}).AddRow("501", false))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (txid, id) > ($1::xid8, $2)")).
+		WithArgs("500", int64(0), false, reviewStreamBatch).
+		WillReturnRows(sqlmock.NewRows(reviewEventColumns).
+			AddRow(40, "500", "pr.created", []byte(`{"pull_request_id":"pr-1","assigned_reviewers":["u1","u2"]}`), time.Now()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), reviewStreamPoll+reviewStreamPoll/2)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/users/reviewStream?user_id=u2", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	ReviewStream(db).ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "id: 500-40\nevent: assigned\ndata: {") {
+		t.Errorf("stream:\n%s", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviewStreamResetsExpiredCursor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(selectUserExistsSQL)).
+		WithArgs("u2").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	// событие 40 удалено очисткой outbox
+	mock.ExpectQuery(regexp.QuoteMeta(selectEventRetainedSQL)).
+		WithArgs(int64(40)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(regexp.QuoteMeta(selectStreamStartSQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"xmin"}).AddRow("900"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), reviewStreamPoll/2)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/users/reviewStream?user_id=u2", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", "500-40")
+	rec := httptest.NewRecorder()
+	ReviewStream(db).ServeHTTP(rec, req)
+
+	if body := rec.Body.String(); !strings.Contains(body, "id: 900-0\nevent: reset\ndata: {\"type\":\"reset\",\"cursor\":\"900-0\"}") {
+		t.Errorf("stream:\n%s", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,50 @@
+package user
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"context"
+	"database/sql"
+)
+
+// UserService удаляет пользователей: OPEN-ревью пользователя переназначаются,
+// как при удалении из команды, в одной транзакции с удалением, а уведомления о
+// заменах отправляются после фиксации.
+type UserService struct {
+	repo *UserRepository
+	prs  *pullRequest.PullRequestService
+}
+
+func NewUserService(db *sql.DB, listener pullRequest.AssignmentListener) *UserService {
+	return &UserService{repo: NewUserRepository(db), prs: pullRequest.NewService(db, listener)}
+}
+
+// DeleteUser удаляет пользователя. Автора OPEN PR удалить нельзя
+// (*dto.UserHasOpenPRsError). Ревью, для которых не нашлось замены, удаляются
+// вместе с пользователем и возвращаются в Reassigned с кодом ошибки.
+func (s *UserService) DeleteUser(ctx context.Context, userID string) (*dto.DeleteUserResponse, error) {
+	tx, err := s.repo.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.repo.LockDeletable(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	results, err := s.prs.ReassignOpenReviewsTx(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteUser(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.prs.NotifyReassigned(userID, results)
+	return &dto.DeleteUserResponse{
+		UserID:     userID,
+		Reassigned: pullRequest.AppendReassignments(make([]dto.ReassignmentDTO, 0), userID, results),
+	}, nil
+}
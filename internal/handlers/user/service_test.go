@@ -0,0 +1,136 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const selectOpenReviewsQuery = `WHERE prr.user_id = $1 AND pr.status = 'OPEN'
+ORDER BY pr.id`
+
+func expectLockUser(mock sqlmock.Sqlmock, userID string, openPRs ...string) {
+	mock.ExpectQuery(regexp.QuoteMeta(lockUserSQL)).
+		WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+	rows := sqlmock.NewRows([]string{"id"})
+	for _, id := range openPRs {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(selectAuthoredOpenPRsSQL)).WithArgs(userID).WillReturnRows(rows)
+}
+
+func TestDeleteUserWithOpenPRsReturnsConflict(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		handler func(db *sql.DB) http.HandlerFunc
+		request *http.Request
+	}{
+		{
+			name:    "v1",
+			pattern: "POST /users/delete",
+			handler: func(db *sql.DB) http.HandlerFunc { return Delete(db, nil) },
+			request: httptest.NewRequest(http.MethodPost, "/users/delete", strings.NewReader(`{"user_id":"u1"}`)),
+		},
+		{
+			name:    "v2",
+			pattern: "DELETE /v2/users/{id}",
+			handler: func(db *sql.DB) http.HandlerFunc { return DeleteV2(db, nil) },
+			request: httptest.NewRequest(http.MethodDelete, "/v2/users/u1", nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			expectLockUser(mock, "u1", "pr-1", "pr-2")
+			// ни переназначений, ни удаления
+			mock.ExpectRollback()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc(tt.pattern, tt.handler(db))
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, tt.request)
+
+			if rec.Code != http.StatusConflict {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+			}
+			var resp common.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error.Code != dto.ErrorCodeUserHasOpenPRs ||
+				!reflect.DeepEqual(resp.Error.Details, []any{"pr-1", "pr-2"}) {
+				t.Errorf("body = %s", rec.Body)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestDeleteUserKeepsTombstoneForHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		history bool
+	}{
+		{name: "without history"},
+		{name: "author of merged PRs", history: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			expectLockUser(mock, "u1")
+			// переназначение в той же транзакции, до удаления
+			mock.ExpectQuery(regexp.QuoteMeta(selectOpenReviewsQuery)).WithArgs("u1").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectQuery(regexp.QuoteMeta(selectUserWithTeamSQL)).WithArgs("u1").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "team_name", "is_active"}).AddRow("u1", "Ivan", "backend", true))
+			for _, query := range []string{deleteOpenReviewsSQL, deleteUserSkillsSQL, deleteUserUnavailabilitySQL, deleteUserExternalLoginsSQL} {
+				mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs("u1").WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+			if tt.history {
+				mock.ExpectExec(regexp.QuoteMeta(deleteUserWithoutHistorySQL)).WithArgs("u1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(tombstoneUserSQL)).WithArgs("u1").WillReturnResult(sqlmock.NewResult(0, 1))
+			} else {
+				mock.ExpectExec(regexp.QuoteMeta(deleteUserWithoutHistorySQL)).WithArgs("u1").WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_event")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			resp, err := NewUserService(db, nil).DeleteUser(context.Background(), "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.UserID != "u1" || len(resp.Reassigned) != 0 {
+				t.Errorf("resp = %+v", resp)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package user
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/outbox"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+const selectAuthoredOpenPRsSQL = `
+SELECT id FROM pull_request WHERE author_id = $1 AND status = 'OPEN' ORDER BY id;
+`
+
+// ревью, для которых не нашлось замены; назначения в MERGED PR остаются историей
+const deleteOpenReviewsSQL = `
+DELETE FROM pull_request_reviewer prr
+USING pull_request pr
+WHERE pr.id = prr.pr_id AND prr.user_id = $1 AND pr.status = 'OPEN';
+`
+
+const deleteUserUnavailabilitySQL = `
+DELETE FROM user_unavailability WHERE user_id = $1;
+`
+
+const deleteUserExternalLoginsSQL = `
+DELETE FROM external_login WHERE user_id = $1;
+`
+
+// пользователь без PR и ревью удаляется совсем
+const deleteUserWithoutHistorySQL = `
+DELETE FROM "user" u
+WHERE u.id = $1
+  AND NOT EXISTS (SELECT 1 FROM pull_request WHERE author_id = u.id)
+  AND NOT EXISTS (SELECT 1 FROM pull_request_reviewer WHERE user_id = u.id);
+`
+
+// остальные остаются надгробием: имя для истории, без команды и контактов
+const tombstoneUserSQL = `
+UPDATE "user"
+SET deleted_at = NOW(),
+    is_active = FALSE,
+    team_id = NULL,
+    email = NULL,
+    display_name = NULL,
+    chat_handle = NULL
+WHERE id = $1;
+`
+
+// LockDeletable блокирует пользователя в транзакции tx до его удаления и
+// возвращает USER_NOT_FOUND или *dto.UserHasOpenPRsError, если удалить его
+// нельзя. Блокировка не дает создать PR с этим автором до конца транзакции.
+func (r *UserRepository) LockDeletable(ctx context.Context, tx *sql.Tx, userID string) error {
+	if err := tx.QueryRowContext(ctx, lockUserSQL, userID).Scan(new(bool)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(userNotFoundError)
+		}
+		return err
+	}
+	return checkAuthoredOpenPRs(ctx, tx, userID)
+}
+
+// DeleteUser удаляет заблокированного LockDeletable пользователя в транзакции
+// tx вместе с навыками, отсутствиями, внешними логинами и оставшимися
+// OPEN-ревью и пишет событие user.deleted. Автор PR или ревьюер MERGED PR
+// остается надгробием (deleted_at), чтобы история сохранила ссылки на него.
+func (r *UserRepository) DeleteUser(ctx context.Context, tx *sql.Tx, userID string) error {
+	var user dto.UserDTO
+	if err := tx.QueryRowContext(ctx, selectUserWithTeamSQL, userID).
+		Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive); err != nil {
+		return err
+	}
+	for _, query := range []string{deleteOpenReviewsSQL, deleteUserSkillsSQL, deleteUserUnavailabilitySQL, deleteUserExternalLoginsSQL} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+	res, err := tx.ExecContext(ctx, deleteUserWithoutHistorySQL, userID)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		if _, err := tx.ExecContext(ctx, tombstoneUserSQL, userID); err != nil {
+			return err
+		}
+	}
+	return outbox.Write(ctx, tx, outbox.UserAggregate(userID), outbox.EventUserDeleted, outbox.UserPayload{
+		UserID:   user.UserID,
+		Username: user.Username,
+		TeamName: user.TeamName,
+		IsActive: user.IsActive,
+	})
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func checkAuthoredOpenPRs(ctx context.Context, q querier, userID string) error {
+	rows, err := q.QueryContext(ctx, selectAuthoredOpenPRsSQL, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		return &dto.UserHasOpenPRsError{PullRequestIDs: ids}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package user
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ListUsers возвращает страницу пользователей по фильтру и курсор следующей
+// страницы ("" - страница последняя).
+func ListUsers(ctx context.Context, db *sql.DB, f dto.UserFilter) ([]dto.UserDTO, string, error) {
+	// удаленные пользователи остаются только надгробиями для истории PR
+	where := []string{"u.deleted_at IS NULL"}
+	var args []any
+	if f.TeamName != "" {
+		args = append(args, f.TeamName)
+		where = append(where, fmt.Sprintf("t.name = $%d", len(args)))
+	}
+	if f.ActiveOnly {
+		where = append(where, "u.is_active")
+	}
+
+	key := "u.id"
+	if strings.TrimPrefix(f.Page.Sort, "-") == dto.SortName {
+		key = "u.name"
+	}
+	where, args, order := common.Keyset(where, args, key, "text", "u.id", strings.HasPrefix(f.Page.Sort, "-"), f.Page.After)
+
+	query := `SELECT u.id, u.name, COALESCE(t.name, ''), u.is_active, COALESCE(u.team_id, 0) FROM "user" u LEFT JOIN team t ON t.id = u.team_id`
+	if len(where) > 0 {
+		query += "\nWHERE " + strings.Join(where, " AND ")
+	}
+	query += "\nORDER BY " + order
+	if f.Page.Limit > 0 {
+		query += fmt.Sprintf("\nLIMIT %d", f.Page.Limit+1)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	result := make([]dto.UserDTO, 0)
+	for rows.Next() {
+		var u dto.UserDTO
+		if err := rows.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.TeamID); err != nil {
+			return nil, "", err
+		}
+		result = append(result, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if f.Page.Limit > 0 && len(result) > f.Page.Limit {
+		result = result[:f.Page.Limit]
+		last := result[len(result)-1]
+		next = common.EncodeCursor(dto.Cursor{Sort: f.Page.Sort, Key: last.Username, ID: last.UserID})
+	}
+	return result, next, nil
+}
+
+func (r *UserRepository) ListUsers(ctx context.Context, f dto.UserFilter) ([]dto.UserDTO, string, error) {
+	return ListUsers(ctx, r.db, f)
+}
@@ -0,0 +1,194 @@
+package user
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/outbox"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// события outbox о ревью после курсора ($1, $2) для всех пользователей сразу.
+// Обычно читаются только события транзакций младше pg_snapshot_xmin: они уже
+// зафиксированы или отменены, и событие с меньшим (txid, id) больше не
+// появится. При $3 читаются все зафиксированные события - см. reviewStreamHub.
+const selectReviewEventsSQL = `
+SELECT id, txid::text, event_type, payload, created_at
+FROM outbox_event
+WHERE (txid, id) > ($1::xid8, $2)
+  AND ($3 OR txid < pg_snapshot_xmin(pg_current_snapshot()))
+  AND event_type IN ('pr.created', 'pr.merged', 'reviewer.reassigned')
+ORDER BY txid, id
+LIMIT $4;
+`
+
+// есть ли события, которые ждут, пока xmin их пропустит
+const selectStreamGateSQL = `
+SELECT pg_snapshot_xmin(pg_current_snapshot())::text,
+       EXISTS (SELECT 1 FROM outbox_event WHERE txid >= pg_snapshot_xmin(pg_current_snapshot()));
+`
+
+// событие курсора удаляется очисткой outbox; после него могли быть удалены и
+// следующие события
+const selectEventRetainedSQL = `
+SELECT EXISTS (SELECT 1 FROM outbox_event WHERE id = $1);
+`
+
+// все события транзакций младше xmin уже видны, поэтому поток без
+// Last-Event-ID начинается с (xmin, 0)
+const selectStreamStartSQL = `
+SELECT pg_snapshot_xmin(pg_current_snapshot())::text;
+`
+
+const selectUserExistsSQL = `
+SELECT EXISTS (SELECT 1 FROM "user" WHERE id = $1 AND deleted_at IS NULL);
+`
+
+// ReviewCursor - позиция в потоке событий ревью: транзакция и id события
+// outbox. В SSE передается как "txid-id" в поле id и Last-Event-ID.
+type ReviewCursor struct {
+	TxID uint64
+	ID   int64
+}
+
+func (c ReviewCursor) String() string {
+	return strconv.FormatUint(c.TxID, 10) + "-" + strconv.FormatInt(c.ID, 10)
+}
+
+func (c ReviewCursor) less(o ReviewCursor) bool {
+	return c.TxID < o.TxID || c.TxID == o.TxID && c.ID < o.ID
+}
+
+// ParseReviewCursor разбирает курсор из ReviewCursor.String.
+func ParseReviewCursor(s string) (ReviewCursor, error) {
+	txid, id, ok := strings.Cut(s, "-")
+	if !ok {
+		return ReviewCursor{}, fmt.Errorf("invalid cursor %q", s)
+	}
+	var c ReviewCursor
+	var err error
+	if c.TxID, err = strconv.ParseUint(txid, 10, 64); err != nil {
+		return ReviewCursor{}, fmt.Errorf("invalid cursor %q", s)
+	}
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil || c.ID < 0 {
+		return ReviewCursor{}, fmt.Errorf("invalid cursor %q", s)
+	}
+	return c, nil
+}
+
+func (r *UserRepository) Exists(ctx context.Context, userID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, selectUserExistsSQL, userID).Scan(&exists)
+	return exists, err
+}
+
+// StreamStart возвращает курсор, после которого идут только новые события.
+func (r *UserRepository) StreamStart(ctx context.Context) (ReviewCursor, error) {
+	var xmin string
+	if err := r.db.QueryRowContext(ctx, selectStreamStartSQL).Scan(&xmin); err != nil {
+		return ReviewCursor{}, err
+	}
+	txid, err := strconv.ParseUint(xmin, 10, 64)
+	if err != nil {
+		return ReviewCursor{}, err
+	}
+	return ReviewCursor{TxID: txid}, nil
+}
+
+// StreamGate возвращает текущий xmin и есть ли события транзакций не младше
+// него, которые ReviewEvents без all пока не отдает.
+func (r *UserRepository) StreamGate(ctx context.Context) (uint64, bool, error) {
+	var xmin string
+	var held bool
+	if err := r.db.QueryRowContext(ctx, selectStreamGateSQL).Scan(&xmin, &held); err != nil {
+		return 0, false, err
+	}
+	txid, err := strconv.ParseUint(xmin, 10, 64)
+	return txid, held, err
+}
+
+// CursorRetained сообщает, что событие курсора еще не удалено очисткой outbox
+// и поток можно продолжить с него без пропусков. Курсор начала потока (без
+// события) считается сохраненным.
+func (r *UserRepository) CursorRetained(ctx context.Context, c ReviewCursor) (bool, error) {
+	if c.ID == 0 {
+		return true, nil
+	}
+	var exists bool
+	err := r.db.QueryRowContext(ctx, selectEventRetainedSQL, c.ID).Scan(&exists)
+	return exists, err
+}
+
+type reviewEvent struct {
+	cursor ReviewCursor
+	outbox.Event
+}
+
+// ReviewEvents возвращает события ревью всех пользователей после курсора
+// after в порядке курсора. all снимает ограничение по xmin.
+func (r *UserRepository) ReviewEvents(ctx context.Context, after ReviewCursor, all bool, limit int) ([]reviewEvent, error) {
+	rows, err := r.db.QueryContext(ctx, selectReviewEventsSQL, strconv.FormatUint(after.TxID, 10), after.ID, all, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []reviewEvent
+	for rows.Next() {
+		var e reviewEvent
+		var txid string
+		if err := rows.Scan(&e.ID, &txid, &e.Type, &e.Payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.cursor.ID = e.ID
+		if e.cursor.TxID, err = strconv.ParseUint(txid, 10, 64); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+// reviewStreamEvent переводит событие outbox в событие потока пользователя;
+// false - событие его не касается.
+func reviewStreamEvent(e outbox.Event, userID string) (dto.ReviewStreamEventDTO, bool, error) {
+	ev := dto.ReviewStreamEventDTO{ID: e.ID, UserID: userID, CreatedAt: e.CreatedAt}
+	switch e.Type {
+	case outbox.EventPRCreated, outbox.EventPRMerged:
+		var p outbox.PullRequestPayload
+		if err := e.Decode(&p); err != nil {
+			return ev, false, err
+		}
+		if !slices.Contains(p.Reviewers, userID) {
+			return ev, false, nil
+		}
+		ev.Type = dto.ReviewStreamAssigned
+		if e.Type == outbox.EventPRMerged {
+			ev.Type = dto.ReviewStreamMerged
+		}
+		ev.PullRequestID, ev.PullRequestName, ev.AuthorID = p.PullRequestID, p.Title, p.AuthorID
+		ev.Repository = p.Repository
+		ev.AssignedReviewers = p.Reviewers
+	case outbox.EventReviewerReassigned:
+		var p outbox.ReassignPayload
+		if err := e.Decode(&p); err != nil {
+			return ev, false, err
+		}
+		switch userID {
+		case p.NewReviewerID:
+			ev.Type = dto.ReviewStreamAssigned
+		case p.OldReviewerID:
+			ev.Type = dto.ReviewStreamUnassigned
+			ev.ReplacedBy = p.NewReviewerID
+		default:
+			return ev, false, nil
+		}
+		ev.PullRequestID, ev.PullRequestName, ev.AuthorID = p.PullRequestID, p.Title, p.AuthorID
+		ev.AssignedReviewers = p.Reviewers
+	default:
+		return ev, false, nil
+	}
+	return ev, true, nil
+}
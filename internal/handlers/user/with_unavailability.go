@@ -0,0 +1,91 @@
+package user
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const insertUnavailabilitySQL = `
+INSERT INTO user_unavailability(user_id, starts_at, ends_at, reason) VALUES ($1, $2, $3, $4)
+RETURNING id;
+`
+
+const updateUnavailabilitySQL = `
+UPDATE user_unavailability
+SET starts_at = $2, ends_at = $3, reason = $4, reassigned_at = NULL
+WHERE id = $1
+RETURNING user_id;
+`
+
+const deleteUnavailabilitySQL = `
+DELETE FROM user_unavailability WHERE id = $1;
+`
+
+const selectUnavailabilitySQL = `
+SELECT id, user_id, starts_at, ends_at, reason
+FROM user_unavailability
+WHERE user_id = $1
+ORDER BY starts_at;
+`
+
+func (r *UserRepository) AddUnavailability(ctx context.Context, u dto.UnavailabilityDTO) (*dto.UnavailabilityDTO, error) {
+	err := r.db.QueryRowContext(ctx, insertUnavailabilitySQL, u.UserID, u.StartsAt, u.EndsAt, u.Reason).Scan(&u.ID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
+			return nil, errors.New(userNotFoundError)
+		}
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *UserRepository) UpdateUnavailability(ctx context.Context, u dto.UnavailabilityDTO) (*dto.UnavailabilityDTO, error) {
+	err := r.db.QueryRowContext(ctx, updateUnavailabilitySQL, u.ID, u.StartsAt, u.EndsAt, u.Reason).Scan(&u.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New(dto.ErrorCodeNotFound)
+		}
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *UserRepository) DeleteUnavailability(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, deleteUnavailabilitySQL, id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New(dto.ErrorCodeNotFound)
+	}
+	return nil
+}
+
+func (r *UserRepository) ListUnavailability(ctx context.Context, userID string) ([]dto.UnavailabilityDTO, error) {
+	rows, err := r.db.QueryContext(ctx, selectUnavailabilitySQL, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]dto.UnavailabilityDTO, 0)
+	for rows.Next() {
+		var u dto.UnavailabilityDTO
+		if err := rows.Scan(&u.ID, &u.UserID, &u.StartsAt, &u.EndsAt, &u.Reason); err != nil {
+			return nil, err
+		}
+		result = append(result, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -1,13 +1,18 @@
 package user
 
 import (
+	"AvitoInternship/internal/handlers/common"
 	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"AvitoInternship/internal/outbox"
 
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/lib/pq"
 )
 
 type UserRepository struct {
@@ -24,28 +29,108 @@ SET is_active = $1
 WHERE id = $2;
 `
 
+const selectUserIsActiveForUpdateSQL = `
+SELECT is_active FROM "user" WHERE id = $1 AND deleted_at IS NULL FOR UPDATE;
+`
+
 const selectUserWithTeamSQL = `
 SELECT
     u.id,
     u.name,
-    t.name AS team_name,
+    COALESCE(t.name, '') AS team_name,
     u.is_active
 FROM "user" u
-JOIN team t ON t.id = u.team_id
-WHERE u.id = $1;
+LEFT JOIN team t ON t.id = u.team_id
+WHERE u.id = $1 AND u.deleted_at IS NULL;
+`
+
+const lockUserSQL = `
+SELECT TRUE FROM "user" WHERE id = $1 AND deleted_at IS NULL FOR UPDATE;
 `
-const getReviewSQL = `
+
+const selectUserProfileSQL = `
 SELECT
-    pr.id,
-    pr.title,
-    pr.author_id,
-    pr.status
-FROM pull_request pr
-JOIN pull_request_reviewer prr ON pr.id = prr.pr_id
-WHERE prr.user_id = $1
-ORDER BY pr.id;
+    u.id,
+    u.name,
+    COALESCE(t.name, '') AS team_name,
+    u.is_active,
+    u.time_zone,
+    COALESCE(u.work_start, ''),
+    COALESCE(u.work_end, ''),
+    COALESCE(u.max_open_reviews, 0),
+    COALESCE(u.chat_handle, ''),
+    u.chat_muted,
+    COALESCE(u.email, ''),
+    COALESCE(u.display_name, '')
+FROM "user" u
+LEFT JOIN team t ON t.id = u.team_id
+WHERE u.id = $1 AND u.deleted_at IS NULL;
+`
+
+const updateUserTimeZoneSQL = `
+UPDATE "user" SET time_zone = $2 WHERE id = $1;
+`
+
+const updateUserWorkHoursSQL = `
+UPDATE "user" SET work_start = $2, work_end = $3 WHERE id = $1;
+`
+
+const updateUserMaxOpenReviewsSQL = `
+UPDATE "user" SET max_open_reviews = NULLIF($2, 0) WHERE id = $1;
+`
+
+const updateUserChatHandleSQL = `
+UPDATE "user" SET chat_handle = NULLIF($2, '') WHERE id = $1;
+`
+
+const updateUserChatMutedSQL = `
+UPDATE "user" SET chat_muted = $2 WHERE id = $1;
 `
 
+const updateUserEmailSQL = `
+UPDATE "user" SET email = NULLIF($2, '') WHERE id = $1;
+`
+
+const updateUserNameSQL = `
+UPDATE "user" SET name = $2 WHERE id = $1;
+`
+
+const updateUserDisplayNameSQL = `
+UPDATE "user" SET display_name = NULLIF($2, '') WHERE id = $1;
+`
+
+const selectUserSkillsSQL = `
+SELECT tag FROM user_skill WHERE user_id = $1 ORDER BY tag;
+`
+
+const deleteUserSkillsSQL = `
+DELETE FROM user_skill WHERE user_id = $1;
+`
+
+const insertUserSkillSQL = `
+INSERT INTO user_skill(user_id, tag) VALUES ($1, $2);
+`
+
+const upsertExternalLoginSQL = `
+INSERT INTO external_login(provider, login, user_id) VALUES ($1, $2, $3)
+ON CONFLICT (provider, user_id) DO UPDATE
+SET login = EXCLUDED.login,
+    -- запомненный id относится к прежнему логину
+    external_id = CASE WHEN external_login.login = EXCLUDED.login THEN external_login.external_id END;
+`
+
+const deleteExternalLoginSQL = `
+DELETE FROM external_login WHERE provider = $1 AND user_id = $2;
+`
+
+const selectExternalLoginsSQL = `
+SELECT provider, login FROM external_login WHERE user_id = $1;
+`
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
 func (r *UserRepository) SetIsActive(ctx context.Context, userID string, isActive bool) (*dto.UserDTO, error) {
 	transaction, err := r.db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelReadCommitted,
@@ -57,63 +142,285 @@ func (r *UserRepository) SetIsActive(ctx context.Context, userID string, isActiv
 		_ = transaction.Rollback()
 	}()
 
-	res, err := transaction.ExecContext(ctx, updateUserIsActiveSQL, isActive, userID)
+	user, err := setIsActive(ctx, transaction, userID, isActive)
 	if err != nil {
 		return nil, err
 	}
-	rows, err := res.RowsAffected()
-	if err != nil {
+	if err := transaction.Commit(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// setIsActive меняет флаг активности в транзакции tx и пишет событие
+// user.activated/deactivated, если флаг изменился.
+func setIsActive(ctx context.Context, transaction *sql.Tx, userID string, isActive bool) (*dto.UserDTO, error) {
+	var wasActive bool
+	if err := transaction.QueryRowContext(ctx, selectUserIsActiveForUpdateSQL, userID).Scan(&wasActive); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New(userNotFoundError)
+		}
 		return nil, err
 	}
-	if rows == 0 {
-		return nil, errors.New(userNotFoundError)
+
+	if _, err := transaction.ExecContext(ctx, updateUserIsActiveSQL, isActive, userID); err != nil {
+		return nil, err
 	}
 	var user dto.UserDTO
-	err = transaction.QueryRowContext(ctx, selectUserWithTeamSQL, userID).
+	err := transaction.QueryRowContext(ctx, selectUserWithTeamSQL, userID).
 		Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
 	if err != nil {
 		return nil, err
 	}
+	if wasActive != isActive {
+		eventType := outbox.EventUserDeactivated
+		if isActive {
+			eventType = outbox.EventUserActivated
+		}
+		if err := outbox.Write(ctx, transaction, outbox.UserAggregate(userID), eventType, outbox.UserPayload{
+			UserID:   user.UserID,
+			Username: user.Username,
+			TeamName: user.TeamName,
+			IsActive: user.IsActive,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) SetProfile(ctx context.Context, req dto.SetProfileRequest) (*dto.UserDTO, error) {
+	return r.UpdateUser(ctx, dto.UpdateUserRequest{SetProfileRequest: req})
+}
+
+// UpdateUser меняет флаг активности и поля профиля одной транзакцией:
+// при ошибке в профиле флаг активности и его событие тоже откатываются.
+func (r *UserRepository) UpdateUser(ctx context.Context, req dto.UpdateUserRequest) (*dto.UserDTO, error) {
+	transaction, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = transaction.Rollback()
+	}()
+
+	if req.IsActive != nil {
+		if _, err := setIsActive(ctx, transaction, req.UserID, *req.IsActive); err != nil {
+			return nil, err
+		}
+	}
+	if err := setProfile(ctx, transaction, req.SetProfileRequest); err != nil {
+		return nil, err
+	}
+
+	user, err := selectProfile(ctx, transaction, req.UserID)
+	if err != nil {
+		return nil, err
+	}
 	if err := transaction.Commit(); err != nil {
 		return nil, err
 	}
+	return user, nil
+}
+
+func setProfile(ctx context.Context, transaction *sql.Tx, req dto.SetProfileRequest) error {
+	var exists bool
+	if err := transaction.QueryRowContext(ctx, lockUserSQL, req.UserID).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(userNotFoundError)
+		}
+		return err
+	}
+
+	if req.Skills != nil {
+		if _, err := transaction.ExecContext(ctx, deleteUserSkillsSQL, req.UserID); err != nil {
+			return err
+		}
+		for _, tag := range common.NormalizeTags(req.Skills) {
+			if _, err := transaction.ExecContext(ctx, insertUserSkillSQL, req.UserID, tag); err != nil {
+				return err
+			}
+		}
+	}
+	if req.TimeZone != nil {
+		if _, err := transaction.ExecContext(ctx, updateUserTimeZoneSQL, req.UserID, *req.TimeZone); err != nil {
+			return err
+		}
+	}
+	if req.WorkStart != nil && req.WorkEnd != nil {
+		start := sql.NullString{String: *req.WorkStart, Valid: *req.WorkStart != ""}
+		end := sql.NullString{String: *req.WorkEnd, Valid: *req.WorkEnd != ""}
+		if _, err := transaction.ExecContext(ctx, updateUserWorkHoursSQL, req.UserID, start, end); err != nil {
+			return err
+		}
+	}
+
+	if req.MaxOpenReviews != nil {
+		if _, err := transaction.ExecContext(ctx, updateUserMaxOpenReviewsSQL, req.UserID, *req.MaxOpenReviews); err != nil {
+			return err
+		}
+	}
+
+	if req.ChatHandle != nil {
+		if _, err := transaction.ExecContext(ctx, updateUserChatHandleSQL, req.UserID, *req.ChatHandle); err != nil {
+			return err
+		}
+	}
+	if req.ChatMuted != nil {
+		if _, err := transaction.ExecContext(ctx, updateUserChatMutedSQL, req.UserID, *req.ChatMuted); err != nil {
+			return err
+		}
+	}
+	if req.Email != nil {
+		if _, err := transaction.ExecContext(ctx, updateUserEmailSQL, req.UserID, *req.Email); err != nil {
+			return err
+		}
+	}
+	if req.Username != nil {
+		if _, err := transaction.ExecContext(ctx, updateUserNameSQL, req.UserID, *req.Username); err != nil {
+			return err
+		}
+	}
+	if req.DisplayName != nil {
+		if _, err := transaction.ExecContext(ctx, updateUserDisplayNameSQL, req.UserID, *req.DisplayName); err != nil {
+			return err
+		}
+	}
+	for provider, login := range req.ExternalLogins {
+		l := dto.ExternalLoginDTO{UserID: req.UserID, Provider: provider, Login: login}
+		if _, err := setExternalLogin(ctx, transaction, l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetProfile возвращает пользователя со всеми полями профиля.
+func (r *UserRepository) GetProfile(ctx context.Context, userID string) (*dto.UserDTO, error) {
+	transaction, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = transaction.Rollback()
+	}()
+
+	user, err := selectProfile(ctx, transaction, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New(userNotFoundError)
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func selectProfile(ctx context.Context, tx *sql.Tx, userID string) (*dto.UserDTO, error) {
+	var user dto.UserDTO
+	err := tx.QueryRowContext(ctx, selectUserProfileSQL, userID).
+		Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.TimeZone, &user.WorkStart, &user.WorkEnd, &user.MaxOpenReviews,
+			&user.ChatHandle, &user.ChatMuted, &user.Email, &user.DisplayName)
+	if err != nil {
+		return nil, err
+	}
+	user.Skills, err = selectSkills(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.ExternalLogins, err = selectExternalLogins(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
-func (r *UserRepository) GetReviewPullRequests(ctx context.Context, userID string) ([]dto.PullRequestShortDTO, error) {
-	rows, err := r.db.QueryContext(ctx, getReviewSQL, userID)
+func selectExternalLogins(ctx context.Context, tx *sql.Tx, userID string) (map[string]string, error) {
+	rows, err := tx.QueryContext(ctx, selectExternalLoginsSQL, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	var logins map[string]string
+	for rows.Next() {
+		var provider, login string
+		if err := rows.Scan(&provider, &login); err != nil {
+			return nil, err
+		}
+		if logins == nil {
+			logins = make(map[string]string)
+		}
+		logins[provider] = login
+	}
+	return logins, rows.Err()
+}
 
-	var result []dto.PullRequestShortDTO
-
+func selectSkills(ctx context.Context, tx *sql.Tx, userID string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, selectUserSkillsSQL, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var skills []string
 	for rows.Next() {
-		var pr dto.PullRequestShortDTO
-		if err := rows.Scan(
-			&pr.PullRequestID,
-			&pr.PullRequestName,
-			&pr.AuthorID,
-			&pr.Status,
-		); err != nil {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
 			return nil, err
 		}
-		result = append(result, pr)
+		skills = append(skills, tag)
 	}
+	return skills, rows.Err()
+}
 
-	if err := rows.Err(); err != nil {
+func (r *UserRepository) SetExternalLogin(ctx context.Context, l dto.ExternalLoginDTO) (*dto.ExternalLoginDTO, error) {
+	return setExternalLogin(ctx, r.db, l)
+}
+
+// setExternalLogin привязывает логин или, если он пустой, отвязывает провайдера.
+func setExternalLogin(ctx context.Context, ex execer, l dto.ExternalLoginDTO) (*dto.ExternalLoginDTO, error) {
+	l.Login = strings.ToLower(l.Login)
+	if l.Login == "" {
+		if _, err := ex.ExecContext(ctx, deleteExternalLoginSQL, l.Provider, l.UserID); err != nil {
+			return nil, err
+		}
+		return &l, nil
+	}
+	if _, err := ex.ExecContext(ctx, upsertExternalLoginSQL, l.Provider, l.Login, l.UserID); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			switch pgErr.Code {
+			case "23503":
+				return nil, errors.New(userNotFoundError)
+			case "23505":
+				return nil, errors.New(dto.ErrorCodeLoginTaken)
+			}
+		}
 		return nil, err
 	}
+	return &l, nil
+}
+
+// GetReviewPullRequests возвращает все PR, где пользователь ревьюер, по id PR.
+func (r *UserRepository) GetReviewPullRequests(ctx context.Context, userID, repository string) ([]dto.PullRequestShortDTO, error) {
+	prs, _, err := r.ListReviewPullRequests(ctx, dto.PullRequestFilter{
+		ReviewerID: userID,
+		Repository: repository,
+		Page:       dto.PageRequest{Sort: dto.SortID},
+	})
+	return prs, err
+}
 
-	return result, nil
+// ListReviewPullRequests - постраничный вариант с фильтрами; f.ReviewerID обязателен.
+func (r *UserRepository) ListReviewPullRequests(ctx context.Context, f dto.PullRequestFilter) ([]dto.PullRequestShortDTO, string, error) {
+	return pullRequest.ListPullRequests(ctx, r.db, f)
 }
 
 func (r *UserRepository) GetByID(ctx context.Context, userID string) (*dto.UserDTO, error) {
 	var id, name string
 	var teamID int
 	var isActive bool
-	row := r.db.QueryRowContext(ctx, `SELECT id, name, team_id, is_active FROM "user" WHERE id = $1`, userID)
+	row := r.db.QueryRowContext(ctx, `SELECT id, name, COALESCE(team_id, 0), is_active FROM "user" WHERE id = $1`, userID)
 	if err := row.Scan(&id, &name, &teamID, &isActive); err != nil {
 		return nil, err
 	}
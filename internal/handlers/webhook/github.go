@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"AvitoInternship/internal/integrations/github"
+)
+
+// GitHub - POST /webhooks/github
+func GitHub(db *sql.DB, secret string, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	p := newProcessor(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readPayload(w, r)
+		if !ok {
+			return
+		}
+		if !github.VerifySignature(secret, body, r.Header.Get("X-Hub-Signature-256")) {
+			common.WriteError(w, http.StatusUnauthorized, dto.ErrorCodeInvalidSignature, "invalid webhook signature")
+			return
+		}
+
+		event := r.Header.Get("X-GitHub-Event")
+		switch event {
+		case "ping":
+			common.WriteJSON(w, http.StatusOK, dto.WebhookResultDTO{Status: dto.WebhookStatusIgnored, Reason: "ping"})
+			return
+		case "pull_request":
+		default:
+			common.WriteJSON(w, http.StatusOK, dto.WebhookResultDTO{Status: dto.WebhookStatusIgnored, Reason: "unsupported event " + event})
+			return
+		}
+
+		var payload github.PullRequestEvent
+		if err := json.Unmarshal(body, &payload); err != nil {
+			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "invalid pull_request payload")
+			return
+		}
+		if payload.Repository.FullName == "" || payload.PullRequest.Number == 0 {
+			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "repository.full_name and pull_request.number are required")
+			return
+		}
+
+		deliveryID := r.Header.Get("X-GitHub-Delivery")
+		p.deliver(w, r, github.Provider, deliveryID, event, func(ctx context.Context) (dto.WebhookResultDTO, error) {
+			return p.handleGitHubPullRequest(ctx, payload)
+		})
+	}
+}
+
+func (p *processor) handleGitHubPullRequest(ctx context.Context, ev github.PullRequestEvent) (dto.WebhookResultDTO, error) {
+	gh := ev.PullRequest
+	prID := github.PullRequestID(ev.Repository.FullName, gh.Number)
+
+	switch ev.Action {
+	case "opened", "reopened", "ready_for_review":
+		// черновики ревьюеров не получают, ждем ready_for_review
+		if gh.Draft {
+			return ignored(prID, "draft pull request"), nil
+		}
+		tags := make([]string, 0, len(gh.Labels))
+		for _, l := range gh.Labels {
+			tags = append(tags, l.Name)
+		}
+		return p.open(ctx, github.Provider, gh.User.Login, dto.PullRequestDTO{
+			PullRequestID:   prID,
+			PullRequestName: gh.Title,
+			Repository:      ev.Repository.FullName,
+			RequiredTags:    tags,
+		})
+	case "closed":
+		// закрытый без merge PR остается в сервисе: при reopened ревьюеры те же
+		if !gh.Merged {
+			return ignored(prID, "closed without merge"), nil
+		}
+		return p.merge(ctx, prID)
+	default:
+		return ignored(prID, "unsupported action "+ev.Action), nil
+	}
+}
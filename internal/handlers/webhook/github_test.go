@@ -0,0 +1,163 @@
+package webhook
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const testSecret = "webhook-secret"
+
+func newGitHubRequest(t *testing.T, event, fixture, deliveryID string) *http.Request {
+	t.Helper()
+	body := []byte("{}")
+	if fixture != "" {
+		var err error
+		if body, err = os.ReadFile(filepath.Join("testdata", fixture)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write(body)
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(body))
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	r.Header.Set("X-GitHub-Event", event)
+	r.Header.Set("X-GitHub-Delivery", deliveryID)
+	return r
+}
+
+func serveGitHub(t *testing.T, r *http.Request, expect func(mock sqlmock.Sqlmock)) (int, dto.WebhookResultDTO) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if expect != nil {
+		expect(mock)
+	}
+	w := httptest.NewRecorder()
+	GitHub(db, testSecret, nil).ServeHTTP(w, r)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	var res dto.WebhookResultDTO
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	return w.Code, res
+}
+
+func expectDelivery(mock sqlmock.Sqlmock, deliveryID string, fresh bool) {
+	var affected int64
+	if fresh {
+		affected = 1
+	}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_delivery")).
+		WithArgs("github", deliveryID, "pull_request").
+		WillReturnResult(sqlmock.NewResult(0, affected))
+}
+
+func TestGitHubRejectsInvalidSignature(t *testing.T) {
+	r := newGitHubRequest(t, "pull_request", "github_pull_request_opened.json", "d1")
+	r.Header.Set("X-Hub-Signature-256", "sha256=00")
+	code, _ := serveGitHub(t, r, nil)
+	if code != http.StatusUnauthorized {
+		t.Errorf("code = %d, want 401", code)
+	}
+}
+
+func TestGitHubIgnoresPingAndOtherEvents(t *testing.T) {
+	for _, event := range []string{"ping", "push"} {
+		code, res := serveGitHub(t, newGitHubRequest(t, event, "", "d1"), nil)
+		if code != http.StatusOK || res.Status != dto.WebhookStatusIgnored {
+			t.Errorf("%s: got %d %+v", event, code, res)
+		}
+	}
+}
+
+func TestGitHubIgnoresDraft(t *testing.T) {
+	code, res := serveGitHub(t, newGitHubRequest(t, "pull_request", "github_pull_request_draft.json", "d2"), func(mock sqlmock.Sqlmock) {
+		expectDelivery(mock, "d2", true)
+	})
+	if code != http.StatusOK || res.Status != dto.WebhookStatusIgnored || res.PullRequestID != "acme/backend#43" {
+		t.Errorf("got %d %+v", code, res)
+	}
+}
+
+func TestGitHubDuplicateDelivery(t *testing.T) {
+	code, res := serveGitHub(t, newGitHubRequest(t, "pull_request", "github_pull_request_opened.json", "d3"), func(mock sqlmock.Sqlmock) {
+		expectDelivery(mock, "d3", false)
+	})
+	if code != http.StatusOK || res.Status != dto.WebhookStatusDuplicate {
+		t.Errorf("got %d %+v", code, res)
+	}
+}
+
+func TestGitHubOpenedByUnknownLogin(t *testing.T) {
+	code, res := serveGitHub(t, newGitHubRequest(t, "pull_request", "github_pull_request_opened.json", "d4"), func(mock sqlmock.Sqlmock) {
+		expectDelivery(mock, "d4", true)
+		// логин приводится к нижнему регистру
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT user_id FROM external_login")).
+			WithArgs("github", "octocat").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	})
+	if code != http.StatusOK || res.Status != dto.WebhookStatusIgnored || res.Reason != "unknown author login Octocat" {
+		t.Errorf("got %d %+v", code, res)
+	}
+}
+
+func TestGitHubMerged(t *testing.T) {
+	code, res := serveGitHub(t, newGitHubRequest(t, "pull_request", "github_pull_request_merged.json", "d5"), func(mock sqlmock.Sqlmock) {
+		expectDelivery(mock, "d5", true)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT status FROM pull_request WHERE id = $1 FOR UPDATE")).
+			WithArgs("acme/backend#42").
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("OPEN"))
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE pull_request SET status = $1")).
+			WithArgs("MERGED", "acme/backend#42").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id", "status", "created_at", "updated_at", "repository"}).
+				AddRow("acme/backend#42", "Add rate limiter", "u1", "MERGED", nil, nil, "acme/backend"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT user_id FROM pull_request_reviewer")).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("u2"))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_event")).
+			WithArgs("pr:acme/backend#42", "pr.merged", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	})
+	if code != http.StatusOK || res.Status != dto.WebhookStatusMerged || res.PullRequestID != "acme/backend#42" {
+		t.Errorf("got %d %+v", code, res)
+	}
+}
+
+func TestGitHubClosedWithoutMergeIsIgnored(t *testing.T) {
+	code, res := serveGitHub(t, newGitHubRequest(t, "pull_request", "github_pull_request_closed.json", "d6"), func(mock sqlmock.Sqlmock) {
+		// PR и его ревьюеры не трогаются
+		expectDelivery(mock, "d6", true)
+	})
+	if code != http.StatusOK || res.Status != dto.WebhookStatusIgnored || res.Reason != "closed without merge" {
+		t.Errorf("got %d %+v", code, res)
+	}
+}
+
+func TestGitHubFailureForgetsDelivery(t *testing.T) {
+	code, _ := serveGitHub(t, newGitHubRequest(t, "pull_request", "github_pull_request_merged.json", "d8"), func(mock sqlmock.Sqlmock) {
+		expectDelivery(mock, "d8", true)
+		mock.ExpectBegin().WillReturnError(sqlmock.ErrCancelled)
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM webhook_delivery")).
+			WithArgs("github", "d8").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	})
+	if code != http.StatusInternalServerError {
+		t.Errorf("code = %d, want 500", code)
+	}
+}
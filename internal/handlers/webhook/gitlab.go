@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"AvitoInternship/internal/integrations/gitlab"
+)
+
+const gitlabMergeRequestHook = "Merge Request Hook"
+
+// GitLab - POST /webhooks/gitlab
+func GitLab(db *sql.DB, token string, listener pullRequest.AssignmentListener) http.HandlerFunc {
+	p := newProcessor(db, listener)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readPayload(w, r)
+		if !ok {
+			return
+		}
+		if !gitlab.VerifyToken(token, r.Header.Get("X-Gitlab-Token")) {
+			common.WriteError(w, http.StatusUnauthorized, dto.ErrorCodeInvalidSignature, "invalid webhook token")
+			return
+		}
+
+		event := r.Header.Get("X-Gitlab-Event")
+		if event != gitlabMergeRequestHook {
+			common.WriteJSON(w, http.StatusOK, dto.WebhookResultDTO{Status: dto.WebhookStatusIgnored, Reason: "unsupported event " + event})
+			return
+		}
+
+		var payload gitlab.MergeRequestEvent
+		if err := json.Unmarshal(body, &payload); err != nil {
+			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "invalid merge request payload")
+			return
+		}
+		if payload.Project.PathWithNamespace == "" || payload.ObjectAttributes.IID == 0 {
+			common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "project.path_with_namespace and object_attributes.iid are required")
+			return
+		}
+
+		// Idempotency-Key появился в новых версиях GitLab, X-Gitlab-Event-UUID - в более ранних
+		deliveryID := r.Header.Get("Idempotency-Key")
+		if deliveryID == "" {
+			deliveryID = r.Header.Get("X-Gitlab-Event-UUID")
+		}
+		p.deliver(w, r, gitlab.Provider, deliveryID, event, func(ctx context.Context) (dto.WebhookResultDTO, error) {
+			return p.handleGitLabMergeRequest(ctx, payload)
+		})
+	}
+}
+
+func (p *processor) handleGitLabMergeRequest(ctx context.Context, ev gitlab.MergeRequestEvent) (dto.WebhookResultDTO, error) {
+	mr := ev.ObjectAttributes
+	prID := gitlab.MergeRequestID(ev.Project.PathWithNamespace, mr.IID)
+
+	switch mr.Action {
+	case "open", "reopen":
+		if mr.IsDraft() {
+			return ignored(prID, "draft merge request"), nil
+		}
+		return p.openGitLab(ctx, ev, prID)
+	case "update":
+		draft, changed := ev.Changes.DraftToggle()
+		if !changed {
+			return ignored(prID, "update without draft toggle"), nil
+		}
+		if draft {
+			return ignored(prID, "marked as draft"), nil
+		}
+		return p.openGitLab(ctx, ev, prID)
+	case "merge":
+		return p.merge(ctx, prID)
+	case "close":
+		return ignored(prID, "closed without merge"), nil
+	default:
+		return ignored(prID, "unsupported action "+mr.Action), nil
+	}
+}
+
+func (p *processor) openGitLab(ctx context.Context, ev gitlab.MergeRequestEvent, prID string) (dto.WebhookResultDTO, error) {
+	authorID, err := p.resolveGitLabAuthor(ctx, ev)
+	if err != nil {
+		if err.Error() == dto.ErrorCodeUserNotFound {
+			return ignored(prID, fmt.Sprintf("unknown author id %d", ev.ObjectAttributes.AuthorID)), nil
+		}
+		return dto.WebhookResultDTO{}, err
+	}
+	tags := make([]string, 0, len(ev.Labels))
+	for _, l := range ev.Labels {
+		tags = append(tags, l.Title)
+	}
+	return p.create(ctx, dto.PullRequestDTO{
+		PullRequestID:   prID,
+		PullRequestName: ev.ObjectAttributes.Title,
+		AuthorID:        authorID,
+		Repository:      ev.Project.PathWithNamespace,
+		RequiredTags:    tags,
+	})
+}
+
+// resolveGitLabAuthor находит автора MR по object_attributes.author_id. В событии
+// нет логина автора, поэтому id запоминается у привязанного логина, когда
+// автор сам совершает действие (обычно это open): user.id совпадает с author_id.
+func (p *processor) resolveGitLabAuthor(ctx context.Context, ev gitlab.MergeRequestEvent) (string, error) {
+	authorID := ev.ObjectAttributes.AuthorID
+	if authorID == 0 {
+		return "", errors.New(dto.ErrorCodeUserNotFound)
+	}
+	userID, err := p.repo.ResolveExternalID(ctx, gitlab.Provider, authorID)
+	if err == nil || err.Error() != dto.ErrorCodeUserNotFound || ev.User.ID != authorID {
+		return userID, err
+	}
+	if userID, err = p.repo.ResolveLogin(ctx, gitlab.Provider, ev.User.Username); err != nil {
+		return "", err
+	}
+	if err := p.repo.RememberExternalID(ctx, gitlab.Provider, ev.User.Username, authorID); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
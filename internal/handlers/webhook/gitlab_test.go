@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const testToken = "gitlab-token"
+
+func serveGitLab(t *testing.T, fixture, uuid string, expect func(mock sqlmock.Sqlmock)) (int, dto.WebhookResultDTO) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/gitlab", bytes.NewReader(body))
+	r.Header.Set("X-Gitlab-Token", testToken)
+	r.Header.Set("X-Gitlab-Event", gitlabMergeRequestHook)
+	r.Header.Set("X-Gitlab-Event-UUID", uuid)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_delivery")).
+		WithArgs("gitlab", uuid, gitlabMergeRequestHook).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expect(mock)
+
+	w := httptest.NewRecorder()
+	GitLab(db, testToken, nil).ServeHTTP(w, r)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	var res dto.WebhookResultDTO
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	return w.Code, res
+}
+
+// expectCreateFails обрывает создание PR на первом запросе: тесты проверяют,
+// кто стал автором, а не назначение ревьюеров.
+func expectCreateFails(mock sqlmock.Sqlmock, authorID string) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(team_id, 0) FROM "user" WHERE id = $1`)).
+		WithArgs(authorID).
+		WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM webhook_delivery")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestGitLabAuthorIsNotTheActor(t *testing.T) {
+	// MR открыл root от имени пользователя 51: автором должен стать владелец
+	// id 51, а не root
+	code, res := serveGitLab(t, "gitlab_merge_request_open.json", "e1", func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT user_id FROM external_login WHERE provider = $1 AND external_id = $2")).
+			WithArgs("gitlab", int64(51)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("u51"))
+		expectCreateFails(mock, "u51")
+	})
+	if code != http.StatusInternalServerError {
+		t.Errorf("got %d %+v", code, res)
+	}
+}
+
+func TestGitLabUnknownAuthorID(t *testing.T) {
+	code, res := serveGitLab(t, "gitlab_merge_request_open.json", "e2", func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT user_id FROM external_login WHERE provider = $1 AND external_id = $2")).
+			WithArgs("gitlab", int64(51)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	})
+	if code != http.StatusOK || res.Status != dto.WebhookStatusIgnored || res.Reason != "unknown author id 51" {
+		t.Errorf("got %d %+v", code, res)
+	}
+}
+
+func TestGitLabAuthorIDLearnedFromLogin(t *testing.T) {
+	code, res := serveGitLab(t, "gitlab_merge_request_open_by_author.json", "e3", func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT user_id FROM external_login WHERE provider = $1 AND external_id = $2")).
+			WithArgs("gitlab", int64(51)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT user_id FROM external_login WHERE provider = $1 AND login = $2")).
+			WithArgs("gitlab", "ipetrov").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("u1"))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE external_login SET external_id = $3")).
+			WithArgs("gitlab", "ipetrov", int64(51)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectCreateFails(mock, "u1")
+	})
+	if code != http.StatusInternalServerError {
+		t.Errorf("got %d %+v", code, res)
+	}
+}
@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+)
+
+// GitHub ограничивает размер полезной нагрузки 25 МБ
+const maxPayloadSize = 25 << 20
+
+// processor переводит события код-хостингов в операции сервиса
+type processor struct {
+	repo   *WebhookRepository
+	prRepo *pullRequest.PullRequestRepository
+}
+
+func newProcessor(db *sql.DB, listener pullRequest.AssignmentListener) *processor {
+	prRepo := pullRequest.NewPullRequestRepository(db)
+	prRepo.SetListener(listener)
+	return &processor{repo: NewWebhookRepository(db), prRepo: prRepo}
+}
+
+func readPayload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		common.WriteError(w, http.StatusMethodNotAllowed, dto.ErrorMethodNotAllowed, "method not allowed")
+		return nil, false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		common.WriteError(w, http.StatusBadRequest, dto.ErrorBadRequest, "invalid request body")
+		return nil, false
+	}
+	return body, true
+}
+
+// open создает PR в сервисе от имени автора с логином login на код-хостинге.
+func (p *processor) open(ctx context.Context, provider, login string, pr dto.PullRequestDTO) (dto.WebhookResultDTO, error) {
+	authorID, err := p.repo.ResolveLogin(ctx, provider, login)
+	if err != nil {
+		if err.Error() == dto.ErrorCodeUserNotFound {
+			return ignored(pr.PullRequestID, "unknown author login "+login), nil
+		}
+		return dto.WebhookResultDTO{}, err
+	}
+	pr.AuthorID = authorID
+	return p.create(ctx, pr)
+}
+
+func (p *processor) create(ctx context.Context, pr dto.PullRequestDTO) (dto.WebhookResultDTO, error) {
+	if _, err := p.prRepo.CreatePullRequest(ctx, pr); err != nil {
+		if err.Error() == dto.ErrorCodePRExists {
+			return ignored(pr.PullRequestID, "pull request already exists"), nil
+		}
+		return dto.WebhookResultDTO{}, err
+	}
+	return dto.WebhookResultDTO{Status: dto.WebhookStatusCreated, PullRequestID: pr.PullRequestID}, nil
+}
+
+func (p *processor) merge(ctx context.Context, prID string) (dto.WebhookResultDTO, error) {
+	if _, err := p.prRepo.MergePullRequest(ctx, prID); err != nil {
+		if err.Error() == dto.ErrorCodeNotFound {
+			return ignored(prID, "pull request is not tracked"), nil
+		}
+		return dto.WebhookResultDTO{}, err
+	}
+	return dto.WebhookResultDTO{Status: dto.WebhookStatusMerged, PullRequestID: prID}, nil
+}
+
+// deliver выполняет handle не более одного раза для каждой доставки. Если
+// обработка завершилась ошибкой, отметка снимается и доставку можно повторить.
+func (p *processor) deliver(w http.ResponseWriter, r *http.Request, provider, deliveryID, event string, handle func(ctx context.Context) (dto.WebhookResultDTO, error)) {
+	ctx := r.Context()
+	if deliveryID != "" {
+		fresh, err := p.repo.RecordDelivery(ctx, provider, deliveryID, event)
+		if err != nil {
+			common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to record delivery")
+			return
+		}
+		if !fresh {
+			common.WriteJSON(w, http.StatusOK, dto.WebhookResultDTO{Status: dto.WebhookStatusDuplicate})
+			return
+		}
+	}
+
+	res, err := handle(ctx)
+	if err != nil {
+		if deliveryID != "" {
+			if ferr := p.repo.ForgetDelivery(context.WithoutCancel(ctx), provider, deliveryID); ferr != nil {
+				log.Printf("webhook %s: failed to forget delivery %s: %v", provider, deliveryID, ferr)
+			}
+		}
+		var noCandidate *dto.NoCandidateError
+		if errors.As(err, &noCandidate) {
+			common.WriteErrorDetails(w, http.StatusConflict, dto.ErrorCodeNoCandidate, "no active reviewer candidate in team", noCandidate.Skipped)
+			return
+		}
+		if err.Error() == dto.ErrorCodeNotFound {
+			common.WriteError(w, http.StatusNotFound, dto.ErrorCodeNotFound, "resource not found")
+			return
+		}
+		log.Printf("webhook %s: delivery %s failed: %v", provider, deliveryID, err)
+		common.WriteError(w, http.StatusInternalServerError, dto.ErrorInternalError, "failed to process webhook")
+		return
+	}
+	common.WriteJSON(w, http.StatusOK, res)
+}
+
+func ignored(prID, reason string) dto.WebhookResultDTO {
+	return dto.WebhookResultDTO{Status: dto.WebhookStatusIgnored, PullRequestID: prID, Reason: reason}
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"AvitoInternship/internal/handlers/dto"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const selectUserByLoginSQL = `
+SELECT user_id FROM external_login WHERE provider = $1 AND login = $2;
+`
+
+const selectUserByExternalIDSQL = `
+SELECT user_id FROM external_login WHERE provider = $1 AND external_id = $2;
+`
+
+const updateExternalIDSQL = `
+UPDATE external_login SET external_id = $3 WHERE provider = $1 AND login = $2;
+`
+
+const insertDeliverySQL = `
+INSERT INTO webhook_delivery(provider, delivery_id, event) VALUES ($1, $2, $3)
+ON CONFLICT (provider, delivery_id) DO NOTHING;
+`
+
+const deleteDeliverySQL = `
+DELETE FROM webhook_delivery WHERE provider = $1 AND delivery_id = $2;
+`
+
+// ResolveLogin возвращает id пользователя по логину на код-хостинге.
+func (r *WebhookRepository) ResolveLogin(ctx context.Context, provider, login string) (string, error) {
+	var userID string
+	err := r.db.QueryRowContext(ctx, selectUserByLoginSQL, provider, strings.ToLower(login)).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errors.New(dto.ErrorCodeUserNotFound)
+		}
+		return "", err
+	}
+	return userID, nil
+}
+
+// ResolveExternalID возвращает id пользователя по его числовому id на
+// код-хостинге, запомненному RememberExternalID.
+func (r *WebhookRepository) ResolveExternalID(ctx context.Context, provider string, externalID int64) (string, error) {
+	var userID string
+	err := r.db.QueryRowContext(ctx, selectUserByExternalIDSQL, provider, externalID).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errors.New(dto.ErrorCodeUserNotFound)
+		}
+		return "", err
+	}
+	return userID, nil
+}
+
+// RememberExternalID связывает числовой id на код-хостинге с привязанным логином.
+func (r *WebhookRepository) RememberExternalID(ctx context.Context, provider, login string, externalID int64) error {
+	_, err := r.db.ExecContext(ctx, updateExternalIDSQL, provider, strings.ToLower(login), externalID)
+	return err
+}
+
+// RecordDelivery запоминает доставку и возвращает false, если она уже обрабатывалась.
+func (r *WebhookRepository) RecordDelivery(ctx context.Context, provider, deliveryID, event string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, insertDeliverySQL, provider, deliveryID, event)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ForgetDelivery удаляет отметку о доставке, чтобы повторная отправка после
+// ошибки обработки не была отброшена как дубликат.
+func (r *WebhookRepository) ForgetDelivery(ctx context.Context, provider, deliveryID string) error {
+	_, err := r.db.ExecContext(ctx, deleteDeliverySQL, provider, deliveryID)
+	return err
+}
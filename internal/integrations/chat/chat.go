@@ -0,0 +1,91 @@
+// Package chat отправляет уведомления в Slack или Mattermost через
+// incoming webhook.
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const (
+	FormatSlack      = "slack"
+	FormatMattermost = "mattermost"
+)
+
+// slackUserID - идентификатор участника Slack (профиль → Copy member ID).
+// Упоминание <@...> срабатывает только с ним, имя пользователя не подходит.
+var slackUserID = regexp.MustCompile(`^[UW][A-Z0-9]{2,}$`)
+
+// Client публикует сообщения в incoming webhook. Форматы отличаются способом
+// адресации: Mattermost умеет переопределять канал на личный ("@user"),
+// в Slack получатель упоминается в тексте по идентификатору участника.
+type Client struct {
+	url    string
+	format string
+	http   *http.Client
+}
+
+func NewClient(url, format string) (*Client, error) {
+	switch format {
+	case "":
+		format = FormatSlack
+	case FormatSlack, FormatMattermost:
+	default:
+		return nil, fmt.Errorf("unknown chat format %q", format)
+	}
+	return &Client{url: url, format: format, http: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+type mattermostMessage struct {
+	Text    string `json:"text"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// Addressable сообщает, можно ли адресовать сообщение handle: для Slack это
+// должен быть идентификатор участника, для Mattermost - имя пользователя.
+func (c *Client) Addressable(handle string) bool {
+	if c.format == FormatSlack {
+		return slackUserID.MatchString(handle)
+	}
+	return handle != ""
+}
+
+// Send отправляет text пользователю handle (без "@").
+func (c *Client) Send(ctx context.Context, handle, text string) error {
+	var msg any
+	switch c.format {
+	case FormatMattermost:
+		msg = mattermostMessage{Text: text, Channel: "@" + handle}
+	default:
+		msg = slackMessage{Text: "<@" + handle + "> " + text}
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("chat webhook: status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		handle string
+		want   map[string]string
+	}{
+		{FormatSlack, "U024BE7LH", map[string]string{"text": "<@U024BE7LH> hello"}},
+		{FormatMattermost, "ivan", map[string]string{"text": "hello", "channel": "@ivan"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var got map[string]string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q", ct)
+				}
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Errorf("bad body %s: %v", body, err)
+				}
+			}))
+			defer srv.Close()
+
+			c, err := NewClient(srv.URL, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Send(context.Background(), tt.handle, "hello"); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("payload = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestSendErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c, _ := NewClient(srv.URL, FormatSlack)
+	if err := c.Send(context.Background(), "U1", "hello"); err == nil {
+		t.Fatal("expected error on 500")
+	}
+}
+
+func TestAddressable(t *testing.T) {
+	slack, _ := NewClient("http://chat", FormatSlack)
+	mattermost, _ := NewClient("http://chat", FormatMattermost)
+	tests := []struct {
+		client *Client
+		handle string
+		want   bool
+	}{
+		{slack, "U024BE7LH", true},
+		{slack, "W012A3CDE", true},
+		{slack, "ivan", false},
+		{slack, "u024be7lh", false},
+		{mattermost, "ivan", true},
+		{mattermost, "", false},
+	}
+	for _, tt := range tests {
+		if got := tt.client.Addressable(tt.handle); got != tt.want {
+			t.Errorf("%s.Addressable(%q) = %v, want %v", tt.client.format, tt.handle, got, tt.want)
+		}
+	}
+}
+
+func TestNewClientUnknownFormat(t *testing.T) {
+	if _, err := NewClient("http://chat", "teams"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
@@ -0,0 +1,156 @@
+package chat
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"log"
+	"slices"
+	"time"
+)
+
+// Выбранные сообщения откладываются на время аренды и фиксируются сразу, как в
+// subscriptions.Deliverer: запросы в чат идут без открытой транзакции.
+const claimMessagesSQL = `
+UPDATE chat_message
+SET next_attempt_at = NOW() + make_interval(secs => $2)
+WHERE id IN (
+    SELECT id FROM chat_message
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY id
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, handle, text, attempts;
+`
+
+// время, на которое выбранное сообщение скрыто от других обработчиков
+const claimLease = 5 * time.Minute
+
+const markSentSQL = `
+UPDATE chat_message
+SET status = 'delivered', attempts = attempts + 1, last_error = NULL, delivered_at = NOW()
+WHERE id = $1;
+`
+
+const markRetrySQL = `
+UPDATE chat_message
+SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + make_interval(secs => $3)
+WHERE id = $1;
+`
+
+const markFailedSQL = `
+UPDATE chat_message
+SET status = 'failed', attempts = attempts + 1, last_error = $2
+WHERE id = $1;
+`
+
+// Deliverer отправляет сообщения из очереди chat_message. У каждого сообщения
+// свой счетчик попыток: недоступный получатель не задерживает остальных,
+// а уже отправленные сообщения не повторяются. После attempts неудачных
+// попыток сообщение получает статус failed.
+type Deliverer struct {
+	db         *sql.DB
+	client     *Client
+	interval   time.Duration
+	batchSize  int
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func NewDeliverer(db *sql.DB, client *Client, interval time.Duration, attempts int, backoff time.Duration) *Deliverer {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &Deliverer{
+		db:         db,
+		client:     client,
+		interval:   interval,
+		batchSize:  50,
+		attempts:   attempts,
+		backoff:    backoff,
+		maxBackoff: time.Hour,
+	}
+}
+
+func (d *Deliverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		n, err := d.RunOnce(ctx)
+		if err != nil {
+			log.Println("chat: delivery failed:", err)
+		}
+		if err == nil && n == d.batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type message struct {
+	id       int64
+	handle   string
+	text     string
+	attempts int
+}
+
+// RunOnce отправляет одну пачку сообщений и возвращает их число.
+func (d *Deliverer) RunOnce(ctx context.Context) (int, error) {
+	batch, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// результат записывается, даже если ctx отменен посередине отправки
+	wctx := context.WithoutCancel(ctx)
+	for _, m := range batch {
+		sendErr := d.client.Send(ctx, m.handle, m.text)
+		switch {
+		case sendErr == nil:
+			_, err = d.db.ExecContext(wctx, markSentSQL, m.id)
+		case m.attempts+1 >= d.attempts:
+			log.Printf("chat: message %d to %s gave up after %d attempts: %v", m.id, m.handle, m.attempts+1, sendErr)
+			_, err = d.db.ExecContext(wctx, markFailedSQL, m.id, sendErr.Error())
+		default:
+			_, err = d.db.ExecContext(wctx, markRetrySQL, m.id, sendErr.Error(), d.delay(m.attempts).Seconds())
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(batch), nil
+}
+
+func (d *Deliverer) claim(ctx context.Context) ([]message, error) {
+	rows, err := d.db.QueryContext(ctx, claimMessagesSQL, d.batchSize, claimLease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var batch []message
+	for rows.Next() {
+		var m message
+		if err := rows.Scan(&m.id, &m.handle, &m.text, &m.attempts); err != nil {
+			return nil, err
+		}
+		batch = append(batch, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(batch, func(a, b message) int { return cmp.Compare(a.id, b.id) })
+	return batch, nil
+}
+
+func (d *Deliverer) delay(attempts int) time.Duration {
+	b := d.backoff
+	for i := 0; i < attempts && b < d.maxBackoff; i++ {
+		b *= 2
+	}
+	return min(b, d.maxBackoff)
+}
@@ -0,0 +1,131 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"AvitoInternship/internal/outbox"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestNotifierEnqueuesAddressableRecipients(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	client, _ := NewClient("http://chat", FormatSlack)
+	n, err := NewNotifier(db, client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, _ := json.Marshal(outbox.PullRequestPayload{
+		PullRequestID: "pr-1",
+		Title:         "Fix",
+		AuthorID:      "u1",
+		Reviewers:     []string{"u2", "u3"},
+	})
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, chat_handle FROM "user"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "chat_handle"}).
+			AddRow("u2", "Bob", "U0BOB").
+			AddRow("u3", "Eve", "eve"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO chat_message")).
+		WithArgs(int64(7), "u2", "U0BOB", "Bob, вас назначили ревьюером PR pr-1 «Fix» (автор u1)").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = n.Publish(context.Background(), outbox.Event{ID: 7, Type: outbox.EventPRCreated, Payload: payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDelivererRetriesOnlyFailedMessages(t *testing.T) {
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slackMessage
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		sent = append(sent, msg.Text)
+		if msg.Text == "<@U2> second" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	client, _ := NewClient(srv.URL, FormatSlack)
+	d := NewDeliverer(db, client, time.Second, 3, time.Second)
+
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE chat_message")).
+		WithArgs(50, claimLease.Seconds()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "handle", "text", "attempts"}).
+			AddRow(3, "U3", "third", 2).
+			AddRow(1, "U1", "first", 0).
+			AddRow(2, "U2", "second", 0))
+	mock.ExpectExec(regexp.QuoteMeta("SET status = 'delivered'")).
+		WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SET attempts = attempts + 1, last_error = $2, next_attempt_at")).
+		WithArgs(int64(2), "chat webhook: status 502", 1.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SET status = 'delivered'")).
+		WithArgs(int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := d.RunOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("n = %d, want 3", n)
+	}
+	want := []string{"<@U1> first", "<@U2> second", "<@U3> third"}
+	if len(sent) != len(want) {
+		t.Fatalf("sent = %v, want %v", sent, want)
+	}
+	for i := range want {
+		if sent[i] != want[i] {
+			t.Errorf("sent[%d] = %q, want %q", i, sent[i], want[i])
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDelivererGivesUp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	client, _ := NewClient(srv.URL, FormatSlack)
+	d := NewDeliverer(db, client, time.Second, 3, time.Second)
+
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE chat_message")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "handle", "text", "attempts"}).AddRow(5, "U5", "hi", 2))
+	mock.ExpectExec(regexp.QuoteMeta("SET status = 'failed'")).
+		WithArgs(int64(5), "chat webhook: status 404").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := d.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,161 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+
+	"AvitoInternship/internal/outbox"
+
+	"github.com/lib/pq"
+)
+
+const selectRecipientsSQL = `
+SELECT id, name, chat_handle FROM "user"
+WHERE id = ANY($1) AND chat_handle IS NOT NULL AND NOT chat_muted;
+`
+
+const enqueueMessageSQL = `
+INSERT INTO chat_message(event_id, user_id, handle, text)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (event_id, user_id) DO NOTHING;
+`
+
+// Шаблоны по умолчанию; CHAT_TEMPLATE_FILE может переопределить любой из них
+// через {{define "assigned"}}, {{define "reminder"}} и {{define "merged"}}.
+const defaultTemplates = `
+{{define "assigned"}}{{.Recipient}}, вас назначили ревьюером PR {{.PullRequestID}} «{{.Title}}» (автор {{.AuthorID}}){{if .Repository}} в {{.Repository}}{{end}}{{end}}
+{{define "reminder"}}{{.Recipient}}, PR {{.PullRequestID}} «{{.Title}}» (автор {{.AuthorID}}) все еще ждет вашего ревью{{end}}
+{{define "merged"}}{{.Recipient}}, ваш PR {{.PullRequestID}} «{{.Title}}» смержен{{end}}
+`
+
+// MessageData - данные, доступные в шаблонах сообщений.
+type MessageData struct {
+	Recipient     string
+	PullRequestID string
+	Title         string
+	AuthorID      string
+	Repository    string
+	Reviewers     []string
+}
+
+// Notifier реализует outbox.Sink: сообщает ревьюерам о назначении при
+// создании PR и переназначении, напоминает о зависших ревью и сообщает
+// автору о merge. Пользователи без chat_handle или с chat_muted пропускаются.
+// Сообщения не отправляются сразу, а кладутся в очередь chat_message, откуда
+// их отправляет Deliverer; повторная публикация события идемпотентна
+// благодаря уникальности (event_id, user_id).
+type Notifier struct {
+	db     *sql.DB
+	client *Client
+	tmpl   *template.Template
+}
+
+func NewNotifier(db *sql.DB, client *Client, templateFile string) (*Notifier, error) {
+	tmpl, err := template.New("chat").Parse(defaultTemplates)
+	if err != nil {
+		return nil, err
+	}
+	if templateFile != "" {
+		content, err := os.ReadFile(templateFile)
+		if err != nil {
+			return nil, err
+		}
+		if tmpl, err = tmpl.Parse(string(content)); err != nil {
+			return nil, err
+		}
+	}
+	return &Notifier{db: db, client: client, tmpl: tmpl}, nil
+}
+
+func (n *Notifier) Publish(ctx context.Context, e outbox.Event) error {
+	switch e.Type {
+	case outbox.EventPRCreated:
+		var p outbox.PullRequestPayload
+		if err := e.Decode(&p); err != nil {
+			return err
+		}
+		return n.notify(ctx, e.ID, "assigned", p.Reviewers, pullRequestData(p))
+	case outbox.EventReviewerReassigned:
+		var p outbox.ReassignPayload
+		if err := e.Decode(&p); err != nil {
+			return err
+		}
+		return n.notify(ctx, e.ID, "assigned", []string{p.NewReviewerID}, MessageData{
+			PullRequestID: p.PullRequestID,
+			Title:         p.Title,
+			AuthorID:      p.AuthorID,
+			Reviewers:     p.Reviewers,
+		})
+	case outbox.EventReviewerReminder:
+		var p outbox.ReminderPayload
+		if err := e.Decode(&p); err != nil {
+			return err
+		}
+		return n.notify(ctx, e.ID, "reminder", []string{p.ReviewerID}, MessageData{
+			PullRequestID: p.PullRequestID,
+			Title:         p.Title,
+			AuthorID:      p.AuthorID,
+		})
+	case outbox.EventPRMerged:
+		var p outbox.PullRequestPayload
+		if err := e.Decode(&p); err != nil {
+			return err
+		}
+		return n.notify(ctx, e.ID, "merged", []string{p.AuthorID}, pullRequestData(p))
+	}
+	return nil
+}
+
+func pullRequestData(p outbox.PullRequestPayload) MessageData {
+	return MessageData{
+		PullRequestID: p.PullRequestID,
+		Title:         p.Title,
+		AuthorID:      p.AuthorID,
+		Repository:    p.Repository,
+		Reviewers:     p.Reviewers,
+	}
+}
+
+func (n *Notifier) notify(ctx context.Context, eventID int64, name string, userIDs []string, data MessageData) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	rows, err := n.db.QueryContext(ctx, selectRecipientsSQL, pq.Array(userIDs))
+	if err != nil {
+		return err
+	}
+	type recipient struct{ id, name, handle string }
+	var recipients []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.id, &r.name, &r.handle); err != nil {
+			rows.Close()
+			return err
+		}
+		recipients = append(recipients, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range recipients {
+		if !n.client.Addressable(r.handle) {
+			log.Printf("chat: skip %s: handle %q is not addressable in %s", r.id, r.handle, n.client.format)
+			continue
+		}
+		data.Recipient = r.name
+		var text strings.Builder
+		if err := n.tmpl.ExecuteTemplate(&text, name, data); err != nil {
+			return err
+		}
+		if _, err := n.db.ExecContext(ctx, enqueueMessageSQL, eventID, r.id, r.handle, strings.TrimSpace(text.String())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
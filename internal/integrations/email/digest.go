@@ -0,0 +1,57 @@
+package email
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/digest.txt templates/digest.html
+var templatesFS embed.FS
+
+// DigestReview - PR в дайджесте; Age отсчитывается от создания PR.
+type DigestReview struct {
+	PullRequestID string
+	Title         string
+	AuthorID      string
+	Repository    string
+	Age           time.Duration
+}
+
+type DigestData struct {
+	Username string
+	Reviews  []DigestReview
+}
+
+var funcs = map[string]any{"age": formatAge}
+
+var (
+	digestText = texttemplate.Must(texttemplate.New("digest.txt").Funcs(funcs).ParseFS(templatesFS, "templates/digest.txt"))
+	digestHTML = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(funcs).ParseFS(templatesFS, "templates/digest.html"))
+)
+
+// RenderDigest возвращает текстовую и HTML-версии дайджеста.
+func RenderDigest(data DigestData) (text, html string, err error) {
+	var t, h strings.Builder
+	if err := digestText.Execute(&t, data); err != nil {
+		return "", "", err
+	}
+	if err := digestHTML.Execute(&h, data); err != nil {
+		return "", "", err
+	}
+	return t.String(), h.String(), nil
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%d д %d ч", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%d ч", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d мин", int(d.Minutes()))
+	}
+}
@@ -0,0 +1,141 @@
+// Package email собирает письма (text + HTML) и отправляет их по SMTP или,
+// в режиме dry-run, складывает в каталог.
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender отправляет одно письмо.
+type Sender interface {
+	Send(msg Message) error
+}
+
+// Build собирает письмо multipart/alternative в формате RFC 5322.
+func Build(msg Message, date time.Time) ([]byte, error) {
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		qp := quotedprintable.NewWriter(&buf)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
+
+func randomBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SMTPSender отправляет письма через SMTP-сервер. Если username пустой,
+// аутентификация не используется.
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+}
+
+func NewSMTPSender(host string, port int, username, password string) *SMTPSender {
+	s := &SMTPSender{addr: net.JoinHostPort(host, fmt.Sprint(port))}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	data, err := Build(msg, time.Now())
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.addr, s.auth, msg.From, []string{msg.To}, data)
+}
+
+// DirSender - режим dry-run: каждое письмо сохраняется в dir как
+// <время>-<адресат>-<суффикс>.eml.
+type DirSender struct {
+	dir string
+}
+
+func NewDirSender(dir string) *DirSender {
+	return &DirSender{dir: dir}
+}
+
+func (s *DirSender) Send(msg Message) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	now := time.Now()
+	data, err := Build(msg, now)
+	if err != nil {
+		return err
+	}
+	// случайный суффикс от CreateTemp не дает письмам одному адресату в одну
+	// секунду затереть друг друга
+	f, err := os.CreateTemp(s.dir, fmt.Sprintf("%s-%s-*.eml", now.Format("20060102-150405"), sanitize(msg.To)))
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,125 @@
+package email
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMultipart(t *testing.T) {
+	msg := Message{From: "bot@example.com", To: "ivan@example.com", Subject: "Ревью", Text: "текст", HTML: "<p>html</p>"}
+	data, err := Build(msg, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+	if got := m.Header.Get("Date"); got != "Tue, 02 Jan 2024 03:04:05 +0000" {
+		t.Errorf("Date = %q", got)
+	}
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v)", m.Header.Get("Content-Type"), err)
+	}
+
+	r := multipart.NewReader(m.Body, params["boundary"])
+	want := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, w := range want {
+		part, err := r.NextRawPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := part.Header.Get("Content-Type"); got != w.contentType {
+			t.Errorf("part Content-Type = %q, want %q", got, w.contentType)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimRight(string(body), "\r\n"); got != w.body {
+			t.Errorf("part body = %q, want %q", got, w.body)
+		}
+	}
+	if _, err := r.NextPart(); err != io.EOF {
+		t.Errorf("expected exactly two parts, got %v", err)
+	}
+}
+
+func TestDirSenderDoesNotOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	s := NewDirSender(filepath.Join(dir, "out"))
+	msg := Message{From: "bot@example.com", To: "ivan <ivan@example.com>", Subject: "s", Text: "t", HTML: "h"}
+	// три письма одному адресату укладываются в одну секунду
+	for i := 0; i < 3; i++ {
+		if err := s.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("files = %d, want 3", len(entries))
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".eml") || !strings.Contains(name, "-ivan__ivan@example.com_-") {
+			t.Errorf("unexpected file name %q", name)
+		}
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	text, html, err := RenderDigest(DigestData{
+		Username: "Ivan",
+		Reviews: []DigestReview{
+			{PullRequestID: "pr-1", Title: "Fix <b>", AuthorID: "u2", Repository: "org/repo", Age: 26 * time.Hour},
+			{PullRequestID: "pr-2", Title: "Docs", AuthorID: "u3", Age: 90 * time.Minute},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Ivan, у вас 2 PR", "pr-1 «Fix <b>» от u2 (org/repo), ждет 1 д 2 ч", "pr-2 «Docs» от u3, ждет 1 ч"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text digest lacks %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(html, "Fix <b>") || !strings.Contains(html, "Fix &lt;b&gt;") {
+		t.Errorf("html digest does not escape title:\n%s", html)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{5 * time.Minute, "5 мин"},
+		{time.Hour, "1 ч"},
+		{23*time.Hour + 59*time.Minute, "23 ч"},
+		{49 * time.Hour, "2 д 1 ч"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
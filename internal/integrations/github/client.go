@@ -0,0 +1,96 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.github.com"
+
+// Client - минимальный клиент GitHub REST API. Базовый адрес настраивается,
+// чтобы его можно было направить на GitHub Enterprise или локальный фейковый сервер.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// APIError - ответ GitHub с кодом не из диапазона 2xx
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("github api: status %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable сообщает, имеет ли смысл повторять запрос: ошибки сервера и
+// ограничение частоты запросов - да, остальные 4xx - нет.
+func (e *APIError) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests || (e.StatusCode == http.StatusForbidden && strings.Contains(e.Body, "rate limit"))
+}
+
+type reviewersBody struct {
+	Reviewers []string `json:"reviewers"`
+}
+
+// RequestReviewers - POST /repos/{owner}/{repo}/pulls/{number}/requested_reviewers
+func (c *Client) RequestReviewers(ctx context.Context, owner, repo string, number int, logins []string) error {
+	return c.do(ctx, http.MethodPost, requestedReviewersPath(owner, repo, number), reviewersBody{Reviewers: logins})
+}
+
+// RemoveRequestedReviewers - DELETE /repos/{owner}/{repo}/pulls/{number}/requested_reviewers
+func (c *Client) RemoveRequestedReviewers(ctx context.Context, owner, repo string, number int, logins []string) error {
+	return c.do(ctx, http.MethodDelete, requestedReviewersPath(owner, repo, number), reviewersBody{Reviewers: logins})
+}
+
+func requestedReviewersPath(owner, repo string, number int) string {
+	return fmt.Sprintf("/repos/%s/%s/pulls/%d/requested_reviewers", owner, repo, number)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,84 @@
+// Package github содержит типы и вспомогательные функции для работы с GitHub:
+// проверку подписи вебхуков и формат идентификаторов PR.
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const Provider = "github"
+
+type User struct {
+	Login string `json:"login"`
+}
+
+type Label struct {
+	Name string `json:"name"`
+}
+
+type PullRequest struct {
+	Number int     `json:"number"`
+	Title  string  `json:"title"`
+	State  string  `json:"state"`
+	Draft  bool    `json:"draft"`
+	Merged bool    `json:"merged"`
+	User   User    `json:"user"`
+	Labels []Label `json:"labels"`
+}
+
+type Repository struct {
+	FullName string `json:"full_name"`
+}
+
+// PullRequestEvent - полезная нагрузка события pull_request
+type PullRequestEvent struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+	Repository  Repository  `json:"repository"`
+}
+
+// VerifySignature проверяет заголовок X-Hub-Signature-256 ("sha256=<hex>").
+func VerifySignature(secret string, body []byte, header string) bool {
+	if secret == "" {
+		return false
+	}
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// PullRequestID строит идентификатор PR в сервисе: "<owner>/<repo>#<number>".
+func PullRequestID(fullName string, number int) string {
+	return fmt.Sprintf("%s#%d", fullName, number)
+}
+
+// ParsePullRequestID разбирает идентификатор, построенный PullRequestID.
+func ParsePullRequestID(id string) (owner, repo string, number int, ok bool) {
+	fullName, num, found := strings.Cut(id, "#")
+	if !found {
+		return "", "", 0, false
+	}
+	owner, repo, found = strings.Cut(fullName, "/")
+	if !found || owner == "" || repo == "" {
+		return "", "", 0, false
+	}
+	number, err := strconv.Atoi(num)
+	if err != nil || number <= 0 {
+		return "", "", 0, false
+	}
+	return owner, repo, number, true
+}
@@ -0,0 +1,40 @@
+package github
+
+import "testing"
+
+func TestVerifySignature(t *testing.T) {
+	// пример из документации GitHub "Validating webhook deliveries"
+	body := []byte("Hello, World!")
+	const valid = "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		want   bool
+	}{
+		{"valid", "It's a Secret to Everybody", valid, true},
+		{"wrong secret", "another secret", valid, false},
+		{"empty secret", "", valid, false},
+		{"missing prefix", "It's a Secret to Everybody", valid[len("sha256="):], false},
+		{"sha1 header", "It's a Secret to Everybody", "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59", false},
+		{"not hex", "It's a Secret to Everybody", "sha256=zz", false},
+		{"empty header", "It's a Secret to Everybody", "", false},
+	}
+	for _, tt := range tests {
+		if got := VerifySignature(tt.secret, body, tt.header); got != tt.want {
+			t.Errorf("%s: VerifySignature = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParsePullRequestID(t *testing.T) {
+	owner, repo, number, ok := ParsePullRequestID(PullRequestID("acme/backend", 42))
+	if !ok || owner != "acme" || repo != "backend" || number != 42 {
+		t.Errorf("round trip = %s %s %d %v", owner, repo, number, ok)
+	}
+	for _, id := range []string{"acme/backend", "acme#1", "/backend#1", "acme/backend#0", "acme/backend#x"} {
+		if _, _, _, ok := ParsePullRequestID(id); ok {
+			t.Errorf("ParsePullRequestID(%q): expected failure", id)
+		}
+	}
+}
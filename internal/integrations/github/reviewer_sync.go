@@ -0,0 +1,195 @@
+package github
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	actionRequest = "request"
+	actionRemove  = "remove"
+)
+
+const selectLoginsSQL = `
+SELECT login FROM external_login WHERE provider = $1 AND user_id = ANY($2) ORDER BY login;
+`
+
+const insertDeadLetterSQL = `
+INSERT INTO github_sync_dead_letter(pr_id, action, logins, error, attempts) VALUES ($1, $2, $3, $4, $5);
+`
+
+// размер очереди одного обработчика
+const syncQueueSize = 256
+
+// ReviewerSync переносит назначения ревьюеров в GitHub после того, как они
+// зафиксированы в базе. Вызовы асинхронные: действия разбирают workers
+// обработчиков, действия одного PR выполняются одним обработчиком по порядку.
+// Запросы к API повторяются с экспоненциальной задержкой, а после исчерпания
+// попыток (или если очередь переполнена) попадают в github_sync_dead_letter.
+// PR, созданные не из GitHub, пропускаются.
+type ReviewerSync struct {
+	db       *sql.DB
+	client   *Client
+	attempts int
+	backoff  time.Duration
+	timeout  time.Duration
+	queues   []chan syncAction
+	pending  sync.WaitGroup
+}
+
+// syncAction - одно действие с ревьюерами PR
+type syncAction struct {
+	prID    string
+	action  string
+	userIDs []string
+}
+
+func NewReviewerSync(db *sql.DB, client *Client, attempts int, backoff time.Duration, workers int) *ReviewerSync {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	s := &ReviewerSync{
+		db:       db,
+		client:   client,
+		attempts: attempts,
+		backoff:  backoff,
+		timeout:  time.Minute,
+		queues:   make([]chan syncAction, workers),
+	}
+	for i := range s.queues {
+		s.queues[i] = make(chan syncAction, syncQueueSize)
+		go s.work(s.queues[i])
+	}
+	return s
+}
+
+func (s *ReviewerSync) ReviewersAssigned(prID string, reviewerIDs []string) {
+	if len(reviewerIDs) == 0 {
+		return
+	}
+	s.enqueue(syncAction{prID: prID, action: actionRequest, userIDs: reviewerIDs})
+}
+
+// ReviewerReplaced снимает старого ревьюера и запрашивает нового независимо:
+// ошибка одного действия не отменяет другое.
+func (s *ReviewerSync) ReviewerReplaced(prID, oldReviewerID, newReviewerID string) {
+	s.enqueue(syncAction{prID: prID, action: actionRemove, userIDs: []string{oldReviewerID}})
+	s.enqueue(syncAction{prID: prID, action: actionRequest, userIDs: []string{newReviewerID}})
+}
+
+// Wait ждет, пока будут обработаны все поставленные в очередь действия.
+func (s *ReviewerSync) Wait() {
+	s.pending.Wait()
+}
+
+func (s *ReviewerSync) enqueue(a syncAction) {
+	if _, _, _, ok := ParsePullRequestID(a.prID); !ok {
+		return
+	}
+	h := fnv.New32a()
+	h.Write([]byte(a.prID))
+	s.pending.Add(1)
+	select {
+	case s.queues[h.Sum32()%uint32(len(s.queues))] <- a:
+	default:
+		defer s.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		logins, err := s.logins(ctx, a.userIDs)
+		if err == nil && len(logins) > 0 {
+			s.deadLetter(ctx, a.prID, a.action, logins, errors.New("sync queue is full"), 0)
+		}
+		log.Printf("github sync: PR %s: queue is full, %s skipped", a.prID, a.action)
+	}
+}
+
+func (s *ReviewerSync) work(queue <-chan syncAction) {
+	for a := range queue {
+		s.run(a)
+		s.pending.Done()
+	}
+}
+
+func (s *ReviewerSync) run(a syncAction) {
+	owner, repo, number, _ := ParsePullRequestID(a.prID)
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	if err := s.apply(ctx, a.prID, a.action, owner, repo, number, a.userIDs); err != nil {
+		log.Printf("github sync: PR %s: %s: %v", a.prID, a.action, err)
+	}
+}
+
+// apply выполняет одно действие с повторами; при окончательной ошибке
+// записывает его в dead-letter таблицу.
+func (s *ReviewerSync) apply(ctx context.Context, prID, action, owner, repo string, number int, userIDs []string) error {
+	logins, err := s.logins(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	if len(logins) == 0 {
+		return nil
+	}
+
+	var lastErr error
+	attempt := 0
+retry:
+	for attempt < s.attempts {
+		attempt++
+		switch action {
+		case actionRemove:
+			lastErr = s.client.RemoveRequestedReviewers(ctx, owner, repo, number, logins)
+		default:
+			lastErr = s.client.RequestReviewers(ctx, owner, repo, number, logins)
+		}
+		if lastErr == nil {
+			return nil
+		}
+		var apiErr *APIError
+		if errors.As(lastErr, &apiErr) && !apiErr.Retryable() {
+			break
+		}
+		if attempt < s.attempts {
+			select {
+			case <-ctx.Done():
+				break retry
+			case <-time.After(s.backoff << (attempt - 1)):
+			}
+		}
+	}
+
+	s.deadLetter(ctx, prID, action, logins, lastErr, attempt)
+	return lastErr
+}
+
+func (s *ReviewerSync) deadLetter(ctx context.Context, prID, action string, logins []string, cause error, attempts int) {
+	if _, err := s.db.ExecContext(context.WithoutCancel(ctx), insertDeadLetterSQL, prID, action, pq.Array(logins), cause.Error(), attempts); err != nil {
+		log.Printf("github sync: PR %s: failed to store dead letter: %v", prID, err)
+	}
+}
+
+func (s *ReviewerSync) logins(ctx context.Context, userIDs []string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, selectLoginsSQL, Provider, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var logins []string
+	for rows.Next() {
+		var login string
+		if err := rows.Scan(&login); err != nil {
+			return nil, err
+		}
+		logins = append(logins, login)
+	}
+	return logins, rows.Err()
+}
@@ -0,0 +1,109 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// fakeGitHub - локальный сервер вместо api.github.com: записывает вызовы
+// requested_reviewers и отвечает статусами из очереди (по умолчанию 201).
+type fakeGitHub struct {
+	mu       sync.Mutex
+	calls    []string
+	statuses map[string][]int
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body reviewersBody
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	call := r.Method + " " + r.URL.Path + " " + body.Reviewers[0]
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	status := http.StatusCreated
+	if q := f.statuses[r.Method]; len(q) > 0 {
+		status, f.statuses[r.Method] = q[0], q[1:]
+	}
+	f.mu.Unlock()
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"message":"fake"}`))
+}
+
+func newTestSync(t *testing.T, fake *fakeGitHub, attempts int) (*ReviewerSync, sqlmock.Sqlmock) {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mock.MatchExpectationsInOrder(false)
+	return NewReviewerSync(db, NewClient(srv.URL, "token"), attempts, 0, 2), mock
+}
+
+func expectLogin(mock sqlmock.Sqlmock, login string) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT login FROM external_login")).
+		WillReturnRows(sqlmock.NewRows([]string{"login"}).AddRow(login))
+}
+
+func TestReviewerReplacedRequestsNewAfterFailedRemove(t *testing.T) {
+	fake := &fakeGitHub{statuses: map[string][]int{http.MethodDelete: {http.StatusUnprocessableEntity}}}
+	s, mock := newTestSync(t, fake, 3)
+	expectLogin(mock, "old")
+	expectLogin(mock, "new")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO github_sync_dead_letter")).
+		WithArgs("acme/backend#7", actionRemove, sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	s.ReviewerReplaced("acme/backend#7", "u-old", "u-new")
+	s.Wait()
+
+	want := []string{
+		"DELETE /repos/acme/backend/pulls/7/requested_reviewers old",
+		"POST /repos/acme/backend/pulls/7/requested_reviewers new",
+	}
+	if !slices.Equal(fake.calls, want) {
+		t.Errorf("calls = %v, want %v", fake.calls, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviewersAssignedRetriesServerErrors(t *testing.T) {
+	fake := &fakeGitHub{statuses: map[string][]int{http.MethodPost: {http.StatusBadGateway, http.StatusTooManyRequests}}}
+	s, mock := newTestSync(t, fake, 3)
+	expectLogin(mock, "octocat")
+
+	s.ReviewersAssigned("acme/backend#8", []string{"u1"})
+	s.Wait()
+
+	if len(fake.calls) != 3 {
+		t.Errorf("calls = %v, want 3 attempts", fake.calls)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReviewersAssignedSkipsForeignPullRequests(t *testing.T) {
+	fake := &fakeGitHub{}
+	s, mock := newTestSync(t, fake, 1)
+
+	s.ReviewersAssigned("pr-1001", []string{"u1"})
+	s.Wait()
+
+	if len(fake.calls) != 0 {
+		t.Errorf("calls = %v, want none", fake.calls)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
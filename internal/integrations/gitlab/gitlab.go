@@ -0,0 +1,82 @@
+// Package gitlab содержит типы и вспомогательные функции для вебхуков GitLab.
+package gitlab
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+const Provider = "gitlab"
+
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type Project struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+}
+
+type Label struct {
+	Title string `json:"title"`
+}
+
+type MergeRequest struct {
+	IID            int    `json:"iid"`
+	AuthorID       int64  `json:"author_id"`
+	Title          string `json:"title"`
+	State          string `json:"state"`
+	Action         string `json:"action"`
+	Draft          bool   `json:"draft"`
+	WorkInProgress bool   `json:"work_in_progress"`
+}
+
+type BoolChange struct {
+	Previous bool `json:"previous"`
+	Current  bool `json:"current"`
+}
+
+type Changes struct {
+	Draft          *BoolChange `json:"draft"`
+	WorkInProgress *BoolChange `json:"work_in_progress"`
+}
+
+// MergeRequestEvent - полезная нагрузка "Merge Request Hook". User - тот, кто
+// совершил действие, автор MR указан только как ObjectAttributes.AuthorID.
+type MergeRequestEvent struct {
+	ObjectKind       string       `json:"object_kind"`
+	User             User         `json:"user"`
+	Project          Project      `json:"project"`
+	ObjectAttributes MergeRequest `json:"object_attributes"`
+	Labels           []Label      `json:"labels"`
+	Changes          Changes      `json:"changes"`
+}
+
+// IsDraft учитывает и новое поле draft, и устаревшее work_in_progress.
+func (mr MergeRequest) IsDraft() bool {
+	return mr.Draft || mr.WorkInProgress
+}
+
+// DraftToggle сообщает, менялся ли в событии признак черновика, и его новое значение.
+func (c Changes) DraftToggle() (current bool, changed bool) {
+	if c.Draft != nil {
+		return c.Draft.Current, true
+	}
+	if c.WorkInProgress != nil {
+		return c.WorkInProgress.Current, true
+	}
+	return false, false
+}
+
+// VerifyToken сравнивает X-Gitlab-Token с ожидаемым секретом за постоянное время.
+func VerifyToken(secret, header string) bool {
+	if secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(header)) == 1
+}
+
+// MergeRequestID строит идентификатор PR в сервисе: "<group>/<project>!<iid>".
+func MergeRequestID(pathWithNamespace string, iid int) string {
+	return fmt.Sprintf("%s!%d", pathWithNamespace, iid)
+}
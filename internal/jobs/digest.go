@@ -0,0 +1,123 @@
+package jobs
+
+import (
+	"AvitoInternship/internal/handlers/common"
+	"AvitoInternship/internal/integrations/email"
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// те же OPEN-ревью, что отдает /users/getReview, сразу по всем активным
+// пользователям с email
+const selectDigestReviewsSQL = `
+SELECT u.id, u.name, u.email, pr.id, pr.title, pr.author_id, COALESCE(pr.repository, ''),
+       EXTRACT(EPOCH FROM (LOCALTIMESTAMP - pr.created_at))::BIGINT
+FROM "user" u
+JOIN pull_request_reviewer prr ON prr.user_id = u.id
+JOIN pull_request pr ON pr.id = prr.pr_id
+WHERE u.is_active = TRUE AND u.email IS NOT NULL AND pr.status = 'OPEN'
+ORDER BY u.id, pr.created_at, pr.id;
+`
+
+// DigestMailer раз в сутки в заданное время рассылает каждому активному
+// пользователю список его OPEN-ревью. Пользователи без ревью писем не получают.
+type DigestMailer struct {
+	db     *sql.DB
+	sender email.Sender
+	from   string
+	at     int // минуты от полуночи
+	loc    *time.Location
+}
+
+// NewDigestMailer принимает время рассылки в формате "HH:MM" в часовом поясе loc.
+func NewDigestMailer(db *sql.DB, sender email.Sender, from, at string, loc *time.Location) (*DigestMailer, error) {
+	minutes, err := common.ParseClock(at)
+	if err != nil {
+		return nil, err
+	}
+	return &DigestMailer{db: db, sender: sender, from: from, at: minutes, loc: loc}, nil
+}
+
+func (j *DigestMailer) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(time.Until(j.next(time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := j.RunOnce(ctx); err != nil {
+			log.Println("digest failed:", err)
+		}
+	}
+}
+
+func (j *DigestMailer) next(now time.Time) time.Time {
+	local := now.In(j.loc)
+	at := time.Date(local.Year(), local.Month(), local.Day(), j.at/60, j.at%60, 0, 0, j.loc)
+	if !at.After(local) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at
+}
+
+// RunOnce собирает и отправляет дайджесты. Ошибка отправки одному
+// пользователю логируется и не мешает остальным.
+func (j *DigestMailer) RunOnce(ctx context.Context) error {
+	type recipient struct {
+		address string
+		data    email.DigestData
+	}
+	rows, err := j.db.QueryContext(ctx, selectDigestReviewsSQL)
+	if err != nil {
+		return err
+	}
+	var recipients []*recipient
+	byUser := map[string]*recipient{}
+	for rows.Next() {
+		var userID, name, address string
+		var rev email.DigestReview
+		var ageSeconds int64
+		if err := rows.Scan(&userID, &name, &address, &rev.PullRequestID, &rev.Title, &rev.AuthorID, &rev.Repository, &ageSeconds); err != nil {
+			rows.Close()
+			return err
+		}
+		rev.Age = time.Duration(ageSeconds) * time.Second
+		rcpt, ok := byUser[userID]
+		if !ok {
+			rcpt = &recipient{address: address, data: email.DigestData{Username: name}}
+			byUser[userID] = rcpt
+			recipients = append(recipients, rcpt)
+		}
+		rcpt.data.Reviews = append(rcpt.data.Reviews, rev)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sent := 0
+	for _, rcpt := range recipients {
+		text, html, err := email.RenderDigest(rcpt.data)
+		if err != nil {
+			return err
+		}
+		err = j.sender.Send(email.Message{
+			From:    j.from,
+			To:      rcpt.address,
+			Subject: "PR в ожидании вашего ревью",
+			Text:    text,
+			HTML:    html,
+		})
+		if err != nil {
+			log.Printf("digest: %s: %v", rcpt.address, err)
+			continue
+		}
+		sent++
+	}
+	log.Printf("digest: sent %d of %d", sent, len(recipients))
+	return nil
+}
@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"AvitoInternship/internal/integrations/email"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type fakeSender struct {
+	sent []email.Message
+	fail map[string]bool
+}
+
+func (s *fakeSender) Send(msg email.Message) error {
+	if s.fail[msg.To] {
+		return errors.New("smtp down")
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestDigestGroupsReviewsByUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sender := &fakeSender{fail: map[string]bool{"eve@example.com": true}}
+	j, err := NewDigestMailer(db, sender, "bot@example.com", "09:00", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cols := []string{"id", "name", "email", "pr_id", "title", "author_id", "repository", "age"}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT u.id, u.name, u.email")).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow("u1", "Bob", "bob@example.com", "pr-1", "One", "u9", "", 3600).
+			AddRow("u1", "Bob", "bob@example.com", "pr-2", "Two", "u9", "", 7200).
+			AddRow("u2", "Eve", "eve@example.com", "pr-3", "Three", "u9", "", 60).
+			AddRow("u3", "Ann", "ann@example.com", "pr-1", "One", "u9", "", 3600))
+
+	if err := j.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	// письмо Eve не ушло, но Ann все равно получила свое
+	if len(sender.sent) != 2 {
+		t.Fatalf("sent %d messages, want 2", len(sender.sent))
+	}
+	bob := sender.sent[0]
+	if bob.To != "bob@example.com" || bob.From != "bot@example.com" {
+		t.Errorf("first message to %s from %s", bob.To, bob.From)
+	}
+	if !strings.Contains(bob.Text, "у вас 2 PR") || !strings.Contains(bob.Text, "pr-2 «Two»") {
+		t.Errorf("bob digest:\n%s", bob.Text)
+	}
+	if sender.sent[1].To != "ann@example.com" {
+		t.Errorf("second message to %s, want ann@example.com", sender.sent[1].To)
+	}
+}
+
+func TestDigestNext(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*3600)
+	j, err := NewDigestMailer(nil, nil, "", "09:30", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		now, want time.Time
+	}{
+		{time.Date(2024, 5, 1, 5, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 9, 30, 0, 0, loc)},
+		{time.Date(2024, 5, 1, 6, 30, 0, 0, time.UTC), time.Date(2024, 5, 2, 9, 30, 0, 0, loc)},
+		{time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC), time.Date(2024, 5, 2, 9, 30, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := j.next(tt.now); !got.Equal(tt.want) {
+			t.Errorf("next(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package jobs
+
+import (
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/handlers/pullRequest"
+	"AvitoInternship/internal/outbox"
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// SLA берется из команды PR (или команды автора), а если у команды он не
+// задан (NULL) - из значения по умолчанию $1. 0 и у команды, и по умолчанию
+// отключает этап.
+const selectReviewsToRemindSQL = `
+SELECT prr.pr_id, pr.title, pr.author_id, prr.user_id, prr.assigned_at
+FROM pull_request_reviewer prr
+JOIN pull_request pr ON pr.id = prr.pr_id
+LEFT JOIN "user" a ON a.id = pr.author_id
+LEFT JOIN team t ON t.id = COALESCE(pr.team_id, a.team_id)
+WHERE pr.status = 'OPEN' AND prr.reminded_at IS NULL
+  AND COALESCE(t.review_reminder_hours, $1) > 0
+  AND prr.assigned_at <= NOW() - make_interval(hours => COALESCE(t.review_reminder_hours, $1))
+ORDER BY prr.assigned_at
+FOR UPDATE OF prr SKIP LOCKED;
+`
+
+const selectReviewsToEscalateSQL = `
+SELECT prr.pr_id, prr.user_id
+FROM pull_request_reviewer prr
+JOIN pull_request pr ON pr.id = prr.pr_id
+LEFT JOIN "user" a ON a.id = pr.author_id
+LEFT JOIN team t ON t.id = COALESCE(pr.team_id, a.team_id)
+WHERE pr.status = 'OPEN' AND prr.escalated_at IS NULL
+  AND COALESCE(t.review_escalation_hours, $1) > 0
+  AND prr.assigned_at <= NOW() - make_interval(hours => COALESCE(t.review_escalation_hours, $1))
+ORDER BY prr.assigned_at;
+`
+
+const markRemindedSQL = `
+UPDATE pull_request_reviewer SET reminded_at = NOW() WHERE pr_id = $1 AND user_id = $2;
+`
+
+const markEscalationFailedSQL = `
+UPDATE pull_request_reviewer SET escalated_at = NOW() WHERE pr_id = $1 AND user_id = $2;
+`
+
+const insertEscalationSQL = `
+INSERT INTO review_escalation(pr_id, reviewer_id, action, new_reviewer_id, error) VALUES ($1, $2, $3, $4, $5);
+`
+
+// StaleReviewEscalator следит за SLA на ревью: по истечении первого порога
+// пишет событие reviewer.reminder, по истечении второго переназначает ревью
+// по правилам PullRequestService.Reassign. Каждый шаг попадает в
+// review_escalation. Неудачная эскалация (например, NO_CANDIDATE) не
+// повторяется.
+type StaleReviewEscalator struct {
+	db              *sql.DB
+	svc             *pullRequest.PullRequestService
+	interval        time.Duration
+	reminderHours   int
+	escalationHours int
+}
+
+func NewStaleReviewEscalator(db *sql.DB, interval time.Duration, reminderHours, escalationHours int, listener pullRequest.AssignmentListener) *StaleReviewEscalator {
+	return &StaleReviewEscalator{
+		db:              db,
+		svc:             pullRequest.NewService(db, listener),
+		interval:        interval,
+		reminderHours:   reminderHours,
+		escalationHours: escalationHours,
+	}
+}
+
+func (j *StaleReviewEscalator) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		if err := j.RunOnce(ctx); err != nil {
+			log.Println("stale reviews failed:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *StaleReviewEscalator) RunOnce(ctx context.Context) error {
+	if err := j.remind(ctx); err != nil {
+		return err
+	}
+	return j.escalate(ctx)
+}
+
+func (j *StaleReviewEscalator) remind(ctx context.Context) error {
+	tx, err := j.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, selectReviewsToRemindSQL, j.reminderHours)
+	if err != nil {
+		return err
+	}
+	var reminders []outbox.ReminderPayload
+	for rows.Next() {
+		var p outbox.ReminderPayload
+		if err := rows.Scan(&p.PullRequestID, &p.Title, &p.AuthorID, &p.ReviewerID, &p.AssignedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		reminders = append(reminders, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range reminders {
+		if _, err := tx.ExecContext(ctx, markRemindedSQL, p.PullRequestID, p.ReviewerID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, insertEscalationSQL, p.PullRequestID, p.ReviewerID, dto.EscalationReminded, nil, nil); err != nil {
+			return err
+		}
+		if err := outbox.Write(ctx, tx, outbox.PullRequestAggregate(p.PullRequestID), outbox.EventReviewerReminder, p); err != nil {
+			return err
+		}
+		log.Printf("stale reviews: PR %s: reminded %s", p.PullRequestID, p.ReviewerID)
+	}
+	return tx.Commit()
+}
+
+func (j *StaleReviewEscalator) escalate(ctx context.Context) error {
+	type review struct{ prID, reviewerID string }
+	rows, err := j.db.QueryContext(ctx, selectReviewsToEscalateSQL, j.escalationHours)
+	if err != nil {
+		return err
+	}
+	var reviews []review
+	for rows.Next() {
+		var r review
+		if err := rows.Scan(&r.prID, &r.reviewerID); err != nil {
+			rows.Close()
+			return err
+		}
+		reviews = append(reviews, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range reviews {
+		res, reassignErr := j.svc.Reassign(ctx, r.prID, r.reviewerID)
+		if reassignErr != nil {
+			switch reassignErr.Error() {
+			case dto.ErrorCodePRMerged, dto.ErrorCodeNotAssigned, dto.ErrorCodeNotFound:
+				// PR успели смержить или ревьюера уже сменили
+				continue
+			}
+			log.Printf("stale reviews: PR %s: escalation from %s failed: %v", r.prID, r.reviewerID, reassignErr)
+			if _, err := j.db.ExecContext(ctx, markEscalationFailedSQL, r.prID, r.reviewerID); err != nil {
+				return err
+			}
+			if _, err := j.db.ExecContext(ctx, insertEscalationSQL, r.prID, r.reviewerID, dto.EscalationFailed, nil, reassignErr.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		log.Printf("stale reviews: PR %s: reassigned from %s to %s", r.prID, r.reviewerID, res.ReplacedBy)
+		if _, err := j.db.ExecContext(ctx, insertEscalationSQL, r.prID, r.reviewerID, dto.EscalationReassigned, res.ReplacedBy, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
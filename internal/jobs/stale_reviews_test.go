@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"AvitoInternship/internal/handlers/dto"
+	"AvitoInternship/internal/outbox"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestStaleReviewThresholdZeroDisablesStage(t *testing.T) {
+	// 0 у команды не должен подменяться значением по умолчанию через NULLIF/COALESCE
+	for name, query := range map[string]string{
+		"remind":   selectReviewsToRemindSQL,
+		"escalate": selectReviewsToEscalateSQL,
+	} {
+		if strings.Contains(query, "NULLIF") {
+			t.Errorf("%s: team threshold 0 must not fall back to the default", name)
+		}
+		if !regexp.MustCompile(`COALESCE\(t\.review_\w+_hours, \$1\) > 0`).MatchString(query) {
+			t.Errorf("%s: threshold 0 must disable the stage", name)
+		}
+	}
+}
+
+func TestStaleReviewRemind(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	j := NewStaleReviewEscalator(db, time.Minute, 24, 0, nil)
+
+	assignedAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT prr.pr_id, pr.title, pr.author_id, prr.user_id, prr.assigned_at")).
+		WithArgs(24).
+		WillReturnRows(sqlmock.NewRows([]string{"pr_id", "title", "author_id", "user_id", "assigned_at"}).
+			AddRow("pr-1", "Fix", "u1", "u2", assignedAt))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE pull_request_reviewer SET reminded_at = NOW()")).
+		WithArgs("pr-1", "u2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO review_escalation")).
+		WithArgs("pr-1", "u2", dto.EscalationReminded, nil, nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_event")).
+		WithArgs(outbox.PullRequestAggregate("pr-1"), outbox.EventReviewerReminder, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	// эскалация по умолчанию отключена (0), но пороги команд все равно проверяются
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT prr.pr_id, prr.user_id")).
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"pr_id", "user_id"}))
+
+	if err := j.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"AvitoInternship/internal/handlers/pullRequest"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// окна, которые уже начались, но ревью пользователя по ним еще не переназначались
+const selectStartedUnavailabilitySQL = `
+SELECT id, user_id, reason
+FROM user_unavailability
+WHERE reassigned_at IS NULL AND starts_at <= NOW() AND ends_at > NOW()
+ORDER BY starts_at;
+`
+
+const markUnavailabilityReassignedSQL = `
+UPDATE user_unavailability SET reassigned_at = NOW() WHERE id = $1;
+`
+
+// VacationReassigner переназначает OPEN-ревью пользователей, у которых начался
+// период недоступности. Каждое окно обрабатывается один раз.
+type VacationReassigner struct {
+	db       *sql.DB
+	svc      *pullRequest.PullRequestService
+	interval time.Duration
+}
+
+func NewVacationReassigner(db *sql.DB, interval time.Duration, listener pullRequest.AssignmentListener) *VacationReassigner {
+	return &VacationReassigner{db: db, svc: pullRequest.NewService(db, listener), interval: interval}
+}
+
+func (j *VacationReassigner) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		if err := j.RunOnce(ctx); err != nil {
+			log.Println("vacation reassign failed:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce обрабатывает все начавшиеся окна. Ошибка одного окна не мешает
+// остальным: окно остается необработанным до следующего запуска, а ошибки
+// возвращаются вместе.
+func (j *VacationReassigner) RunOnce(ctx context.Context) error {
+	type window struct {
+		id     int
+		userID string
+		reason string
+	}
+	rows, err := j.db.QueryContext(ctx, selectStartedUnavailabilitySQL)
+	if err != nil {
+		return err
+	}
+	var windows []window
+	for rows.Next() {
+		var w window
+		if err := rows.Scan(&w.id, &w.userID, &w.reason); err != nil {
+			rows.Close()
+			return err
+		}
+		windows = append(windows, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, w := range windows {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		results, err := j.svc.ReassignOpenReviews(ctx, w.userID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("window %d of user %s: %w", w.id, w.userID, err))
+			continue
+		}
+		for _, res := range results {
+			if res.Err != nil {
+				log.Printf("vacation: user %s (%s): PR %s left assigned: %v", w.userID, w.reason, res.PullRequestID, res.Err)
+				continue
+			}
+			log.Printf("vacation: user %s (%s): PR %s reassigned to %s", w.userID, w.reason, res.PullRequestID, res.ReplacedBy)
+		}
+		if _, err := j.db.ExecContext(ctx, markUnavailabilityReassignedSQL, w.id); err != nil {
+			errs = append(errs, fmt.Errorf("window %d of user %s: %w", w.id, w.userID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
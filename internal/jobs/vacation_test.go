@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const selectOpenReviewIDsQuery = `WHERE prr.user_id = $1 AND pr.status = 'OPEN'
+ORDER BY pr.id`
+
+func TestVacationContinuesAfterFailedWindow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	j := NewVacationReassigner(db, time.Minute, nil)
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	mock.ExpectQuery(regexp.QuoteMeta(selectStartedUnavailabilitySQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "reason"}).
+			AddRow(1, "u1", "sick").
+			AddRow(2, "u2", "vacation"))
+
+	// первое окно падает и остается необработанным
+	mock.ExpectQuery(regexp.QuoteMeta(selectOpenReviewIDsQuery)).WithArgs("u1").
+		WillReturnError(errors.New("connection reset"))
+
+	// второе обрабатывается: ревью переходит к u3
+	mock.ExpectQuery(regexp.QuoteMeta(selectOpenReviewIDsQuery)).WithArgs("u2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("pr-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, title, author_id, status, team_id FROM pull_request WHERE id = $1 FOR UPDATE`)).
+		WithArgs("pr-1").WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id", "status", "team_id"}).
+		AddRow("pr-1", "Fix", "a1", "OPEN", 7))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id FROM pull_request_reviewer WHERE pr_id = $1 FOR UPDATE`)).
+		WithArgs("pr-1").WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("u2"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT tag FROM pull_request_tag WHERE pr_id = $1`)).
+		WithArgs("pr-1").WillReturnRows(sqlmock.NewRows([]string{"tag"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, COALESCE(team_id, 0), is_active FROM "user" WHERE id = $1`)).
+		WithArgs("u2").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "team_id", "is_active"}).AddRow("u2", "Petr", 7, true))
+	mock.ExpectQuery(regexp.QuoteMeta(`ORDER BY random()`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "is_active", "unavailable", "skills", "open_reviews", "time_zone", "work_start", "work_end", "max_open_reviews"}).
+			AddRow("u3", true, false, "{}", 0, "UTC", "", "", 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM pull_request_reviewer WHERE pr_id = $1 AND user_id = $2`)).
+		WithArgs("pr-1", "u2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO pull_request_reviewer(pr_id, user_id)`)).
+		WithArgs("pr-1", "u3").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE pull_request SET updated_at = NOW()`)).
+		WithArgs("pr-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id FROM pull_request_reviewer WHERE pr_id = $1 ORDER BY user_id`)).
+		WithArgs("pr-1").WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("u3"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_event")).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(regexp.QuoteMeta(markUnavailabilityReassignedSQL)).
+		WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = j.RunOnce(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "window 1 of user u1: connection reset") {
+		t.Fatalf("err = %v", err)
+	}
+	if !strings.Contains(logs.String(), "vacation: user u2 (vacation): PR pr-1 reassigned to u3") {
+		t.Errorf("logs = %q", logs.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
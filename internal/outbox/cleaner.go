@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// Удаляются доставленные события и события, которые диспетчер уже не
+// доставит: записанные, пока он был выключен (до dispatch_from или при
+// dispatch_from = NULL). Удаление идет пачками, чтобы не держать долгую
+// блокировку.
+const deleteExpiredSQL = `
+DELETE FROM outbox_event
+WHERE id IN (
+    SELECT e.id FROM outbox_event e, outbox_dispatch_state s
+    WHERE e.dispatched_at < NOW() - make_interval(secs => $1)
+       OR (e.created_at < NOW() - make_interval(secs => $1)
+           AND (s.dispatch_from IS NULL OR e.id < s.dispatch_from))
+    LIMIT 10000
+);
+`
+
+const cleanupEvery = time.Hour
+
+// Cleaner удаляет устаревшие события outbox независимо от того, включен ли
+// диспетчер, иначе при выключенном outbox таблица растет бесконечно.
+type Cleaner struct {
+	db        *sql.DB
+	retention time.Duration
+}
+
+// NewCleaner создает очистку событий старше retention (0 - хранятся всегда).
+func NewCleaner(db *sql.DB, retention time.Duration) *Cleaner {
+	return &Cleaner{db: db, retention: retention}
+}
+
+func (c *Cleaner) Run(ctx context.Context) {
+	if c.retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(cleanupEvery)
+	defer ticker.Stop()
+	for {
+		if n, err := c.RunOnce(ctx); err != nil {
+			log.Println("outbox cleanup failed:", err)
+		} else if n > 0 {
+			log.Printf("outbox: removed %d events older than %s", n, c.retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce удаляет устаревшие события и возвращает их число.
+func (c *Cleaner) RunOnce(ctx context.Context) (int64, error) {
+	var total int64
+	for {
+		res, err := c.db.ExecContext(ctx, deleteExpiredSQL, c.retention.Seconds())
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < 10000 {
+			return total, nil
+		}
+	}
+}
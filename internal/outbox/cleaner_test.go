@@ -0,0 +1,36 @@
+package outbox
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCleanerDeletesInBatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	c := NewCleaner(db, 24*time.Hour)
+
+	mock.ExpectExec(regexp.QuoteMeta(deleteExpiredSQL)).
+		WithArgs((24 * time.Hour).Seconds()).
+		WillReturnResult(sqlmock.NewResult(0, 10000))
+	mock.ExpectExec(regexp.QuoteMeta(deleteExpiredSQL)).
+		WillReturnResult(sqlmock.NewResult(0, 7))
+
+	n, err := c.RunOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10007 {
+		t.Errorf("n = %d, want 10007", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
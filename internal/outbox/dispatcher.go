@@ -0,0 +1,220 @@
+package outbox
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"log"
+	"slices"
+	"time"
+)
+
+// Событие выбирается, только если у его агрегата нет более ранних
+// недоставленных событий: так сохраняется порядок внутри одного PR, даже
+// если предыдущее событие ждет повторной попытки. Выбранные события сразу
+// откладываются на время аренды (next_attempt_at) и фиксируются, поэтому
+// доставка идет без открытой транзакции и блокировок строк; если диспетчер
+// упадет посередине, события снова станут доступны после окончания аренды.
+// События до dispatch_from ($3) не доставляются и не задерживают следующие.
+const claimEventsSQL = `
+UPDATE outbox_event
+SET next_attempt_at = NOW() + make_interval(secs => $2)
+WHERE id IN (
+    SELECT e.id
+    FROM outbox_event e
+    WHERE e.id >= $3
+      AND e.dispatched_at IS NULL
+      AND e.failed_at IS NULL
+      AND e.next_attempt_at <= NOW()
+      AND NOT EXISTS (
+          SELECT 1 FROM outbox_event p
+          WHERE p.aggregate_id = e.aggregate_id AND p.dispatched_at IS NULL AND p.failed_at IS NULL
+            AND p.id >= $3 AND p.id < e.id
+      )
+    ORDER BY e.id
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, aggregate_id, event_type, payload, created_at, attempts;
+`
+
+const markDispatchedSQL = `
+UPDATE outbox_event SET dispatched_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1;
+`
+
+const markFailedSQL = `
+UPDATE outbox_event
+SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + make_interval(secs => $3)
+WHERE id = $1;
+`
+
+const markDeadSQL = `
+UPDATE outbox_event SET attempts = attempts + 1, last_error = $2, failed_at = NOW() WHERE id = $1;
+`
+
+// при первом запуске после выключения доставка начинается после последнего
+// записанного события
+const startDispatchSQL = `
+UPDATE outbox_dispatch_state
+SET dispatch_from = COALESCE(dispatch_from, (SELECT COALESCE(MAX(id), 0) + 1 FROM outbox_event))
+RETURNING dispatch_from;
+`
+
+const stopDispatchSQL = `
+UPDATE outbox_dispatch_state SET dispatch_from = NULL;
+`
+
+const (
+	// время, на которое выбранное событие скрыто от других диспетчеров
+	claimLease = 5 * time.Minute
+	// ограничение на одну публикацию, чтобы пачка укладывалась в аренду
+	publishTimeout = 30 * time.Second
+)
+
+type Dispatcher struct {
+	db          *sql.DB
+	sink        Sink
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	// первое доставляемое событие; 0 - Start еще не вызывался
+	from int64
+}
+
+// NewDispatcher создает диспетчер. Событие, не доставленное за maxAttempts
+// попыток, помечается failed_at и больше не повторяется.
+func NewDispatcher(db *sql.DB, sink Sink, interval time.Duration, batchSize, maxAttempts int) *Dispatcher {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	return &Dispatcher{
+		db:          db,
+		sink:        sink,
+		interval:    interval,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		minBackoff:  time.Second,
+		maxBackoff:  10 * time.Minute,
+	}
+}
+
+// Start запоминает позицию, с которой диспетчер доставляет события. Если
+// диспетчер был выключен (StopDispatch), события, записанные до этого
+// момента, не доставляются.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	return d.db.QueryRowContext(ctx, startDispatchSQL).Scan(&d.from)
+}
+
+// StopDispatch отмечает, что диспетчер выключен: при следующем запуске он не
+// будет доставлять события, накопленные до него.
+func StopDispatch(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, stopDispatchSQL)
+	return err
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		if d.from == 0 {
+			// без позиции доставлять нечего: повторяем на следующем тике
+			if err := d.Start(ctx); err != nil {
+				log.Println("outbox dispatch start failed:", err)
+			} else {
+				log.Printf("outbox: dispatching events from id %d", d.from)
+				continue
+			}
+		} else {
+			// пока есть полные пачки, разбираем очередь без ожидания
+			n, err := d.RunOnce(ctx)
+			if err != nil {
+				log.Println("outbox dispatch failed:", err)
+			}
+			if err == nil && n == d.batchSize {
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type claimedEvent struct {
+	Event
+	attempts int
+}
+
+// RunOnce доставляет одну пачку событий и возвращает число обработанных.
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	events, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range events {
+		if err := d.publish(ctx, e); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+func (d *Dispatcher) claim(ctx context.Context) ([]claimedEvent, error) {
+	rows, err := d.db.QueryContext(ctx, claimEventsSQL, d.batchSize, claimLease.Seconds(), d.from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []claimedEvent
+	for rows.Next() {
+		var c claimedEvent
+		if err := rows.Scan(&c.ID, &c.AggregateID, &c.Type, &c.Payload, &c.CreatedAt, &c.attempts); err != nil {
+			return nil, err
+		}
+		events = append(events, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING не гарантирует порядок
+	slices.SortFunc(events, func(a, b claimedEvent) int { return cmp.Compare(a.ID, b.ID) })
+	return events, nil
+}
+
+// publish отправляет событие и записывает результат: доставлено, повтор с
+// задержкой или, после maxAttempts попыток, failed_at.
+func (d *Dispatcher) publish(ctx context.Context, e claimedEvent) error {
+	pctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	pubErr := d.sink.Publish(pctx, e.Event)
+	cancel()
+	// результат записывается, даже если ctx отменен посередине публикации
+	wctx := context.WithoutCancel(ctx)
+	if pubErr == nil {
+		_, err := d.db.ExecContext(wctx, markDispatchedSQL, e.ID)
+		return err
+	}
+	if e.attempts+1 >= d.maxAttempts {
+		log.Printf("outbox: event %d (%s) failed after %d attempts, giving up: %v", e.ID, e.Type, e.attempts+1, pubErr)
+		_, err := d.db.ExecContext(wctx, markDeadSQL, e.ID, pubErr.Error())
+		return err
+	}
+	backoff := d.backoff(e.attempts)
+	log.Printf("outbox: event %d (%s) failed, retry in %s: %v", e.ID, e.Type, backoff, pubErr)
+	_, err := d.db.ExecContext(wctx, markFailedSQL, e.ID, pubErr.Error(), backoff.Seconds())
+	return err
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	b := d.minBackoff
+	for i := 0; i < attempts && b < d.maxBackoff; i++ {
+		b *= 2
+	}
+	return min(b, d.maxBackoff)
+}
@@ -0,0 +1,107 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type recordingSink struct {
+	published []int64
+	fail      map[int64]error
+}
+
+func (s *recordingSink) Publish(_ context.Context, e Event) error {
+	s.published = append(s.published, e.ID)
+	return s.fail[e.ID]
+}
+
+var claimedColumns = []string{"id", "aggregate_id", "event_type", "payload", "created_at", "attempts"}
+
+func TestRunOncePublishesOutsideTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	now := time.Now()
+	sink := &recordingSink{fail: map[int64]error{
+		2: errors.New("sink is down"),
+		3: errors.New("still down"),
+	}}
+	d := NewDispatcher(db, sink, time.Second, 10, 5)
+	d.from = 1
+
+	// ни Begin, ни Commit: события выбираются одним UPDATE ... RETURNING
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE outbox_event")).
+		WithArgs(10, claimLease.Seconds(), int64(1)).
+		WillReturnRows(sqlmock.NewRows(claimedColumns).
+			AddRow(3, "pr:c", EventPRCreated, []byte(`{}`), now, 4).
+			AddRow(1, "pr:a", EventPRCreated, []byte(`{}`), now, 0).
+			AddRow(2, "pr:b", EventPRMerged, []byte(`{}`), now, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE outbox_event SET dispatched_at = NOW()")).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// вторая попытка: задержка minBackoff*2
+	mock.ExpectExec(regexp.QuoteMeta("next_attempt_at = NOW() + make_interval(secs => $3)")).
+		WithArgs(int64(2), "sink is down", 2.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// пятая попытка из пяти: событие уходит в failed_at
+	mock.ExpectExec(regexp.QuoteMeta("failed_at = NOW()")).
+		WithArgs(int64(3), "still down").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := d.RunOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("n = %d, want 3", n)
+	}
+	if want := []int64{1, 2, 3}; !slices.Equal(sink.published, want) {
+		t.Errorf("published %v, want %v in id order", sink.published, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStartSkipsEventsWrittenWhileStopped(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	d := NewDispatcher(db, &recordingSink{}, time.Second, 10, 5)
+
+	// dispatch_from = NULL: позиция ставится после последнего события
+	mock.ExpectQuery(regexp.QuoteMeta(startDispatchSQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"dispatch_from"}).AddRow(42))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE outbox_event")).
+		WithArgs(10, claimLease.Seconds(), int64(42)).
+		WillReturnRows(sqlmock.NewRows(claimedColumns))
+
+	if err := d.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := d.RunOnce(context.Background()); err != nil || n != 0 {
+		t.Fatalf("RunOnce = %d, %v", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(nil, nil, time.Second, 0, 0)
+	for attempts, want := range map[int]time.Duration{0: time.Second, 1: 2 * time.Second, 3: 8 * time.Second, 30: 10 * time.Minute} {
+		if got := d.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Package outbox реализует transactional outbox: доменные события пишутся в
+// таблицу outbox_event в той же транзакции, что и изменение данных, а
+// Dispatcher доставляет их во внешние получатели (Sink) минимум один раз.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventPRCreated          = "pr.created"
+	EventPRMerged           = "pr.merged"
+	EventReviewerReassigned = "reviewer.reassigned"
+	EventUserActivated      = "user.activated"
+	EventUserDeactivated    = "user.deactivated"
+	EventUserDeleted        = "user.deleted"
+	EventReviewerReminder   = "reviewer.reminder"
+)
+
+// EventTypes перечисляет все типы событий, которые пишет сервис.
+var EventTypes = []string{
+	EventPRCreated,
+	EventPRMerged,
+	EventReviewerReassigned,
+	EventUserActivated,
+	EventUserDeactivated,
+	EventUserDeleted,
+	EventReviewerReminder,
+}
+
+type Event struct {
+	ID          int64           `json:"id"`
+	AggregateID string          `json:"aggregate_id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// PullRequestPayload - данные событий pr.created и pr.merged
+type PullRequestPayload struct {
+	PullRequestID string   `json:"pull_request_id"`
+	Title         string   `json:"pull_request_name"`
+	AuthorID      string   `json:"author_id"`
+	Status        string   `json:"status"`
+	Repository    string   `json:"repository,omitempty"`
+	Reviewers     []string `json:"assigned_reviewers"`
+}
+
+// ReassignPayload - данные события reviewer.reassigned
+type ReassignPayload struct {
+	PullRequestID string   `json:"pull_request_id"`
+	Title         string   `json:"pull_request_name"`
+	AuthorID      string   `json:"author_id"`
+	OldReviewerID string   `json:"old_reviewer_id"`
+	NewReviewerID string   `json:"new_reviewer_id"`
+	Reviewers     []string `json:"assigned_reviewers"`
+}
+
+// ReminderPayload - данные события reviewer.reminder: ревьюер не отреагировал
+// на PR дольше SLA команды
+type ReminderPayload struct {
+	PullRequestID string    `json:"pull_request_id"`
+	Title         string    `json:"pull_request_name"`
+	AuthorID      string    `json:"author_id"`
+	ReviewerID    string    `json:"reviewer_id"`
+	AssignedAt    time.Time `json:"assigned_at"`
+}
+
+// UserPayload - данные событий user.activated, user.deactivated и
+// user.deleted (для user.deleted - последнее состояние пользователя)
+type UserPayload struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	TeamName string `json:"team_name"`
+	IsActive bool   `json:"is_active"`
+}
+
+// PullRequestAggregate и UserAggregate задают ключ упорядочивания: события
+// одного агрегата доставляются строго по порядку.
+func PullRequestAggregate(prID string) string { return "pr:" + prID }
+
+func UserAggregate(userID string) string { return "user:" + userID }
+
+const insertEventSQL = `
+INSERT INTO outbox_event(aggregate_id, event_type, payload) VALUES ($1, $2, $3);
+`
+
+// Write добавляет событие в outbox в рамках транзакции tx.
+func Write(ctx context.Context, tx *sql.Tx, aggregateID, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, insertEventSQL, aggregateID, eventType, data)
+	return err
+}
+
+// Decode разбирает полезную нагрузку события в v.
+func (e Event) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
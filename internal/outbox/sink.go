@@ -0,0 +1,109 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sink получает события из outbox. Publish может вызываться повторно для
+// одного и того же события (доставка at-least-once), поэтому получатели
+// должны быть идемпотентными по Event.ID.
+type Sink interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// WriterSink пишет события в виде JSON Lines, например в stdout.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewStdoutSink() *WriterSink {
+	return &WriterSink{w: os.Stdout}
+}
+
+func (s *WriterSink) Publish(_ context.Context, e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.NewEncoder(s.w).Encode(e)
+}
+
+// FileSink дописывает события в файл в формате JSON Lines.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Publish(_ context.Context, e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(e); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// HTTPSink отправляет каждое событие POST-запросом с JSON-телом.
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", e.Type)
+	req.Header.Set("X-Event-ID", fmt.Sprint(e.ID))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("outbox http sink: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// MultiSink публикует событие во все получатели. Если хотя бы один вернул
+// ошибку, событие будет доставлено повторно во все.
+type MultiSink []Sink
+
+func (m MultiSink) Publish(ctx context.Context, e Event) error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Publish(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -3,10 +3,12 @@ package models
 import "time"
 
 type PullRequest struct {
-	ID        string
-	Title     string
-	AuthorID  string
-	Status    string
-	CreatedAt time.Time
-	UpdatedAt *time.Time
+	ID         string
+	Title      string
+	AuthorID   string
+	Status     string
+	TeamName   string
+	Repository string
+	CreatedAt  time.Time
+	UpdatedAt  *time.Time
 }
@@ -5,4 +5,16 @@ type User struct {
 	Name     string
 	TeamName string
 	IsActive bool
+	Skills   []string
+
+	TimeZone       string
+	WorkStart      string
+	WorkEnd        string
+	MaxOpenReviews int
+	ChatHandle     string
+	ChatMuted      bool
+	Email          string
+	DisplayName    string
+	// логины во внешних системах по провайдеру
+	ExternalLogins map[string]string
 }
@@ -0,0 +1,209 @@
+package subscriptions
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Выбранные доставки откладываются на время аренды и фиксируются сразу, как в
+// outbox.Dispatcher: HTTP-запросы идут без открытой транзакции.
+const claimDeliveriesSQL = `
+UPDATE webhook_subscription_delivery d
+SET next_attempt_at = NOW() + make_interval(secs => $2)
+FROM webhook_subscription s
+WHERE s.id = d.subscription_id
+  AND d.id IN (
+      SELECT id FROM webhook_subscription_delivery
+      WHERE status = 'pending' AND next_attempt_at <= NOW()
+      ORDER BY id
+      LIMIT $1
+      FOR UPDATE SKIP LOCKED
+  )
+RETURNING d.id, d.event_id, d.event_type, d.payload, d.attempts, s.url, s.secret;
+`
+
+// время, на которое выбранная доставка скрыта от других обработчиков
+const claimLease = 5 * time.Minute
+
+const markDeliveredSQL = `
+UPDATE webhook_subscription_delivery
+SET status = 'delivered', attempts = attempts + 1, response_status = $2, last_error = NULL, delivered_at = NOW()
+WHERE id = $1;
+`
+
+const markRetrySQL = `
+UPDATE webhook_subscription_delivery
+SET attempts = attempts + 1, response_status = $2, last_error = $3, next_attempt_at = NOW() + make_interval(secs => $4)
+WHERE id = $1;
+`
+
+const markDeliveryFailedSQL = `
+UPDATE webhook_subscription_delivery
+SET status = 'failed', attempts = attempts + 1, response_status = $2, last_error = $3
+WHERE id = $1;
+`
+
+// SignatureHeader содержит "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)),
+// где timestamp - значение TimestampHeader (Unix-время отправки в секундах).
+// Подписанное время позволяет получателю отбрасывать перехваченные и
+// повторно отправленные запросы.
+const (
+	SignatureHeader = "X-Signature-256"
+	TimestampHeader = "X-Signature-Timestamp"
+)
+
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliverer отправляет накопленные доставки. Неуспешная доставка
+// повторяется с экспоненциальной задержкой от backoff, после attempts
+// попыток получает статус failed и остается в истории подписки.
+type Deliverer struct {
+	db         *sql.DB
+	client     *http.Client
+	interval   time.Duration
+	batchSize  int
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func NewDeliverer(db *sql.DB, interval time.Duration, attempts int, backoff time.Duration) *Deliverer {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &Deliverer{
+		db:         db,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		interval:   interval,
+		batchSize:  50,
+		attempts:   attempts,
+		backoff:    backoff,
+		maxBackoff: time.Hour,
+	}
+}
+
+func (d *Deliverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		n, err := d.RunOnce(ctx)
+		if err != nil {
+			log.Println("subscriptions: delivery failed:", err)
+		}
+		if err == nil && n == d.batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type delivery struct {
+	id        int64
+	eventID   int64
+	eventType string
+	payload   []byte
+	attempts  int
+	url       string
+	secret    string
+}
+
+// RunOnce отправляет одну пачку доставок и возвращает их число.
+func (d *Deliverer) RunOnce(ctx context.Context) (int, error) {
+	batch, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// результат записывается, даже если ctx отменен посередине отправки
+	wctx := context.WithoutCancel(ctx)
+	for _, dl := range batch {
+		status, sendErr := d.send(ctx, dl)
+		respStatus := sql.NullInt64{Int64: int64(status), Valid: status != 0}
+		switch {
+		case sendErr == nil:
+			_, err = d.db.ExecContext(wctx, markDeliveredSQL, dl.id, respStatus)
+		case dl.attempts+1 >= d.attempts:
+			log.Printf("subscriptions: delivery %d to %s gave up after %d attempts: %v", dl.id, dl.url, dl.attempts+1, sendErr)
+			_, err = d.db.ExecContext(wctx, markDeliveryFailedSQL, dl.id, respStatus, sendErr.Error())
+		default:
+			_, err = d.db.ExecContext(wctx, markRetrySQL, dl.id, respStatus, sendErr.Error(), d.delay(dl.attempts).Seconds())
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(batch), nil
+}
+
+func (d *Deliverer) claim(ctx context.Context) ([]delivery, error) {
+	rows, err := d.db.QueryContext(ctx, claimDeliveriesSQL, d.batchSize, claimLease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var batch []delivery
+	for rows.Next() {
+		var dl delivery
+		if err := rows.Scan(&dl.id, &dl.eventID, &dl.eventType, &dl.payload, &dl.attempts, &dl.url, &dl.secret); err != nil {
+			return nil, err
+		}
+		batch = append(batch, dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(batch, func(a, b delivery) int { return cmp.Compare(a.id, b.id) })
+	return batch, nil
+}
+
+func (d *Deliverer) send(ctx context.Context, dl delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dl.url, bytes.NewReader(dl.payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", dl.eventType)
+	req.Header.Set("X-Event-ID", fmt.Sprint(dl.eventID))
+	req.Header.Set("X-Delivery-ID", fmt.Sprint(dl.id))
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(dl.secret, timestamp, dl.payload))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Deliverer) delay(attempts int) time.Duration {
+	b := d.backoff
+	for i := 0; i < attempts && b < d.maxBackoff; i++ {
+		b *= 2
+	}
+	return min(b, d.maxBackoff)
+}
@@ -0,0 +1,80 @@
+package subscriptions
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"id":1}' | openssl dgst -sha256 -hmac secret
+	const want = "sha256=3dd1b9aef568d75f6790a84bd2e5dfa1f44409eef3cbdbd3f10b837376100c11"
+	got := Sign("secret", "1700000000", []byte(`{"id":1}`))
+	if got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if got == Sign("secret", "1700000001", []byte(`{"id":1}`)) {
+		t.Error("signature must depend on the timestamp")
+	}
+	if got == Sign("other", "1700000000", []byte(`{"id":1}`)) {
+		t.Error("signature must depend on the secret")
+	}
+}
+
+func TestRunOnceSignsAndRecordsResult(t *testing.T) {
+	var gotSig, gotTS, gotBody string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotSig, gotTS, gotBody = r.Header.Get(SignatureHeader), r.Header.Get(TimestampHeader), string(body)
+		if r.Header.Get("X-Delivery-ID") == "2" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	d := NewDeliverer(db, time.Second, 3, time.Second)
+
+	cols := []string{"id", "event_id", "event_type", "payload", "attempts", "url", "secret"}
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE webhook_subscription_delivery d")).
+		WithArgs(50, claimLease.Seconds()).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(2, 11, "pr.merged", []byte(`{"id":11}`), 2, receiver.URL, "s2").
+			AddRow(1, 10, "pr.created", []byte(`{"id":10}`), 0, receiver.URL, "s1"))
+	mock.ExpectExec(regexp.QuoteMeta("SET status = 'delivered'")).
+		WithArgs(int64(1), int64(200)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SET status = 'failed'")).
+		WithArgs(int64(2), int64(503), "status 503").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := d.RunOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("n = %d, want 2", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	ts, err := strconv.ParseInt(gotTS, 10, 64)
+	if err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+		t.Errorf("timestamp header %q is not the current Unix time", gotTS)
+	}
+	if want := Sign("s2", gotTS, []byte(gotBody)); gotSig != want {
+		t.Errorf("signature = %s, want %s", gotSig, want)
+	}
+}
@@ -0,0 +1,38 @@
+// Package subscriptions доставляет доменные события из outbox во внешние
+// сервисы, подписанные через /subscriptions/add. Sink раскладывает каждое
+// событие в очередь доставок по подходящим подпискам, Deliverer отправляет
+// их с HMAC-подписью и повторами.
+package subscriptions
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"AvitoInternship/internal/outbox"
+)
+
+const enqueueDeliveriesSQL = `
+INSERT INTO webhook_subscription_delivery(subscription_id, event_id, event_type, payload)
+SELECT id, $1, $2, $3 FROM webhook_subscription WHERE $2 = ANY(event_types)
+ON CONFLICT (subscription_id, event_id) DO NOTHING;
+`
+
+// Sink реализует outbox.Sink. Повторная публикация того же события
+// идемпотентна благодаря уникальности (subscription_id, event_id).
+type Sink struct {
+	db *sql.DB
+}
+
+func NewSink(db *sql.DB) *Sink {
+	return &Sink{db: db}
+}
+
+func (s *Sink) Publish(ctx context.Context, e outbox.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, enqueueDeliveriesSQL, e.ID, e.Type, body)
+	return err
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_pull_request_repository;
+
+ALTER TABLE pull_request
+    DROP COLUMN IF EXISTS repository,
+    DROP COLUMN IF EXISTS team_id;
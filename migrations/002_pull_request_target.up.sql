@@ -0,0 +1,5 @@
+ALTER TABLE pull_request
+    ADD COLUMN team_id    INT REFERENCES team(id),
+    ADD COLUMN repository TEXT;
+
+CREATE INDEX idx_pull_request_repository ON pull_request(repository);
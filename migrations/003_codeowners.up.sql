@@ -0,0 +1,6 @@
+CREATE TABLE codeowners (
+    repository TEXT PRIMARY KEY,
+    team_id    INT NOT NULL REFERENCES team(id),
+    content    TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pull_request_tag;
+DROP TABLE IF EXISTS user_skill;
@@ -0,0 +1,11 @@
+CREATE TABLE user_skill (
+    user_id TEXT NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+    tag     TEXT NOT NULL,
+    PRIMARY KEY (user_id, tag)
+);
+
+CREATE TABLE pull_request_tag (
+    pr_id TEXT NOT NULL REFERENCES pull_request(id) ON DELETE CASCADE,
+    tag   TEXT NOT NULL,
+    PRIMARY KEY (pr_id, tag)
+);
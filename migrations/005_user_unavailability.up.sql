@@ -0,0 +1,12 @@
+CREATE TABLE user_unavailability (
+    id            SERIAL PRIMARY KEY,
+    user_id       TEXT NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+    starts_at     TIMESTAMPTZ NOT NULL,
+    ends_at       TIMESTAMPTZ NOT NULL,
+    reason        TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    reassigned_at TIMESTAMP DEFAULT NULL,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_user_unavailability_user ON user_unavailability(user_id, starts_at);
@@ -0,0 +1,4 @@
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS work_end,
+    DROP COLUMN IF EXISTS work_start,
+    DROP COLUMN IF EXISTS time_zone;
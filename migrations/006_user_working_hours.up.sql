@@ -0,0 +1,4 @@
+ALTER TABLE "user"
+    ADD COLUMN time_zone  TEXT NOT NULL DEFAULT 'UTC',
+    ADD COLUMN work_start TEXT,
+    ADD COLUMN work_end   TEXT;
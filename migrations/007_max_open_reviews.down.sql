@@ -0,0 +1,5 @@
+ALTER TABLE team
+    DROP COLUMN IF EXISTS default_max_open_reviews;
+
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS max_open_reviews;
@@ -0,0 +1,5 @@
+ALTER TABLE "user"
+    ADD COLUMN max_open_reviews INT CHECK (max_open_reviews > 0);
+
+ALTER TABLE team
+    ADD COLUMN default_max_open_reviews INT CHECK (default_max_open_reviews > 0);
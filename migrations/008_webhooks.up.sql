@@ -0,0 +1,15 @@
+CREATE TABLE external_login (
+    provider TEXT NOT NULL,
+    login    TEXT NOT NULL,
+    user_id  TEXT NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+    PRIMARY KEY (provider, login),
+    UNIQUE (provider, user_id)
+);
+
+CREATE TABLE webhook_delivery (
+    provider    TEXT NOT NULL,
+    delivery_id TEXT NOT NULL,
+    event       TEXT NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, delivery_id)
+);
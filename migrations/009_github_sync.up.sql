@@ -0,0 +1,9 @@
+CREATE TABLE github_sync_dead_letter (
+    id         BIGSERIAL PRIMARY KEY,
+    pr_id      TEXT NOT NULL,
+    action     TEXT NOT NULL CHECK (action IN ('request', 'remove')),
+    logins     TEXT[] NOT NULL,
+    error      TEXT NOT NULL,
+    attempts   INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
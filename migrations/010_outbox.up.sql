@@ -0,0 +1,13 @@
+CREATE TABLE outbox_event (
+    id              BIGSERIAL PRIMARY KEY,
+    aggregate_id    TEXT NOT NULL,
+    event_type      TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error      TEXT,
+    dispatched_at   TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX idx_outbox_event_pending ON outbox_event(aggregate_id, id) WHERE dispatched_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS chat_muted,
+    DROP COLUMN IF EXISTS chat_handle;
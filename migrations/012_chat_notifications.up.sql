@@ -0,0 +1,3 @@
+ALTER TABLE "user"
+    ADD COLUMN chat_handle TEXT,
+    ADD COLUMN chat_muted  BOOLEAN NOT NULL DEFAULT FALSE;
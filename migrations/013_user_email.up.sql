@@ -0,0 +1 @@
+ALTER TABLE "user" ADD COLUMN email TEXT;
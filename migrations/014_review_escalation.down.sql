@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS review_escalation;
+
+ALTER TABLE team
+    DROP COLUMN IF EXISTS review_escalation_hours,
+    DROP COLUMN IF EXISTS review_reminder_hours;
+
+ALTER TABLE pull_request_reviewer
+    DROP COLUMN IF EXISTS escalated_at,
+    DROP COLUMN IF EXISTS reminded_at,
+    DROP COLUMN IF EXISTS assigned_at;
@@ -0,0 +1,20 @@
+ALTER TABLE pull_request_reviewer
+    ADD COLUMN assigned_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN reminded_at  TIMESTAMPTZ,
+    ADD COLUMN escalated_at TIMESTAMPTZ;
+
+ALTER TABLE team
+    ADD COLUMN review_reminder_hours   INT CHECK (review_reminder_hours > 0),
+    ADD COLUMN review_escalation_hours INT CHECK (review_escalation_hours > 0);
+
+CREATE TABLE review_escalation (
+    id              BIGSERIAL PRIMARY KEY,
+    pr_id           TEXT NOT NULL REFERENCES pull_request(id) ON DELETE CASCADE,
+    reviewer_id     TEXT NOT NULL,
+    action          TEXT NOT NULL CHECK (action IN ('reminded', 'reassigned', 'failed')),
+    new_reviewer_id TEXT,
+    error           TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX review_escalation_pr_idx ON review_escalation (pr_id, id);
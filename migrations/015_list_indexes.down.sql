@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_team_name;
+DROP INDEX IF EXISTS idx_pull_request_author;
+DROP INDEX IF EXISTS idx_pull_request_created_at;
@@ -0,0 +1,4 @@
+-- постраничная выдача списков идет по (ключ сортировки, id)
+CREATE INDEX idx_pull_request_created_at ON pull_request(created_at, id);
+CREATE INDEX idx_pull_request_author ON pull_request(author_id, created_at);
+CREATE INDEX idx_user_team_name ON "user"(team_id, name, id);
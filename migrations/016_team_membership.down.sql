@@ -0,0 +1,2 @@
+-- пользователей без команды перед откатом нужно вернуть в команды
+ALTER TABLE "user" ALTER COLUMN team_id SET NOT NULL;
@@ -0,0 +1,3 @@
+-- пользователь, удаленный из команды, остается в базе без команды: на него
+-- ссылаются его PR и история ревью
+ALTER TABLE "user" ALTER COLUMN team_id DROP NOT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE pull_request_reviewer
+    DROP CONSTRAINT pull_request_reviewer_user_id_fkey,
+    ADD CONSTRAINT pull_request_reviewer_user_id_fkey FOREIGN KEY (user_id) REFERENCES "user"(id);
+-- PR удаленных пользователей не проверяются: их авторов уже нет
+ALTER TABLE pull_request
+    ADD CONSTRAINT pull_request_author_id_fkey FOREIGN KEY (author_id) REFERENCES "user"(id) NOT VALID;
+ALTER TABLE "user" DROP COLUMN IF EXISTS display_name;
@@ -0,0 +1,8 @@
+ALTER TABLE "user" ADD COLUMN display_name TEXT;
+
+-- удаленный пользователь остается автором своих MERGED PR по id, как ревьюер в
+-- review_escalation; назначения ревью удаляются вместе с ним
+ALTER TABLE pull_request DROP CONSTRAINT pull_request_author_id_fkey;
+ALTER TABLE pull_request_reviewer
+    DROP CONSTRAINT pull_request_reviewer_user_id_fkey,
+    ADD CONSTRAINT pull_request_reviewer_user_id_fkey FOREIGN KEY (user_id) REFERENCES "user"(id) ON DELETE CASCADE;
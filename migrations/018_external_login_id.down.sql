@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS external_login_external_id_idx;
+ALTER TABLE external_login DROP COLUMN IF EXISTS external_id;
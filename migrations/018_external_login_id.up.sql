@@ -0,0 +1,4 @@
+-- числовой id пользователя на код-хостинге: в Merge Request Hook GitLab автор
+-- MR указан только по id
+ALTER TABLE external_login ADD COLUMN external_id BIGINT;
+CREATE UNIQUE INDEX external_login_external_id_idx ON external_login (provider, external_id) WHERE external_id IS NOT NULL;
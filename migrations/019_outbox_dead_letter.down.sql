@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_outbox_event_dispatched;
+DROP INDEX IF EXISTS idx_outbox_event_pending;
+CREATE INDEX idx_outbox_event_pending ON outbox_event(aggregate_id, id) WHERE dispatched_at IS NULL;
+ALTER TABLE outbox_event DROP COLUMN IF EXISTS failed_at;
@@ -0,0 +1,8 @@
+-- событие, не доставленное за OUTBOX_MAX_ATTEMPTS попыток, помечается failed_at
+-- и больше не задерживает следующие события своего агрегата
+ALTER TABLE outbox_event ADD COLUMN failed_at TIMESTAMP DEFAULT NULL;
+
+DROP INDEX IF EXISTS idx_outbox_event_pending;
+CREATE INDEX idx_outbox_event_pending ON outbox_event(aggregate_id, id) WHERE dispatched_at IS NULL AND failed_at IS NULL;
+-- для удаления доставленных событий старше OUTBOX_RETENTION
+CREATE INDEX idx_outbox_event_dispatched ON outbox_event(dispatched_at) WHERE dispatched_at IS NOT NULL;
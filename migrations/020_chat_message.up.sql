@@ -0,0 +1,19 @@
+-- очередь сообщений в чат: у каждого сообщения свои попытки и задержка,
+-- поэтому недоступный чат не задерживает outbox и другие sink'и
+CREATE TABLE chat_message (
+    id              BIGSERIAL PRIMARY KEY,
+    event_id        BIGINT NOT NULL,
+    user_id         TEXT NOT NULL,
+    handle          TEXT NOT NULL,
+    text            TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at    TIMESTAMPTZ,
+    UNIQUE (event_id, user_id)
+);
+
+CREATE INDEX chat_message_pending_idx
+    ON chat_message (next_attempt_at) WHERE status = 'pending';
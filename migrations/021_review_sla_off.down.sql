@@ -0,0 +1,8 @@
+UPDATE team SET review_reminder_hours = NULL WHERE review_reminder_hours = 0;
+UPDATE team SET review_escalation_hours = NULL WHERE review_escalation_hours = 0;
+
+ALTER TABLE team
+    DROP CONSTRAINT IF EXISTS team_review_reminder_hours_check,
+    DROP CONSTRAINT IF EXISTS team_review_escalation_hours_check,
+    ADD CONSTRAINT team_review_reminder_hours_check CHECK (review_reminder_hours > 0),
+    ADD CONSTRAINT team_review_escalation_hours_check CHECK (review_escalation_hours > 0);
@@ -0,0 +1,6 @@
+-- NULL - значение по умолчанию из конфигурации, 0 - этап отключен для команды
+ALTER TABLE team
+    DROP CONSTRAINT IF EXISTS team_review_reminder_hours_check,
+    DROP CONSTRAINT IF EXISTS team_review_escalation_hours_check,
+    ADD CONSTRAINT team_review_reminder_hours_check CHECK (review_reminder_hours >= 0),
+    ADD CONSTRAINT team_review_escalation_hours_check CHECK (review_escalation_hours >= 0);
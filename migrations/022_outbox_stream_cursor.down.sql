@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_outbox_event_old_reviewer;
+DROP INDEX IF EXISTS idx_outbox_event_new_reviewer;
+DROP INDEX IF EXISTS idx_outbox_event_assigned_reviewers;
+DROP INDEX IF EXISTS idx_outbox_event_txid;
+ALTER TABLE outbox_event DROP COLUMN IF EXISTS txid;
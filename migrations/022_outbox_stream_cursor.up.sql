@@ -0,0 +1,13 @@
+-- txid - транзакция, записавшая событие. Поток ревью читает события в порядке
+-- (txid, id) и только из транзакций младше pg_snapshot_xmin: id выдается до
+-- commit, и событие с меньшим id может стать видимым позже уже прочитанного.
+ALTER TABLE outbox_event ADD COLUMN txid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX idx_outbox_event_txid ON outbox_event(txid, id);
+
+-- выборка событий ревьюера: payload->'assigned_reviewers' ? $1 и смена ревьюера
+CREATE INDEX idx_outbox_event_assigned_reviewers ON outbox_event USING GIN ((payload->'assigned_reviewers'));
+CREATE INDEX idx_outbox_event_new_reviewer ON outbox_event((payload->>'new_reviewer_id'))
+    WHERE event_type = 'reviewer.reassigned';
+CREATE INDEX idx_outbox_event_old_reviewer ON outbox_event((payload->>'old_reviewer_id'))
+    WHERE event_type = 'reviewer.reassigned';
@@ -0,0 +1,16 @@
+-- до этой миграции удаление пользователя каскадом стирало его ревью, в том
+-- числе историю MERGED PR; надгробия нельзя удалить, не потеряв ее, поэтому
+-- откат возможен только без них (их можно восстановить повторным добавлением
+-- или удалить вручную вместе с историей)
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM "user" WHERE deleted_at IS NOT NULL) THEN
+        RAISE EXCEPTION 'cannot roll back 023_user_tombstone: deleted users keep PR and review history';
+    END IF;
+END $$;
+
+ALTER TABLE pull_request_reviewer
+    DROP CONSTRAINT pull_request_reviewer_user_id_fkey,
+    ADD CONSTRAINT pull_request_reviewer_user_id_fkey FOREIGN KEY (user_id) REFERENCES "user"(id) ON DELETE CASCADE;
+ALTER TABLE pull_request DROP CONSTRAINT pull_request_author_id_fkey;
+ALTER TABLE "user" DROP COLUMN deleted_at;
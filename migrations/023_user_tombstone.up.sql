@@ -0,0 +1,15 @@
+-- пользователь с историей (автор PR или ревьюер MERGED PR) при удалении
+-- остается надгробием с deleted_at: внешние ключи на него сохраняются
+ALTER TABLE "user" ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- авторы, удаленные до этой миграции, возвращаются надгробиями
+INSERT INTO "user"(id, name, is_active, deleted_at)
+SELECT DISTINCT pr.author_id, pr.author_id, FALSE, NOW()
+FROM pull_request pr
+WHERE NOT EXISTS (SELECT 1 FROM "user" u WHERE u.id = pr.author_id);
+
+ALTER TABLE pull_request
+    ADD CONSTRAINT pull_request_author_id_fkey FOREIGN KEY (author_id) REFERENCES "user"(id);
+ALTER TABLE pull_request_reviewer
+    DROP CONSTRAINT pull_request_reviewer_user_id_fkey,
+    ADD CONSTRAINT pull_request_reviewer_user_id_fkey FOREIGN KEY (user_id) REFERENCES "user"(id);
@@ -0,0 +1,11 @@
+-- dispatch_from - первое событие, которое доставляет диспетчер; NULL - диспетчер
+-- выключен, и при включении доставка начнется с текущей позиции, а не со всей
+-- накопленной истории
+CREATE TABLE outbox_dispatch_state (
+    id            BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    dispatch_from BIGINT
+);
+
+-- если диспетчер уже работал, очередь доставляется как раньше
+INSERT INTO outbox_dispatch_state(dispatch_from)
+SELECT CASE WHEN EXISTS (SELECT 1 FROM outbox_event WHERE attempts > 0) THEN 0 END;